@@ -0,0 +1,62 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+// runs the copy subcommand, which copies the introspected tables with
+// their rows into the target database.
+func copy_cmd(args []string) {
+	fs := flag.NewFlagSet("copy", flag.ExitOnError)
+	srcf := new_dbflags(fs, "")
+	dstf := new_dbflags(fs, "target-")
+	tables := fs.String("tables", "",
+		"comma separated tables names to copy, defaults to all tables")
+	batch := fs.Int("batch", 1000, "number of rows copied per batch")
+	nofk := fs.Bool("disable-fk", false,
+		"disable the target foreign key checks while copying")
+	fs.Parse(args)
+	dstf.debug = srcf.debug
+
+	src, err := open_database(srcf)
+	if err != nil {
+		fail(err)
+	}
+	list, err := describe(src, *tables)
+	if err != nil {
+		src.Shutdown()
+		fail(err)
+	}
+	metainfo := table_models(list, *dstf.backend != *srcf.backend)
+
+	dst, err := open_database(dstf)
+	if err != nil {
+		src.Shutdown()
+		fail(err)
+	}
+	report, err := sqldb.CopyModels(src, dst, metainfo, &sqldb.CopyOptions{
+		BatchSize:          *batch,
+		DisableForeignKeys: *nofk,
+		Progress: func(table string, count int) {
+			fmt.Fprintf(os.Stderr, "* %s: %d rows\n", table, count)
+		},
+	})
+	src.Shutdown()
+	dst.Shutdown()
+	if report != nil {
+		for _, t := range report.Tables {
+			fmt.Println(t)
+		}
+	}
+	if err != nil {
+		fail(err)
+	}
+}
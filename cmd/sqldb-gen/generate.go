@@ -0,0 +1,350 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strings"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+// common initialisms kept in upper case in identifiers
+var initialisms = map[string]bool{
+	"ID": true, "GUID": true, "UUID": true, "URL": true, "URI": true,
+	"IP": true, "JSON": true, "XML": true, "HTML": true, "HTTP": true,
+	"API": true, "SQL": true, "UTC": true,
+}
+
+// Generator generates models source code from database tables.
+type Generator struct {
+	// Package is the generated source package name.
+	Package string
+	// Structs enables generating typed rows structs for tables.
+	Structs bool
+}
+
+// table metainfo to generate
+type table struct {
+	name string
+	meta *sqldb.TableMeta
+}
+
+// Generate returns the formatted source code of tables models.
+func (g *Generator) Generate(tables []table) ([]byte, error) {
+	body := &bytes.Buffer{}
+	imports := map[string]bool{}
+	for _, t := range tables {
+		g.model(body, t)
+		if g.Structs {
+			g.rowStruct(body, t, imports)
+		}
+	}
+
+	src := &bytes.Buffer{}
+	fmt.Fprintf(src, "// Code generated by sqldb-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(src, "package %s\n\n", g.Package)
+	fmt.Fprintf(src, "import (\n")
+	if imports["time"] {
+		fmt.Fprintf(src, "\t\"time\"\n\n")
+	}
+	fmt.Fprintf(src, "\t\"github.com/exonlabs/go-sqldb/pkg/sqldb\"\n)\n")
+	src.Write(body.Bytes())
+
+	b, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid generated source - %v", err)
+	}
+	return b, nil
+}
+
+// writes the table model type and TableMeta method
+func (g *Generator) model(w *bytes.Buffer, t table) {
+	typename := model_typename(t.name)
+	autoguid := is_autoguid(t.meta)
+
+	fmt.Fprintf(w, "\n////////////////////////////////////////////////////\n\n")
+	fmt.Fprintf(w, "type %s struct{ sqldb.BaseModel }\n\n", typename)
+	fmt.Fprintf(w, "// %s is the model of table %q.\n", exported(t.name), t.name)
+	fmt.Fprintf(w, "var %s = &%s{sqldb.BaseModel{\n", exported(t.name), typename)
+	fmt.Fprintf(w, "DefaultTable: %q,\n", t.name)
+	if autoguid {
+		fmt.Fprintf(w, "AutoGuid: true,\n")
+	}
+	fmt.Fprintf(w, "}}\n\n")
+
+	fmt.Fprintf(w, "// TableMeta returns the table %q metainfo.\n", t.name)
+	fmt.Fprintf(w, "func (m *%s) TableMeta() *sqldb.TableMeta {\n", typename)
+	fmt.Fprintf(w, "return &sqldb.TableMeta{\n")
+	fmt.Fprintf(w, "Columns: []sqldb.ColumnMeta{\n")
+	for _, c := range t.meta.Columns {
+		if autoguid && c.Name == "guid" {
+			continue
+		}
+		fmt.Fprintf(w, "{Name: %q", c.Name)
+		if is_json(c.Type) {
+			mods := strings.TrimSpace(c.Type[len(base_type(c.Type)):])
+			if mods != "" {
+				fmt.Fprintf(w, ", Type: %q", mods)
+			}
+			fmt.Fprintf(w, ", Kind: sqldb.KindJSON")
+		} else {
+			fmt.Fprintf(w, ", Type: %q", c.Type)
+		}
+		if c.Primary {
+			fmt.Fprintf(w, ", Primary: true")
+		}
+		if c.Unique {
+			fmt.Fprintf(w, ", Unique: true")
+		}
+		if c.Index {
+			fmt.Fprintf(w, ", Index: true")
+		}
+		fmt.Fprintf(w, "},\n")
+	}
+	fmt.Fprintf(w, "},\n")
+	if len(t.meta.Constraints) > 0 {
+		fmt.Fprintf(w, "Constraints: []sqldb.ConstraintMeta{\n")
+		for _, c := range t.meta.Constraints {
+			if c.Name != "" {
+				fmt.Fprintf(w, "{Name: %q, Definition: %q},\n",
+					c.Name, c.Definition)
+			} else {
+				fmt.Fprintf(w, "{Definition: %q},\n", c.Definition)
+			}
+		}
+		fmt.Fprintf(w, "},\n")
+	}
+	if len(t.meta.ForeignKeys) > 0 {
+		fmt.Fprintf(w, "ForeignKeys: []sqldb.ForeignKeyMeta{\n")
+		for _, fk := range t.meta.ForeignKeys {
+			fmt.Fprintf(w, "{Name: %q, Columns: %#v, RefTable: %q, RefColumns: %#v",
+				fk.Name, fk.Columns, fk.RefTable, fk.RefColumns)
+			if fk.OnDelete != "" {
+				fmt.Fprintf(w, ", OnDelete: %q", fk.OnDelete)
+			}
+			if fk.OnUpdate != "" {
+				fmt.Fprintf(w, ", OnUpdate: %q", fk.OnUpdate)
+			}
+			fmt.Fprintf(w, "},\n")
+		}
+		fmt.Fprintf(w, "},\n")
+	}
+	if len(t.meta.Indexes) > 0 {
+		fmt.Fprintf(w, "Indexes: []sqldb.IndexMeta{\n")
+		for _, ix := range t.meta.Indexes {
+			fmt.Fprintf(w, "{Name: %q, Columns: %#v", ix.Name, ix.Columns)
+			if ix.Unique {
+				fmt.Fprintf(w, ", Unique: true")
+			}
+			fmt.Fprintf(w, "},\n")
+		}
+		fmt.Fprintf(w, "},\n")
+	}
+	if autoguid {
+		fmt.Fprintf(w, "AutoGuid: true,\n")
+	}
+	fmt.Fprintf(w, "}\n}\n")
+}
+
+// writes the table typed row struct with its data conversion methods
+func (g *Generator) rowStruct(w *bytes.Buffer, t table, imports map[string]bool) {
+	name := exported(t.name) + "Row"
+
+	type field struct {
+		name, column, gotype, accessor string
+		pointer                        bool
+	}
+	fields := []field{}
+	used := map[string]bool{}
+	pkeys := primary_columns(t.meta)
+	for _, c := range t.meta.Columns {
+		f := field{name: exported(c.Name), column: c.Name}
+		for used[f.name] {
+			f.name += "_"
+		}
+		used[f.name] = true
+		f.gotype, f.accessor = go_type(c.Type)
+		if f.gotype == "time.Time" {
+			imports["time"] = true
+		}
+		nullable := !c.Primary && !pkeys[c.Name] &&
+			!strings.Contains(strings.ToUpper(c.Type), "NOT NULL")
+		f.pointer = nullable && f.gotype != "[]byte" && f.gotype != "any"
+		fields = append(fields, f)
+	}
+
+	fmt.Fprintf(w, "\n// %s represents a data row of table %q.\n", name, t.name)
+	fmt.Fprintf(w, "type %s struct {\n", name)
+	for _, f := range fields {
+		gotype := f.gotype
+		if f.pointer {
+			gotype = "*" + gotype
+		}
+		fmt.Fprintf(w, "%s %s `json:%q`\n", f.name, gotype, f.column)
+	}
+	fmt.Fprintf(w, "}\n\n")
+
+	fmt.Fprintf(w, "// Decode sets the row fields from data, missing and null\n")
+	fmt.Fprintf(w, "// columns are set to zero values.\n")
+	fmt.Fprintf(w, "func (r *%s) Decode(data sqldb.Data) error {\n", name)
+	fmt.Fprintf(w, "row := sqldb.Row(data)\n")
+	fmt.Fprintf(w, "*r = %s{}\n", name)
+	for _, f := range fields {
+		fmt.Fprintf(w, "if !row.IsNull(%q) {\n", f.column)
+		switch {
+		case f.accessor == "JSON":
+			fmt.Fprintf(w, "if err := row.JSON(%q, &r.%s); err != nil {\n",
+				f.column, f.name)
+			fmt.Fprintf(w, "return err\n}\n")
+		case f.pointer:
+			fmt.Fprintf(w, "v, err := row.%s(%q)\n", f.accessor, f.column)
+			fmt.Fprintf(w, "if err != nil {\nreturn err\n}\n")
+			fmt.Fprintf(w, "r.%s = &v\n", f.name)
+		default:
+			fmt.Fprintf(w, "v, err := row.%s(%q)\n", f.accessor, f.column)
+			fmt.Fprintf(w, "if err != nil {\nreturn err\n}\n")
+			fmt.Fprintf(w, "r.%s = v\n", f.name)
+		}
+		fmt.Fprintf(w, "}\n")
+	}
+	fmt.Fprintf(w, "return nil\n}\n\n")
+
+	fmt.Fprintf(w, "// Data returns the row fields as data.\n")
+	fmt.Fprintf(w, "func (r *%s) Data() sqldb.Data {\n", name)
+	fmt.Fprintf(w, "data := sqldb.Data{}\n")
+	for _, f := range fields {
+		if f.pointer {
+			fmt.Fprintf(w, "if r.%s != nil {\n", f.name)
+			fmt.Fprintf(w, "data[%q] = *r.%s\n", f.column, f.name)
+			fmt.Fprintf(w, "} else {\n")
+			fmt.Fprintf(w, "data[%q] = nil\n}\n", f.column)
+		} else {
+			fmt.Fprintf(w, "data[%q] = r.%s\n", f.column, f.name)
+		}
+	}
+	fmt.Fprintf(w, "return data\n}\n")
+}
+
+////////////////////////////////////////////////////
+
+// checks the table uses the standard guid primary key
+func is_autoguid(meta *sqldb.TableMeta) bool {
+	for _, c := range meta.Columns {
+		if c.Name == "guid" && c.Primary {
+			return base_type(c.Type) == "VARCHAR" ||
+				base_type(c.Type) == "CHARACTER VARYING" ||
+				base_type(c.Type) == "NVARCHAR"
+		}
+	}
+	return false
+}
+
+// returns the columns of multi columns primary key constraint
+func primary_columns(meta *sqldb.TableMeta) map[string]bool {
+	result := map[string]bool{}
+	for _, c := range meta.Constraints {
+		def := strings.TrimSpace(c.Definition)
+		if !strings.HasPrefix(strings.ToUpper(def), "PRIMARY KEY") {
+			continue
+		}
+		i, j := strings.Index(def, "("), strings.LastIndex(def, ")")
+		if i < 0 || j < i {
+			continue
+		}
+		for _, col := range strings.Split(def[i+1:j], ",") {
+			result[strings.TrimSpace(col)] = true
+		}
+	}
+	return result
+}
+
+func is_json(sqltype string) bool {
+	t := base_type(sqltype)
+	return t == "JSON" || t == "JSONB"
+}
+
+// returns the upper case base type name without size and modifiers
+func base_type(sqltype string) string {
+	s := strings.ToUpper(strings.TrimSpace(sqltype))
+	for _, prefix := range []string{
+		"CHARACTER VARYING", "DOUBLE PRECISION", "TIMESTAMP WITH",
+		"TIMESTAMP WITHOUT", "TIME WITH", "TIME WITHOUT"} {
+		if strings.HasPrefix(s, prefix) {
+			return prefix
+		}
+	}
+	if i := strings.IndexAny(s, " ("); i > 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+// returns the go type and the sqldb.Row accessor for sql type
+func go_type(sqltype string) (string, string) {
+	if strings.HasPrefix(strings.ToUpper(sqltype), "TINYINT(1)") {
+		return "bool", "Bool"
+	}
+	switch base_type(sqltype) {
+	case "BOOL", "BOOLEAN", "BIT":
+		return "bool", "Bool"
+	case "INT", "INTEGER", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT",
+		"INT2", "INT4", "INT8", "SERIAL", "SMALLSERIAL", "BIGSERIAL", "YEAR":
+		return "int64", "Int64"
+	case "REAL", "FLOAT", "FLOAT4", "FLOAT8", "DOUBLE", "DOUBLE PRECISION":
+		return "float64", "Float64"
+	case "DATE", "DATETIME", "DATETIME2", "SMALLDATETIME", "DATETIMEOFFSET",
+		"TIMESTAMP", "TIMESTAMPTZ", "TIMESTAMP WITH", "TIMESTAMP WITHOUT":
+		return "time.Time", "Time"
+	case "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BYTEA", "BINARY",
+		"VARBINARY", "IMAGE":
+		return "[]byte", "Bytes"
+	case "JSON", "JSONB":
+		return "any", "JSON"
+	}
+	// decimals are kept as strings to preserve precision
+	return "string", "String"
+}
+
+// returns the exported camel case identifier of name
+func exported(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' ||
+			r >= '0' && r <= '9')
+	})
+	s := ""
+	for _, p := range parts {
+		if initialisms[strings.ToUpper(p)] {
+			s += strings.ToUpper(p)
+		} else {
+			s += strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		s = "T" + s
+	}
+	return s
+}
+
+// returns the unexported model type name of table
+func model_typename(name string) string {
+	s := exported(name)
+	// lower the leading initialism or first letter
+	n := 1
+	for n < len(s) && s[n] >= 'A' && s[n] <= 'Z' {
+		n++
+	}
+	if n > 1 && n < len(s) {
+		n--
+	}
+	s = strings.ToLower(s[:n]) + s[n:]
+	if token.IsKeyword(s) {
+		s += "Model"
+	}
+	return s
+}
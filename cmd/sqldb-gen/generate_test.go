@@ -0,0 +1,81 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/exonlabs/go-utils/pkg/abc/dictx"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+	sqlitedb "github.com/exonlabs/go-sqldb/pkg/sqlite_modernc"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// fixture database schema
+var fixtureSchema = []string{
+	"CREATE TABLE roles (" +
+		"guid VARCHAR(32) NOT NULL PRIMARY KEY, " +
+		"name VARCHAR(64) NOT NULL UNIQUE, " +
+		"attrs JSON);",
+	"CREATE TABLE user_items (" +
+		"id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT, " +
+		"role_id VARCHAR(32) NOT NULL, " +
+		"sku VARCHAR(16) NOT NULL, " +
+		"price DECIMAL(10,2) DEFAULT 0, " +
+		"active BOOLEAN NOT NULL DEFAULT 1, " +
+		"created DATETIME, " +
+		"data BLOB, " +
+		"FOREIGN KEY (role_id) REFERENCES roles (guid) ON DELETE CASCADE);",
+	"CREATE UNIQUE INDEX ux_user_items_role_sku ON user_items (role_id, sku);",
+	"CREATE INDEX ix_user_items_created ON user_items (created);",
+}
+
+func fixtureDatabase(t *testing.T) *sqldb.Database {
+	t.Helper()
+	opts := dictx.Dict{
+		"database": filepath.Join(t.TempDir(), "fixture.db"),
+	}
+	engine, err := sqlitedb.NewEngine(nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sqldb.NewDatabase(nil, engine, opts)
+	t.Cleanup(db.Shutdown)
+	dbs := db.Session()
+	for _, stmt := range fixtureSchema {
+		if _, err := dbs.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestGenerateGolden(t *testing.T) {
+	db := fixtureDatabase(t)
+	src, err := generate(db, "", &Generator{Package: "models", Structs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "models.golden")
+	if *update {
+		if err := os.WriteFile(golden, src, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, want) {
+		t.Errorf("generated source differs from %s:\n%s", golden, src)
+	}
+}
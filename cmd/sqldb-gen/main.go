@@ -0,0 +1,248 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+// sqldb-gen generates Go models source code from an existing database.
+//
+// The tool connects to the database using the backend engine package,
+// introspects the tables schema and writes the BaseModel based models
+// with their TableMeta columns and constraints, and optionally typed rows
+// structs with data conversion methods.
+//
+// Usage:
+//
+//	sqldb-gen -backend sqlite -database /path/to/file.db -o models.go
+//	sqldb-gen -backend pgsql -i -tables users,roles -structs
+//	sqldb-gen schema -backend sqlite -database /path/to/file.db -target mssql
+//	sqldb-gen copy -backend sqlite -database /path/to/file.db \
+//		-target-backend pgsql -target-database dbname -target-address host
+//
+// The schema subcommand writes the DDL statments of the introspected
+// tables for the target backend, for review before creating the schema.
+//
+// The copy subcommand copies the introspected tables with their rows into
+// the target database, which can be of different backend.
+//
+// The database options can be set using the flags or interactively
+// using the -i flag, where the flags values are used as defaults.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/exonlabs/go-utils/pkg/abc/dictx"
+	"github.com/exonlabs/go-utils/pkg/abc/slicex"
+	"github.com/exonlabs/go-utils/pkg/logging"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+
+	mssqldb "github.com/exonlabs/go-sqldb/pkg/mssql_microsoft"
+	mysqldb "github.com/exonlabs/go-sqldb/pkg/mysql_sqldriver"
+	pgsqldb "github.com/exonlabs/go-sqldb/pkg/pgsql_libpq"
+	sqlitedb "github.com/exonlabs/go-sqldb/pkg/sqlite_modernc"
+)
+
+var BACKENDS = []string{"sqlite", "mysql", "pgsql", "mssql"}
+
+// database connection flags
+type dbFlags struct {
+	debug        *bool
+	backend      *string
+	interactive  *bool
+	database     *string
+	address      *string
+	username     *string
+	password     *string
+	connect_args *string
+}
+
+// defines the database connection flags in flags set, where prefix is
+// prepended to the flags names. the debug flag is defined only for the
+// flags without prefix.
+func new_dbflags(fs *flag.FlagSet, prefix string) *dbFlags {
+	dbf := &dbFlags{
+		backend: fs.String(prefix+"backend", "",
+			fmt.Sprintf("select backend {%s}", strings.Join(BACKENDS, "|"))),
+		interactive: fs.Bool(prefix+"i", false,
+			"configure database interactively"),
+		database: fs.String(prefix+"database", "",
+			"database name or file path"),
+		address: fs.String(prefix+"address", "", "database server address"),
+		username: fs.String(prefix+"username", "",
+			"database access username"),
+		password: fs.String(prefix+"password", "",
+			"database access password"),
+		connect_args: fs.String(prefix+"connect_args", "",
+			"database connection params"),
+	}
+	if prefix == "" {
+		dbf.debug = fs.Bool("x", false, "\nenable debug logs")
+	}
+	return dbf
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "schema":
+			schema_cmd(os.Args[2:])
+			return
+		case "copy":
+			copy_cmd(os.Args[2:])
+			return
+		}
+	}
+
+	fs := flag.CommandLine
+	dbf := new_dbflags(fs, "")
+	pkgname := fs.String("pkg", "models", "generated source package name")
+	tables := fs.String("tables", "",
+		"comma separated tables names to generate, defaults to all tables")
+	structs := fs.Bool("structs", false, "generate typed rows structs")
+	output := fs.String("o", "", "output file path, defaults to stdout")
+	flag.Parse()
+
+	db, err := open_database(dbf)
+	if err != nil {
+		fail(err)
+	}
+	src, err := generate(db, *tables, &Generator{
+		Package: *pkgname,
+		Structs: *structs,
+	})
+	db.Shutdown()
+	if err != nil {
+		fail(err)
+	}
+
+	write_output(*output, src)
+}
+
+// creates the database handler from the database flags
+func open_database(dbf *dbFlags) (*sqldb.Database, error) {
+	if slicex.Index(BACKENDS, *dbf.backend) < 0 {
+		return nil, fmt.Errorf("invalid backend '%s'", *dbf.backend)
+	}
+
+	db_config := dictx.Dict{}
+	for k, v := range map[string]string{
+		"database":     *dbf.database,
+		"address":      *dbf.address,
+		"username":     *dbf.username,
+		"password":     *dbf.password,
+		"connect_args": *dbf.connect_args,
+	} {
+		if v != "" {
+			dictx.Set(db_config, k, v)
+		}
+	}
+
+	var err error
+	if *dbf.interactive {
+		fmt.Fprintln(os.Stderr, "* Configure database:")
+		switch *dbf.backend {
+		case "sqlite":
+			db_config, err = sqlitedb.InteractiveConfig(db_config)
+		case "mysql":
+			db_config, err = mysqldb.InteractiveConfig(db_config)
+		case "pgsql":
+			db_config, err = pgsqldb.InteractiveConfig(db_config)
+		case "mssql":
+			db_config, err = mssqldb.InteractiveConfig(db_config)
+		}
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(os.Stderr)
+	}
+
+	var dblog *logging.Logger
+	if dbf.debug != nil && *dbf.debug {
+		// the debug logs are written to stderr, keeping stdout for the
+		// generated output
+		dblog = logging.NewLogger("db")
+		dblog.AddHandler(logging.NewStreamHandler(os.Stderr))
+		dblog.Level = logging.DEBUG
+	}
+
+	// create engine
+	var engine sqldb.Engine
+	switch *dbf.backend {
+	case "sqlite":
+		engine, err = sqlitedb.NewEngine(dblog, db_config)
+	case "mysql":
+		engine, err = mysqldb.NewEngine(dblog, db_config)
+	case "pgsql":
+		engine, err = pgsqldb.NewEngine(dblog, db_config)
+	case "mssql":
+		engine, err = mssqldb.NewEngine(dblog, db_config)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return sqldb.NewDatabase(dblog, engine, db_config), nil
+}
+
+// introspects the database tables and generates the models source
+func generate(db *sqldb.Database, names string, g *Generator) ([]byte, error) {
+	tables, err := describe(db, names)
+	if err != nil {
+		return nil, err
+	}
+	return g.Generate(tables)
+}
+
+// introspects the database tables metainfo, where names are the comma
+// separated tables names, or all database tables if empty.
+func describe(db *sqldb.Database, names string) ([]table, error) {
+	in, err := db.Introspector()
+	if err != nil {
+		return nil, err
+	}
+	dbs := db.Session()
+
+	list := []string{}
+	if names != "" {
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				list = append(list, name)
+			}
+		}
+	} else if list, err = in.ListTables(dbs); err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, errors.New("no tables found")
+	}
+
+	tables := []table{}
+	for _, name := range list {
+		meta, err := in.DescribeTable(dbs, name)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table{name: name, meta: meta})
+	}
+	return tables, nil
+}
+
+// writes the output to file path, or to stdout if empty
+func write_output(path string, src []byte) {
+	if path == "" {
+		os.Stdout.Write(src)
+	} else if err := os.WriteFile(path, src, 0o644); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	if !strings.Contains(err.Error(), "EOF") {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+	}
+	os.Exit(1)
+}
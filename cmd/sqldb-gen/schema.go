@@ -0,0 +1,84 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+
+	"github.com/exonlabs/go-utils/pkg/abc/slicex"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+// introspected table model
+type tableModel struct {
+	sqldb.BaseModel
+	meta *sqldb.TableMeta
+}
+
+// TableMeta returns the introspected table metainfo.
+func (m *tableModel) TableMeta() *sqldb.TableMeta {
+	return m.meta
+}
+
+// runs the schema subcommand, which writes the DDL statments of the
+// introspected tables for the target backend.
+func schema_cmd(args []string) {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	dbf := new_dbflags(fs, "")
+	target := fs.String("target", "",
+		"target backend of DDL statments, defaults to the database backend")
+	tables := fs.String("tables", "",
+		"comma separated tables names to export, defaults to all tables")
+	output := fs.String("o", "", "output file path, defaults to stdout")
+	fs.Parse(args)
+
+	if *target == "" {
+		*target = *dbf.backend
+	}
+	if slicex.Index(BACKENDS, *target) < 0 {
+		fail(fmt.Errorf("invalid target backend '%s'", *target))
+	}
+
+	db, err := open_database(dbf)
+	if err != nil {
+		fail(err)
+	}
+	list, err := describe(db, *tables)
+	db.Shutdown()
+	if err != nil {
+		fail(err)
+	}
+
+	metainfo := table_models(list, *target != *dbf.backend)
+	var buff bytes.Buffer
+	if err := sqldb.WriteSchemaSQL(&buff, *target, metainfo); err != nil {
+		fail(err)
+	}
+	write_output(*output, buff.Bytes())
+}
+
+// returns the models of introspected tables, where portable converts the
+// native columns types for other backends.
+func table_models(list []table, portable bool) []sqldb.ModelMeta {
+	metainfo := []sqldb.ModelMeta{}
+	for _, t := range list {
+		if portable {
+			for i, c := range t.meta.Columns {
+				t.meta.Columns[i] = sqldb.PortableColumn(c)
+			}
+		}
+		metainfo = append(metainfo, sqldb.ModelMeta{
+			Table: t.name,
+			Model: &tableModel{
+				BaseModel: sqldb.BaseModel{DefaultTable: t.name},
+				meta:      t.meta,
+			},
+		})
+	}
+	return metainfo
+}
@@ -65,7 +65,7 @@ func (m *role) TableMeta() *sqldb.TableMeta {
 				Unique: true, Index: true},
 			{Name: "description", Type: "TEXT"},
 			{Name: "access_level", Type: "INTEGER"},
-			{Name: "public_join", Type: "BOOLEAN DEFAULT false"},
+			{Name: "public_join", Kind: sqldb.KindBool, Default: false},
 		},
 		Constraints: []sqldb.ConstraintMeta{
 			{Definition: "CHECK (access_level>=1 AND access_level<=5)"},
@@ -89,12 +89,13 @@ func (m *person) TableMeta() *sqldb.TableMeta {
 			{Name: "name", Type: "VARCHAR(128) NOT NULL",
 				Unique: true, Index: true},
 			{Name: "email", Type: "VARCHAR(256)"},
-			{Name: "active", Type: "BOOLEAN DEFAULT true"},
+			{Name: "active", Kind: sqldb.KindBool, Default: true},
 			{Name: "role_guid", Type: "VARCHAR(32) NOT NULL"},
 		},
-		Constraints: []sqldb.ConstraintMeta{
-			{Definition: "FOREIGN KEY (role_guid) REFERENCES roles (guid) " +
-				"ON UPDATE CASCADE ON DELETE RESTRICT"},
+		ForeignKeys: []sqldb.ForeignKeyMeta{
+			{Columns: []string{"role_guid"}, RefTable: "roles",
+				RefColumns: []string{"guid"},
+				OnUpdate:   "CASCADE", OnDelete: "RESTRICT"},
 		},
 		AutoGuid: true,
 		Args:     meta_args,
@@ -124,14 +125,19 @@ func print_data(d dictx.Dict) string {
 	return "{" + s + "}"
 }
 
+var metainfo = []sqldb.ModelMeta{
+	{Table: Role.DefaultTable, Model: Role},
+	{Table: Person.DefaultTable, Model: Person},
+}
+
 func run_initialize(db *sqldb.Database) error {
-	metainfo := []sqldb.ModelMeta{
-		{Table: Role.DefaultTable, Model: Role},
-		{Table: Person.DefaultTable, Model: Person},
-	}
 	return sqldb.InitializeModels(db, metainfo)
 }
 
+func run_cleanup(db *sqldb.Database) error {
+	return sqldb.DropModels(db, metainfo)
+}
+
 func run_operations(db *sqldb.Database) error {
 	if !db.Ping() {
 		return errors.New("database connection down")
@@ -229,6 +235,7 @@ func main() {
 	backend := flag.String("backend", "",
 		fmt.Sprintf("select backend {%s}", strings.Join(BACKENDS, "|")))
 	setup := flag.Bool("setup", false, "perform database setup")
+	clean := flag.Bool("clean", false, "perform database cleanup")
 	flag.Parse()
 
 	switch {
@@ -335,6 +342,18 @@ func main() {
 		return
 	}
 
+	// cleanup database
+	if *clean {
+		fmt.Println("* Clean-Up database:")
+		if err := run_cleanup(db); err != nil {
+			fmt.Printf("Error: %s\n", err)
+		}
+		fmt.Println()
+
+		log.Info("done")
+		return
+	}
+
 	if err := run_operations(db); err != nil {
 		log.Info("Error: %s\n", err.Error())
 		return
@@ -26,6 +26,10 @@ var (
 	db_path   = filepath.Join(os.TempDir(), "sample.db")
 	db_config = dictx.Dict{
 		"database": db_path,
+		// "journal_mode": "WAL",
+		// "synchronous": "NORMAL",
+		// "busy_timeout": 100,
+		// "single_writer": true,
 		// "connect_args": "",
 		// "operation_timeout": 3.0,
 		// "retry_interval": 0.1,
@@ -14,6 +14,7 @@ import (
 	"runtime/debug"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/exonlabs/go-utils/pkg/abc/dictx"
 	"github.com/exonlabs/go-utils/pkg/logging"
@@ -45,8 +46,10 @@ func (m *dataset) TableMeta() *sqldb.TableMeta {
 	return &sqldb.TableMeta{
 		Columns: []sqldb.ColumnMeta{
 			{Name: "data", Type: "TEXT"},
-			{Name: "csv", Type: "TEXT"}, // 2-way mapping
-			{Name: "hex", Type: "TEXT"}, // 1-way mapping
+			{Name: "csv", Type: "TEXT", Codec: sqldb.CSVCodec},
+			{Name: "hex", Type: "TEXT", Codec: sqldb.HexCodec},
+			{Name: "attrs", Kind: sqldb.KindJSON},
+			{Name: "created", Type: "TEXT", Codec: sqldb.TimeCodec},
 		},
 		AutoGuid: false,
 		Args: dictx.Dict{
@@ -55,39 +58,6 @@ func (m *dataset) TableMeta() *sqldb.TableMeta {
 	}
 }
 
-func (m *dataset) DataEncode(data []sqldb.Data) error {
-	for i := 0; i < len(data); i++ {
-		if _, ok := data[i]["csv"]; ok {
-			if v, ok := data[i]["csv"].([]string); ok {
-				data[i]["csv"] = strings.Join(v, ",")
-			} else {
-				data[i]["csv"] = ""
-			}
-		}
-		if _, ok := data[i]["hex"]; ok {
-			if v, ok := data[i]["hex"].([]byte); ok {
-				data[i]["hex"] = fmt.Sprintf("%X", v)
-			} else {
-				data[i]["hex"] = ""
-			}
-		}
-	}
-	return nil
-}
-
-func (m *dataset) DataDecode(data []sqldb.Data) error {
-	for i := 0; i < len(data); i++ {
-		if _, ok := data[i]["csv"]; ok {
-			if v, ok := data[i]["csv"].(string); ok {
-				data[i]["csv"] = strings.Split(v, ",")
-			} else {
-				data[i]["csv"] = ""
-			}
-		}
-	}
-	return nil
-}
-
 //////////////////////////////// operations
 
 func print_data(d dictx.Dict) string {
@@ -128,9 +98,11 @@ func run_operations(db *sqldb.Database) error {
 
 	// add new data
 	data := sqldb.Data{
-		"data": "normal data",
-		"csv":  []string{"1", "2", "3", "4"},
-		"hex":  []byte("hex data 1 2 3 4"),
+		"data":    "normal data",
+		"csv":     []string{"1", "2", "3", "4"},
+		"hex":     []byte("hex data 1 2 3 4"),
+		"attrs":   map[string]any{"color": "red", "size": 10},
+		"created": time.Now(),
 	}
 	fmt.Printf("\n* Adding new data:\n  - %v\n", data)
 	_, err := dbs.Query(DataSet).Insert(data)
@@ -149,6 +121,18 @@ func run_operations(db *sqldb.Database) error {
 		fmt.Printf("Total: %d\n", len(data))
 	}
 
+	// filter data by JSON attrs
+	fmt.Println("\n* List data with attrs color: red")
+	if data, err := dbs.Query(DataSet).Where(
+		sqldb.JSONPath("attrs", "$.color").Eq("red")).All(); err != nil {
+		fmt.Println("ERROR:", err.Error())
+	} else {
+		for _, v := range data {
+			fmt.Println("  - " + print_data(v))
+		}
+		fmt.Printf("Total: %d\n", len(data))
+	}
+
 	// listing all data
 	fmt.Println("\n* List all data (low level by session)")
 	if data, err := dbs.Fetch("SELECT * FROM datasets;"); err != nil {
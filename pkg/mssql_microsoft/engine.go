@@ -6,7 +6,9 @@ package mssqldb
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,7 +17,7 @@ import (
 	"github.com/exonlabs/go-utils/pkg/logging"
 
 	"github.com/exonlabs/go-sqldb/pkg/sqldb"
-	_ "github.com/microsoft/go-mssqldb"
+	mssql "github.com/microsoft/go-mssqldb"
 )
 
 // Engine represents the backend engine structure.
@@ -103,6 +105,66 @@ func (e *Engine) CanRetryErr(err error) bool {
 	return false
 }
 
+// IsDuplicateErr checks weather an operation error is for creating
+// an already existing database object.
+func (e *Engine) IsDuplicateErr(err error) bool {
+	var merr mssql.Error
+	if errors.As(err, &merr) {
+		switch merr.Number {
+		case 1913, // index already exists
+			2705, // duplicate column name
+			2714: // object already exists
+			return true
+		}
+	}
+	return false
+}
+
+// IsMissingTableErr checks weather an operation error is for accessing
+// a not existing table.
+func (e *Engine) IsMissingTableErr(err error) bool {
+	var merr mssql.Error
+	if errors.As(err, &merr) {
+		switch merr.Number {
+		case 208, // invalid object name
+			3701: // cannot drop object, not exist
+			return true
+		}
+	}
+	return false
+}
+
+// TransactionalDDL checks weather the table schema statments can run
+// within a transaction and be rolled back on failure. the full-text
+// catalog and index statments are not allowed in user transactions.
+func (e *Engine) TransactionalDDL(meta *sqldb.TableMeta) bool {
+	return meta == nil || meta.FullText == nil
+}
+
+// Types defines the mssql native types of portable column kinds.
+var Types = &sqldb.TypeMap{
+	Types: map[sqldb.Kind]string{
+		sqldb.KindJSON:    "NVARCHAR(MAX)",
+		sqldb.KindString:  "NVARCHAR",
+		sqldb.KindText:    "NVARCHAR(MAX)",
+		sqldb.KindInt:     "INT",
+		sqldb.KindBigInt:  "BIGINT",
+		sqldb.KindBool:    "BIT",
+		sqldb.KindFloat:   "FLOAT",
+		sqldb.KindDecimal: "DECIMAL",
+		sqldb.KindTime:    "DATETIME2",
+		sqldb.KindDate:    "DATE",
+		sqldb.KindBlob:    "VARBINARY(MAX)",
+		sqldb.KindUUID:    "UNIQUEIDENTIFIER",
+	},
+	True:          "1",
+	False:         "0",
+	AutoIncrement: "IDENTITY(1,1)",
+}
+
+// RESTRICT referential action in raw constraints definitions
+var restrictRegex = regexp.MustCompile(`(?i)\b(ON\s+(?:DELETE|UPDATE))\s+RESTRICT\b`)
+
 // SqlGenerator represents mssql SQL statment generator.
 type SqlGenerator struct {
 	sqldb.StdSqlGenerator
@@ -158,10 +220,59 @@ func (*SqlGenerator) Select(attrs *sqldb.StmtAttrs) (string, []any) {
 	return stmt, params
 }
 
+// ColumnType generates the column type definition. the KindRaw columns
+// use the column Type as is.
+func (g *SqlGenerator) ColumnType(c *sqldb.ColumnMeta) string {
+	switch c.Kind {
+	case sqldb.KindJSON:
+		return Types.ColumnType(c) +
+			fmt.Sprintf(" CHECK (ISJSON(%s)=1)", c.Name)
+	case sqldb.KindString:
+		// national strings are limited to 4000 characters
+		if c.Size > 4000 {
+			text := *c
+			text.Kind = sqldb.KindText
+			return Types.ColumnType(&text)
+		}
+	}
+	return Types.ColumnType(c)
+}
+
+// JSONValue generates the expression extracting a JSON document value
+func (*SqlGenerator) JSONValue(
+	column, path string, numeric bool) (string, error) {
+	if _, err := sqldb.JSONPathKeys(path); err != nil {
+		return "", err
+	}
+	expr := fmt.Sprintf("JSON_VALUE(%s, '%s')", column, path)
+	if numeric {
+		return "CAST(" + expr + " AS FLOAT)", nil
+	}
+	return expr, nil
+}
+
+// FullTextMatch generates the full-text search filter expression using
+// the table full-text index.
+func (*SqlGenerator) FullTextMatch(tablename string, meta *sqldb.TableMeta,
+	columns []string, text string) (string, []any) {
+	terms := []string{}
+	for _, t := range sqldb.SearchTerms(text) {
+		terms = append(terms, `"`+strings.ReplaceAll(t, `"`, "")+`"`)
+	}
+	stmt := fmt.Sprintf("CONTAINS((%s), %s)",
+		strings.Join(columns, ", "), sqldb.SQL_PLACEHOLDER)
+	return stmt, []any{strings.Join(terms, " AND ")}
+}
+
 // Schema generates table schema statments from metainfo
-func (*SqlGenerator) Schema(tablename string, meta *sqldb.TableMeta) []string {
+func (g *SqlGenerator) Schema(tablename string, meta *sqldb.TableMeta) []string {
 	var buff, constraints, indexes []string
 
+	if meta.View != nil {
+		return []string{fmt.Sprintf("CREATE OR ALTER VIEW %s AS\n%s;",
+			tablename, sqldb.ViewSelect(g, meta.View, Types))}
+	}
+
 	// if AutoGuid, add guid column if not exist as first column
 	if meta.AutoGuid && meta.Columns[0].Name != "guid" {
 		meta.Columns = append([]sqldb.ColumnMeta{
@@ -171,13 +282,7 @@ func (*SqlGenerator) Schema(tablename string, meta *sqldb.TableMeta) []string {
 
 	// loop and parse columns meta
 	for _, c := range meta.Columns {
-		col_type := c.Type
-		if strings.Contains(col_type, "BOOLEAN") {
-			col_type = strings.ReplaceAll(col_type, "BOOLEAN", "BIT")
-			col_type = strings.ReplaceAll(col_type, "false", "0")
-			col_type = strings.ReplaceAll(col_type, "true", "1")
-		}
-		buff = append(buff, fmt.Sprintf("%s %s", c.Name, col_type))
+		buff = append(buff, fmt.Sprintf("%s %s", c.Name, g.ColumnType(&c)))
 
 		// add constraints and indexes
 		if c.Primary {
@@ -188,11 +293,8 @@ func (*SqlGenerator) Schema(tablename string, meta *sqldb.TableMeta) []string {
 				fmt.Sprintf("UNIQUE (%s)", c.Name))
 		}
 		if c.Primary || c.Index {
-			indexes = append(indexes, fmt.Sprintf(
-				"IF NOT EXISTS (SELECT * FROM sys.indexes "+
-					"WHERE name='ix_%s_%s')\n"+
-					"CREATE INDEX ix_%s_%s ON %s (%s);",
-				tablename, c.Name, tablename, c.Name, tablename, c.Name))
+			indexes = append(indexes, g.Index(tablename, meta,
+				&sqldb.IndexMeta{Columns: []string{c.Name}}))
 		}
 	}
 
@@ -201,10 +303,7 @@ func (*SqlGenerator) Schema(tablename string, meta *sqldb.TableMeta) []string {
 
 	// add explicit table constraints
 	for _, c := range meta.Constraints {
-		c_def := c.Definition
-		if strings.Contains(c_def, "RESTRICT") {
-			c_def = strings.ReplaceAll(c_def, "RESTRICT", "NO ACTION")
-		}
+		c_def := restrictRegex.ReplaceAllString(c.Definition, "$1 NO ACTION")
 		if c.Name != "" {
 			buff = append(buff, fmt.Sprintf(
 				"CONSTRAINT %s %s", c.Name, c_def))
@@ -213,15 +312,205 @@ func (*SqlGenerator) Schema(tablename string, meta *sqldb.TableMeta) []string {
 		}
 	}
 
+	// add foreign keys constraints
+	for _, fk := range meta.ForeignKeys {
+		buff = append(buff, g.ForeignKey(tablename, &fk))
+	}
+
+	// add explicit table indexes
+	for _, ix := range meta.Indexes {
+		indexes = append(indexes, g.Index(tablename, meta, &ix))
+	}
+
 	stmt := fmt.Sprintf(
 		"IF OBJECT_ID(N'%s', N'U') IS NULL\n"+
 			"CREATE TABLE %s (\n  %s\n);",
 		tablename, tablename, strings.Join(buff, ",\n  "))
 
-	return append([]string{stmt}, indexes...)
+	stmts := append([]string{stmt}, indexes...)
+	if meta.FullText != nil && len(meta.FullText.Columns) > 0 {
+		stmts = append(stmts, fulltext_schema(tablename, meta)...)
+	}
+
+	return stmts
+}
+
+// Index generates the table index creation statment, where the partial
+// index predicate creates a filtered index.
+func (*SqlGenerator) Index(
+	tablename string, meta *sqldb.TableMeta, ix *sqldb.IndexMeta) string {
+	name := sqldb.IndexName(tablename, ix, 128)
+	unique := ""
+	if ix.Unique {
+		unique = " UNIQUE"
+	}
+	stmt := fmt.Sprintf(
+		"IF NOT EXISTS (SELECT * FROM sys.indexes WHERE name='%s')\n"+
+			"CREATE%s INDEX %s ON %s (%s)", name, unique, name, tablename,
+		strings.Join(ix.Columns, ", "))
+	if len(ix.Include) > 0 {
+		stmt += fmt.Sprintf(" INCLUDE (%s)", strings.Join(ix.Include, ", "))
+	}
+	if ix.Where != "" {
+		stmt += " WHERE " + ix.Where
+	}
+	return stmt + ";"
+}
+
+// ForeignKey generates the foreign key constraint definition, where the
+// RESTRICT action is replaced by NO ACTION, which is the mssql action
+// rejecting the change of referenced rows.
+func (*SqlGenerator) ForeignKey(tablename string, fk *sqldb.ForeignKeyMeta) string {
+	f := *fk
+	if strings.EqualFold(strings.TrimSpace(f.OnDelete), "RESTRICT") {
+		f.OnDelete = ""
+	}
+	if strings.EqualFold(strings.TrimSpace(f.OnUpdate), "RESTRICT") {
+		f.OnUpdate = ""
+	}
+	return sqldb.GenerateForeignKey(tablename, &f, 128)
+}
+
+// AddForeignKey generates the statments adding foreign key constraint
+// if not exists.
+func (g *SqlGenerator) AddForeignKey(
+	tablename string, fk *sqldb.ForeignKeyMeta) []string {
+	return []string{fmt.Sprintf(
+		"IF NOT EXISTS (SELECT * FROM sys.foreign_keys WHERE name='%s')\n"+
+			"ALTER TABLE %s ADD %s;", sqldb.ForeignKeyName(tablename, fk, 128),
+		tablename, g.ForeignKey(tablename, fk))}
+}
+
+// generates the table full-text catalog and index, where the full-text
+// index requires a unique key index on the table primary column.
+func fulltext_schema(tablename string, meta *sqldb.TableMeta) []string {
+	key := sqldb.PrimaryColumn(meta)
+	if key == "" {
+		return nil
+	}
+	return []string{
+		fmt.Sprintf(
+			"IF NOT EXISTS (SELECT * FROM sys.fulltext_catalogs "+
+				"WHERE name='ftc_%s')\n"+
+				"CREATE FULLTEXT CATALOG ftc_%s;", tablename, tablename),
+		fmt.Sprintf(
+			"IF NOT EXISTS (SELECT * FROM sys.indexes "+
+				"WHERE name='ux_%s_fts')\n"+
+				"CREATE UNIQUE INDEX ux_%s_fts ON %s (%s);",
+			tablename, tablename, tablename, key),
+		fmt.Sprintf(
+			"IF NOT EXISTS (SELECT * FROM sys.fulltext_indexes "+
+				"WHERE object_id=OBJECT_ID(N'%s'))\n"+
+				"CREATE FULLTEXT INDEX ON %s (%s) "+
+				"KEY INDEX ux_%s_fts ON ftc_%s;",
+			tablename, tablename, strings.Join(meta.FullText.Columns, ", "),
+			tablename, tablename),
+	}
+}
+
+// AlterSchema generates the statments adding the missing columns
+func (g *SqlGenerator) AlterSchema(diff *sqldb.SchemaDiff) ([]string, error) {
+	stmts := []string{}
+	for _, c := range diff.MissingColumns {
+		if c.Primary {
+			return nil, fmt.Errorf(
+				"can not add primary column '%s' to existing table", c.Name)
+		}
+		col_type := g.ColumnType(&c)
+		if c.Unique {
+			col_type += " UNIQUE"
+		}
+		stmts = append(stmts, fmt.Sprintf(
+			"ALTER TABLE %s ADD %s %s;", diff.Table, c.Name, col_type))
+	}
+	return stmts, nil
+}
+
+// DropSchema generates the statments dropping table schema and its
+// full-text catalog if exists.
+func (*SqlGenerator) DropSchema(tablename string, meta *sqldb.TableMeta) []string {
+	if meta != nil && meta.View != nil {
+		return []string{fmt.Sprintf(
+			"IF OBJECT_ID(N'%s', N'V') IS NOT NULL\n"+
+				"DROP VIEW %s;", tablename, tablename)}
+	}
+	stmts := []string{fmt.Sprintf(
+		"IF OBJECT_ID(N'%s', N'U') IS NOT NULL\n"+
+			"DROP TABLE %s;", tablename, tablename)}
+	if meta != nil && meta.FullText != nil {
+		stmts = append(stmts, fmt.Sprintf(
+			"IF EXISTS (SELECT * FROM sys.fulltext_catalogs "+
+				"WHERE name='ftc_%s')\n"+
+				"DROP FULLTEXT CATALOG ftc_%s;", tablename, tablename))
+	}
+	return stmts
+}
+
+// Truncate generates the statments deleting all table rows and
+// resetting the table identity. tables referenced by foreign keys can
+// not be truncated.
+func (*SqlGenerator) Truncate(tablename string, meta *sqldb.TableMeta) []string {
+	return []string{fmt.Sprintf("TRUNCATE TABLE %s;", tablename)}
+}
+
+// ForeignKeyChecks generates the statments disabling or enabling the
+// tables constraints, where the enabled constraints are checked for the
+// existing tables rows.
+func (*SqlGenerator) ForeignKeyChecks(tablenames []string, enable bool) []string {
+	stmts := []string{}
+	for _, t := range tablenames {
+		if enable {
+			stmts = append(stmts, fmt.Sprintf(
+				"ALTER TABLE %s WITH CHECK CHECK CONSTRAINT ALL;", t))
+		} else {
+			stmts = append(stmts, fmt.Sprintf(
+				"ALTER TABLE %s NOCHECK CONSTRAINT ALL;", t))
+		}
+	}
+	return stmts
+}
+
+// IdentityInsert generates the statment allowing or disallowing the
+// explicit values inserts into table identity column, which is allowed
+// for one table per session at a time. the identity is advanced by the
+// inserted values.
+func (*SqlGenerator) IdentityInsert(tablename string, enable bool) []string {
+	if enable {
+		return []string{fmt.Sprintf("SET IDENTITY_INSERT %s ON;", tablename)}
+	}
+	return []string{fmt.Sprintf("SET IDENTITY_INSERT %s OFF;", tablename)}
+}
+
+// Call generates the stored procedure EXEC statment with named args,
+// where the output args are bound using sql.Out.
+func (*SqlGenerator) Call(proc string, args []sqldb.CallArg) ([]string, []any) {
+	exprs, params := []string{}, []any{}
+	for _, a := range args {
+		if a.Out {
+			exprs = append(exprs, fmt.Sprintf("@%s=@%s OUTPUT", a.Name, a.Name))
+			params = append(params, sql.Named(a.Name, sql.Out{
+				Dest: sqldb.CallOutDest(a.Value), In: a.Value != nil}))
+		} else {
+			exprs = append(exprs, fmt.Sprintf("@%s=@%s", a.Name, a.Name))
+			params = append(params, sql.Named(a.Name, a.Value))
+		}
+	}
+	stmt := "EXEC " + proc
+	if len(exprs) > 0 {
+		stmt += " " + strings.Join(exprs, ", ")
+	}
+	return []string{stmt + ";"}, params
 }
 
 // SqlGenerator returns the engine SQL statment generator.
 func (e *Engine) SqlGenerator() sqldb.SqlGenerator {
 	return &SqlGenerator{}
 }
+
+// registers the backend SQL generator for statments generation without
+// database connection.
+func init() {
+	sqldb.RegisterGenerator("mssql", func() sqldb.SqlGenerator {
+		return &SqlGenerator{}
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package mssqldb
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+func TestCall(t *testing.T) {
+	g := &SqlGenerator{}
+	stmts, params := g.Call("dbo.proc", []sqldb.CallArg{
+		{Name: "a", Value: 1},
+		{Name: "total", Value: int64(5), Out: true},
+		{Name: "msg", Out: true},
+	})
+	want := []string{
+		"EXEC dbo.proc @a=@a, @total=@total OUTPUT, @msg=@msg OUTPUT;"}
+	if !reflect.DeepEqual(stmts, want) {
+		t.Errorf("Call() = %q, want %q", stmts, want)
+	}
+	if len(params) != 3 {
+		t.Fatalf("Call() params = %v", params)
+	}
+	if p := params[0].(sql.NamedArg); p.Name != "a" || p.Value != 1 {
+		t.Errorf("input param = %+v", p)
+	}
+
+	// the output args are bound with typed destinations
+	p := params[1].(sql.NamedArg)
+	out, ok := p.Value.(sql.Out)
+	if dest, isint := out.Dest.(*int64); p.Name != "total" || !ok ||
+		!out.In || !isint || *dest != 5 {
+		t.Errorf("output param = %+v", p)
+	}
+	p = params[2].(sql.NamedArg)
+	if out, ok := p.Value.(sql.Out); p.Name != "msg" || !ok || out.In {
+		t.Errorf("untyped output param = %+v", p)
+	}
+
+	stmts, _ = g.Call("proc", nil)
+	if len(stmts) != 1 || stmts[0] != "EXEC proc;" {
+		t.Errorf("Call() = %q", stmts)
+	}
+}
+
+func TestColumnType(t *testing.T) {
+	g := &SqlGenerator{}
+	tests := []struct {
+		column sqldb.ColumnMeta
+		want   string
+	}{
+		// the raw types are used as is
+		{sqldb.ColumnMeta{Name: "a", Type: "BOOLEAN DEFAULT true"},
+			"BOOLEAN DEFAULT true"},
+		{sqldb.ColumnMeta{Name: "a", Type: "NVARCHAR(32) DEFAULT 'BOOLEAN'"},
+			"NVARCHAR(32) DEFAULT 'BOOLEAN'"},
+		{sqldb.ColumnMeta{Name: "a", Kind: sqldb.KindBool, Default: true},
+			"BIT DEFAULT 1"},
+		{sqldb.ColumnMeta{Name: "a", Kind: sqldb.KindBool, NotNull: true,
+			Default: false}, "BIT NOT NULL DEFAULT 0"},
+		{sqldb.ColumnMeta{Name: "a", Kind: sqldb.KindString, Size: 5000},
+			"NVARCHAR(MAX)"},
+		{sqldb.ColumnMeta{Name: "a", Kind: sqldb.KindJSON},
+			"NVARCHAR(MAX) CHECK (ISJSON(a)=1)"},
+		{sqldb.ColumnMeta{Name: "a", Kind: sqldb.KindInt,
+			AutoIncrement: true}, "INT IDENTITY(1,1)"},
+	}
+	for _, tc := range tests {
+		if got := g.ColumnType(&tc.column); got != tc.want {
+			t.Errorf("ColumnType(%+v) = %q, want %q", tc.column, got, tc.want)
+		}
+	}
+}
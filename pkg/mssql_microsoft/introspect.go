@@ -0,0 +1,212 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package mssqldb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+// ListTables returns the database tables names.
+func (e *Engine) ListTables(dbs *sqldb.Session) ([]string, error) {
+	rows, err := dbs.Fetch(
+		"SELECT name FROM sys.tables " +
+			"WHERE schema_id=SCHEMA_ID() AND is_ms_shipped=0 ORDER BY name;")
+	if err != nil {
+		return nil, err
+	}
+	result := []string{}
+	for _, r := range rows {
+		name, err := sqldb.Row(r).String("name")
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, name)
+	}
+	return result, nil
+}
+
+// DescribeTable returns the table metainfo from database.
+func (e *Engine) DescribeTable(
+	dbs *sqldb.Session, tablename string) (*sqldb.TableMeta, error) {
+	rows, err := dbs.Fetch(
+		"SELECT c.name AS name, t.name AS type, c.max_length AS size, "+
+			"c.precision AS precision, c.scale AS scale, "+
+			"c.is_nullable AS nullable, c.is_identity AS is_identity, "+
+			"OBJECT_DEFINITION(c.default_object_id) AS dflt "+
+			"FROM sys.columns c "+
+			"JOIN sys.types t ON t.user_type_id=c.user_type_id "+
+			"WHERE c.object_id="+table_oid+" "+
+			"ORDER BY c.column_id;", tablename)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w - %s", sqldb.ErrNoTable, tablename)
+	}
+
+	columns := []sqldb.ColumnMeta{}
+	for _, r := range rows {
+		row := sqldb.Row(r)
+		c := sqldb.ColumnMeta{}
+		c.Name, _ = row.String("name")
+		c.Type, _ = row.String("type")
+		c.Type = strings.ToUpper(c.Type)
+		size, _ := row.Int64("size")
+		precision, _ := row.Int64("precision")
+		scale, _ := row.Int64("scale")
+		switch c.Type {
+		case "NVARCHAR", "NCHAR", "VARCHAR", "CHAR", "VARBINARY", "BINARY":
+			if size < 0 {
+				c.Type += "(MAX)"
+			} else {
+				// national types sizes are in bytes
+				if strings.HasPrefix(c.Type, "N") {
+					size /= 2
+				}
+				c.Type += fmt.Sprintf("(%d)", size)
+			}
+		case "DECIMAL", "NUMERIC":
+			c.Type += fmt.Sprintf("(%d,%d)", precision, scale)
+		}
+		if nullable, _ := row.Bool("nullable"); !nullable {
+			c.Type += " NOT NULL"
+		}
+		if dflt, err := row.String("dflt"); err == nil {
+			c.Type += " DEFAULT " + strip_parens(dflt)
+		}
+		if identity, _ := row.Bool("is_identity"); identity {
+			c.Type += " IDENTITY(1,1)"
+		}
+		columns = append(columns, c)
+	}
+
+	indexes, err := e.ListIndexes(dbs, tablename)
+	if err != nil {
+		return nil, err
+	}
+
+	// foreign keys
+	rows, err = dbs.Fetch(
+		"SELECT f.name AS name, c.name AS col, "+
+			"OBJECT_NAME(f.referenced_object_id) AS ref_table, "+
+			"rc.name AS ref_col, "+
+			"f.update_referential_action_desc AS on_update, "+
+			"f.delete_referential_action_desc AS on_delete "+
+			"FROM sys.foreign_keys f "+
+			"JOIN sys.foreign_key_columns k "+
+			"ON k.constraint_object_id=f.object_id "+
+			"JOIN sys.columns c "+
+			"ON c.object_id=k.parent_object_id AND c.column_id=k.parent_column_id "+
+			"JOIN sys.columns rc "+
+			"ON rc.object_id=k.referenced_object_id "+
+			"AND rc.column_id=k.referenced_column_id "+
+			"WHERE f.parent_object_id="+table_oid+" "+
+			"ORDER BY f.name, k.constraint_column_id;", tablename)
+	if err != nil {
+		return nil, err
+	}
+	fkeys := map[string]*sqldb.ForeignKeyMeta{}
+	names := []string{}
+	for _, r := range rows {
+		row := sqldb.Row(r)
+		name, _ := row.String("name")
+		fk, ok := fkeys[name]
+		if !ok {
+			fk = &sqldb.ForeignKeyMeta{Name: name}
+			fk.RefTable, _ = row.String("ref_table")
+			fk.OnUpdate, _ = row.String("on_update")
+			fk.OnDelete, _ = row.String("on_delete")
+			fkeys[name] = fk
+			names = append(names, name)
+		}
+		col, _ := row.String("col")
+		ref, _ := row.String("ref_col")
+		fk.Columns = append(fk.Columns, col)
+		fk.RefColumns = append(fk.RefColumns, ref)
+	}
+	foreignkeys := []sqldb.ForeignKeyMeta{}
+	for _, name := range names {
+		foreignkeys = append(foreignkeys, *fkeys[name])
+	}
+
+	return sqldb.NewTableMeta(columns, indexes, nil, foreignkeys), nil
+}
+
+// ListIndexes returns the table indexes.
+func (e *Engine) ListIndexes(
+	dbs *sqldb.Session, tablename string) ([]sqldb.IndexMeta, error) {
+	rows, err := dbs.Fetch(
+		"SELECT i.name AS name, i.is_unique AS is_unique, "+
+			"i.is_primary_key AS is_primary, c.name AS col "+
+			"FROM sys.indexes i "+
+			"JOIN sys.index_columns k "+
+			"ON k.object_id=i.object_id AND k.index_id=i.index_id "+
+			"JOIN sys.columns c "+
+			"ON c.object_id=k.object_id AND c.column_id=k.column_id "+
+			"WHERE i.object_id="+table_oid+" "+
+			"AND i.name IS NOT NULL AND k.is_included_column=0 "+
+			"ORDER BY i.name, k.key_ordinal;", tablename)
+	if err != nil {
+		return nil, err
+	}
+	result := []sqldb.IndexMeta{}
+	for _, r := range rows {
+		row := sqldb.Row(r)
+		name, _ := row.String("name")
+		n := len(result)
+		if n == 0 || result[n-1].Name != name {
+			ix := sqldb.IndexMeta{Name: name}
+			ix.Unique, _ = row.Bool("is_unique")
+			ix.Primary, _ = row.Bool("is_primary")
+			result = append(result, ix)
+			n++
+		}
+		col, _ := row.String("col")
+		result[n-1].Columns = append(result[n-1].Columns, col)
+	}
+	return result, nil
+}
+
+// ServerVersion returns the database server version.
+func (e *Engine) ServerVersion(dbs *sqldb.Session) (string, error) {
+	rows, err := dbs.Fetch(
+		"SELECT CAST(SERVERPROPERTY('ProductVersion') " +
+			"AS NVARCHAR(128)) AS version;")
+	if err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return "", fmt.Errorf("%w - invalid query result", sqldb.ErrOperation)
+	}
+	return sqldb.Row(rows[0]).String("version")
+}
+
+////////////////////////////////////////////////////
+
+// expression of the table object id in current schema
+const table_oid = "OBJECT_ID(QUOTENAME(SCHEMA_NAME())+'.'+QUOTENAME(?), 'U')"
+
+// strips the outer parentheses of default definition, ex. ((0)) -> 0
+func strip_parens(s string) string {
+	for len(s) > 1 && s[0] == '(' && s[len(s)-1] == ')' {
+		// check the parentheses are matching
+		depth := 0
+		for i, ch := range s {
+			if ch == '(' {
+				depth++
+			} else if ch == ')' {
+				depth--
+			}
+			if depth == 0 && i < len(s)-1 {
+				return s
+			}
+		}
+		s = s[1 : len(s)-1]
+	}
+	return s
+}
@@ -7,6 +7,7 @@ package mysqldb
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"strings"
 	"sync"
 
@@ -102,14 +103,122 @@ func (e *Engine) CanRetryErr(err error) bool {
 	return errors.Is(err, mysql.ErrBusyBuffer)
 }
 
+// IsDuplicateErr checks weather an operation error is for creating
+// an already existing database object.
+func (e *Engine) IsDuplicateErr(err error) bool {
+	var merr *mysql.MySQLError
+	if errors.As(err, &merr) {
+		switch merr.Number {
+		case 1050, // ER_TABLE_EXISTS_ERROR
+			1060, // ER_DUP_FIELDNAME
+			1061, // ER_DUP_KEYNAME
+			1826: // ER_FK_DUP_NAME
+			return true
+		}
+	}
+	return false
+}
+
+// IsMissingTableErr checks weather an operation error is for accessing
+// a not existing table.
+func (e *Engine) IsMissingTableErr(err error) bool {
+	var merr *mysql.MySQLError
+	if errors.As(err, &merr) {
+		switch merr.Number {
+		case 1051, // ER_BAD_TABLE_ERROR
+			1146: // ER_NO_SUCH_TABLE
+			return true
+		}
+	}
+	return false
+}
+
+// TransactionalDDL checks weather the table schema statments can run
+// within a transaction and be rolled back on failure. mysql commits
+// the active transaction implicitly on schema statments.
+func (e *Engine) TransactionalDDL(meta *sqldb.TableMeta) bool {
+	return false
+}
+
+// Types defines the mysql native types of portable column kinds.
+var Types = &sqldb.TypeMap{
+	Types: map[sqldb.Kind]string{
+		sqldb.KindJSON:    "JSON",
+		sqldb.KindString:  "VARCHAR",
+		sqldb.KindText:    "LONGTEXT",
+		sqldb.KindInt:     "INT",
+		sqldb.KindBigInt:  "BIGINT",
+		sqldb.KindBool:    "BOOLEAN",
+		sqldb.KindFloat:   "DOUBLE",
+		sqldb.KindDecimal: "DECIMAL",
+		sqldb.KindTime:    "DATETIME(6)",
+		sqldb.KindDate:    "DATE",
+		sqldb.KindBlob:    "LONGBLOB",
+		sqldb.KindUUID:    "CHAR(36)",
+	},
+	True:          "TRUE",
+	False:         "FALSE",
+	AutoIncrement: "AUTO_INCREMENT",
+}
+
 // SqlGenerator represents mysql SQL statment generator.
 type SqlGenerator struct {
 	sqldb.StdSqlGenerator
+
+	// the engine logger, for warnings on dropped unsupported definitions
+	log *logging.Logger
+}
+
+// ColumnType generates the column type definition
+func (g *SqlGenerator) ColumnType(c *sqldb.ColumnMeta) string {
+	return Types.ColumnType(c)
+}
+
+// JSONValue generates the expression extracting a JSON document value
+func (*SqlGenerator) JSONValue(
+	column, path string, numeric bool) (string, error) {
+	if _, err := sqldb.JSONPathKeys(path); err != nil {
+		return "", err
+	}
+	if numeric {
+		return fmt.Sprintf("JSON_EXTRACT(%s, '%s')", column, path), nil
+	}
+	return fmt.Sprintf(
+		"JSON_UNQUOTE(JSON_EXTRACT(%s, '%s'))", column, path), nil
+}
+
+// FullTextMatch generates the full-text search filter expression. the
+// columns must match exactly the columns of a FULLTEXT index.
+func (*SqlGenerator) FullTextMatch(tablename string, meta *sqldb.TableMeta,
+	columns []string, text string) (string, []any) {
+	terms := []string{}
+	for _, t := range sqldb.SearchTerms(text) {
+		terms = append(terms, `+"`+strings.ReplaceAll(t, `"`, "")+`"`)
+	}
+	stmt := fmt.Sprintf("MATCH (%s) AGAINST (%s IN BOOLEAN MODE)",
+		strings.Join(columns, ", "), sqldb.SQL_PLACEHOLDER)
+	return stmt, []any{strings.Join(terms, " ")}
 }
 
 // Schema generates table schema statments from metainfo
 func (g *SqlGenerator) Schema(tablename string, meta *sqldb.TableMeta) []string {
-	stmts := g.StdSqlGenerator.Schema(tablename, meta)
+	if meta.View != nil {
+		return []string{fmt.Sprintf("CREATE OR REPLACE VIEW %s AS\n%s;",
+			tablename, sqldb.ViewSelect(g, meta.View, Types))}
+	}
+
+	// add FULLTEXT index in table definition
+	if meta.FullText != nil && len(meta.FullText.Columns) > 0 {
+		m := *meta
+		m.Constraints = append(append([]sqldb.ConstraintMeta{},
+			meta.Constraints...), sqldb.ConstraintMeta{
+			Definition: fmt.Sprintf("FULLTEXT ix_%s_fts (%s)", tablename,
+				strings.Join(meta.FullText.Columns, ", ")),
+		})
+		meta = &m
+	}
+
+	stmts := sqldb.GenerateSchema(g, tablename, meta)
 
 	storage_engine := dictx.GetString(meta.Args, "mysql_storage_engine", "")
 	if storage_engine != "" {
@@ -120,7 +229,86 @@ func (g *SqlGenerator) Schema(tablename string, meta *sqldb.TableMeta) []string
 	return stmts
 }
 
+// Index generates the table index creation statment. the included
+// columns are ignored, and the partial indexes predicates are dropped
+// with a logged warning as mysql doesn't support partial indexes, where
+// the index is created on all table rows.
+func (g *SqlGenerator) Index(
+	tablename string, meta *sqldb.TableMeta, ix *sqldb.IndexMeta) string {
+	index := *ix
+	index.Include = nil
+	if index.Where != "" {
+		if g.log != nil {
+			g.log.Warn("partial index not supported, dropped predicate "+
+				"of index %s: %s", sqldb.IndexName(tablename, ix, 64), ix.Where)
+		}
+		index.Where = ""
+	}
+	return sqldb.GenerateIndex(tablename, meta, &index, 64)
+}
+
+// ForeignKey generates the foreign key constraint definition
+func (*SqlGenerator) ForeignKey(tablename string, fk *sqldb.ForeignKeyMeta) string {
+	return sqldb.GenerateForeignKey(tablename, fk, 64)
+}
+
+// AddForeignKey generates the statments adding foreign key constraint
+func (g *SqlGenerator) AddForeignKey(
+	tablename string, fk *sqldb.ForeignKeyMeta) []string {
+	return sqldb.GenerateAddForeignKey(g, tablename, fk)
+}
+
+// AlterSchema generates the statments adding the missing columns
+func (g *SqlGenerator) AlterSchema(diff *sqldb.SchemaDiff) ([]string, error) {
+	return sqldb.GenerateAlterSchema(g, diff)
+}
+
+// Truncate generates the statments deleting all table rows. tables
+// referenced by foreign keys can not be truncated.
+func (*SqlGenerator) Truncate(tablename string, meta *sqldb.TableMeta) []string {
+	return []string{fmt.Sprintf("TRUNCATE TABLE %s;", tablename)}
+}
+
+// ForeignKeyChecks generates the statments disabling or enabling the
+// foreign key checks of the transaction connection.
+func (*SqlGenerator) ForeignKeyChecks(tablenames []string, enable bool) []string {
+	if enable {
+		return []string{"SET FOREIGN_KEY_CHECKS = 1;"}
+	}
+	return []string{"SET FOREIGN_KEY_CHECKS = 0;"}
+}
+
+// Call generates the stored procedure CALL statment with positional
+// args, where the output args are bound to session variables fetched
+// after the call.
+func (*SqlGenerator) Call(proc string, args []sqldb.CallArg) ([]string, []any) {
+	exprs, outs, params := []string{}, []string{}, []any{}
+	for _, a := range args {
+		if a.Out {
+			exprs = append(exprs, "@"+a.Name)
+			outs = append(outs, fmt.Sprintf("@%s AS %s", a.Name, a.Name))
+		} else {
+			exprs = append(exprs, sqldb.SQL_PLACEHOLDER)
+			params = append(params, a.Value)
+		}
+	}
+	stmts := []string{
+		fmt.Sprintf("CALL %s(%s);", proc, strings.Join(exprs, ", "))}
+	if len(outs) > 0 {
+		stmts = append(stmts, "SELECT "+strings.Join(outs, ", ")+";")
+	}
+	return stmts, params
+}
+
 // SqlGenerator returns the engine SQL statment generator.
 func (e *Engine) SqlGenerator() sqldb.SqlGenerator {
-	return &SqlGenerator{}
+	return &SqlGenerator{log: e.Log}
+}
+
+// registers the backend SQL generator for statments generation without
+// database connection.
+func init() {
+	sqldb.RegisterGenerator("mysql", func() sqldb.SqlGenerator {
+		return &SqlGenerator{}
+	})
 }
@@ -0,0 +1,48 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package mysqldb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+func TestIndexPartial(t *testing.T) {
+	g := &SqlGenerator{}
+	ix := &sqldb.IndexMeta{
+		Columns: []string{"a"}, Include: []string{"b"}, Where: "a > 0"}
+	want := "CREATE INDEX IF NOT EXISTS ix_t_a ON t (a);"
+	if got := g.Index("t", nil, ix); got != want {
+		t.Errorf("Index() = %q, want %q", got, want)
+	}
+}
+
+func TestCall(t *testing.T) {
+	g := &SqlGenerator{}
+	stmts, params := g.Call("db.proc", []sqldb.CallArg{
+		{Name: "a", Value: 1},
+		{Name: "total", Value: 0, Out: true},
+		{Name: "b", Value: "x"},
+		{Name: "msg", Out: true},
+	})
+	want := []string{
+		"CALL db.proc(?, @total, ?, @msg);",
+		"SELECT @total AS total, @msg AS msg;",
+	}
+	if !reflect.DeepEqual(stmts, want) {
+		t.Errorf("Call() = %q, want %q", stmts, want)
+	}
+	if !reflect.DeepEqual(params, []any{1, "x"}) {
+		t.Errorf("Call() params = %v", params)
+	}
+
+	// no output args statment
+	stmts, params = g.Call("proc", nil)
+	if len(stmts) != 1 || stmts[0] != "CALL proc();" || len(params) != 0 {
+		t.Errorf("Call() = %q, %v", stmts, params)
+	}
+}
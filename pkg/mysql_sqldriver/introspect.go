@@ -0,0 +1,191 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package mysqldb
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+// numeric literals and function calls defaults, which are not quoted
+var rawDefaultRegex = regexp.MustCompile(`^(-?[0-9.]+|[A-Za-z_]+\(.*\)|CURRENT_[A-Z_]+)$`)
+
+// ListTables returns the database tables names.
+func (e *Engine) ListTables(dbs *sqldb.Session) ([]string, error) {
+	rows, err := dbs.Fetch(
+		"SELECT table_name AS name FROM information_schema.tables " +
+			"WHERE table_schema=DATABASE() AND table_type='BASE TABLE' " +
+			"ORDER BY table_name;")
+	if err != nil {
+		return nil, err
+	}
+	result := []string{}
+	for _, r := range rows {
+		name, err := sqldb.Row(r).String("name")
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, name)
+	}
+	return result, nil
+}
+
+// DescribeTable returns the table metainfo from database.
+func (e *Engine) DescribeTable(
+	dbs *sqldb.Session, tablename string) (*sqldb.TableMeta, error) {
+	rows, err := dbs.Fetch(
+		"SELECT column_name AS name, column_type AS type, "+
+			"is_nullable AS nullable, column_default AS dflt, extra "+
+			"FROM information_schema.columns "+
+			"WHERE table_schema=DATABASE() AND table_name=? "+
+			"ORDER BY ordinal_position;", tablename)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w - %s", sqldb.ErrNoTable, tablename)
+	}
+
+	columns := []sqldb.ColumnMeta{}
+	for _, r := range rows {
+		row := sqldb.Row(r)
+		c := sqldb.ColumnMeta{}
+		c.Name, _ = row.String("name")
+		c.Type, _ = row.String("type")
+		c.Type = strings.ToUpper(c.Type)
+		if nullable, _ := row.String("nullable"); nullable == "NO" {
+			c.Type += " NOT NULL"
+		}
+		if dflt, err := row.String("dflt"); err == nil {
+			if !rawDefaultRegex.MatchString(dflt) {
+				dflt = "'" + strings.ReplaceAll(dflt, "'", "''") + "'"
+			}
+			c.Type += " DEFAULT " + dflt
+		}
+		if extra, _ := row.String("extra"); strings.Contains(
+			strings.ToLower(extra), "auto_increment") {
+			c.Type += " AUTO_INCREMENT"
+		}
+		columns = append(columns, c)
+	}
+
+	indexes, err := e.ListIndexes(dbs, tablename)
+	if err != nil {
+		return nil, err
+	}
+
+	// foreign keys
+	rows, err = dbs.Fetch(
+		"SELECT k.constraint_name AS name, k.column_name AS col, "+
+			"k.referenced_table_name AS ref_table, "+
+			"k.referenced_column_name AS ref_col, "+
+			"r.update_rule AS on_update, r.delete_rule AS on_delete "+
+			"FROM information_schema.key_column_usage k "+
+			"JOIN information_schema.referential_constraints r "+
+			"ON r.constraint_schema=k.constraint_schema "+
+			"AND r.constraint_name=k.constraint_name "+
+			"WHERE k.table_schema=DATABASE() AND k.table_name=? "+
+			"ORDER BY k.constraint_name, k.ordinal_position;", tablename)
+	if err != nil {
+		return nil, err
+	}
+	fkeys := map[string]*sqldb.ForeignKeyMeta{}
+	names := []string{}
+	for _, r := range rows {
+		row := sqldb.Row(r)
+		name, _ := row.String("name")
+		fk, ok := fkeys[name]
+		if !ok {
+			fk = &sqldb.ForeignKeyMeta{Name: name}
+			fk.RefTable, _ = row.String("ref_table")
+			fk.OnUpdate, _ = row.String("on_update")
+			fk.OnDelete, _ = row.String("on_delete")
+			fkeys[name] = fk
+			names = append(names, name)
+		}
+		col, _ := row.String("col")
+		ref, _ := row.String("ref_col")
+		fk.Columns = append(fk.Columns, col)
+		fk.RefColumns = append(fk.RefColumns, ref)
+	}
+	foreignkeys := []sqldb.ForeignKeyMeta{}
+	for _, name := range names {
+		foreignkeys = append(foreignkeys, *fkeys[name])
+	}
+
+	return sqldb.NewTableMeta(columns, indexes, nil, foreignkeys), nil
+}
+
+// ListIndexes returns the table indexes.
+func (e *Engine) ListIndexes(
+	dbs *sqldb.Session, tablename string) ([]sqldb.IndexMeta, error) {
+	rows, err := dbs.Fetch(
+		"SELECT index_name AS name, non_unique AS non_unique, "+
+			"column_name AS col FROM information_schema.statistics "+
+			"WHERE table_schema=DATABASE() AND table_name=? "+
+			"ORDER BY index_name, seq_in_index;", tablename)
+	if err != nil {
+		return nil, err
+	}
+	result := []sqldb.IndexMeta{}
+	for _, r := range rows {
+		row := sqldb.Row(r)
+		name, _ := row.String("name")
+		col, _ := row.String("col")
+		n := len(result)
+		if n == 0 || result[n-1].Name != name {
+			non_unique, _ := row.Bool("non_unique")
+			result = append(result, sqldb.IndexMeta{
+				Name:    name,
+				Unique:  !non_unique,
+				Primary: name == "PRIMARY",
+			})
+			n++
+		}
+		if col != "" {
+			result[n-1].Columns = append(result[n-1].Columns, col)
+		}
+	}
+	return result, nil
+}
+
+// ServerVersion returns the database server version.
+func (e *Engine) ServerVersion(dbs *sqldb.Session) (string, error) {
+	rows, err := dbs.Fetch("SELECT VERSION() AS version;")
+	if err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return "", fmt.Errorf("%w - invalid query result", sqldb.ErrOperation)
+	}
+	return sqldb.Row(rows[0]).String("version")
+}
+
+// ProcArgs returns the stored procedure arguments names in order, used
+// to order the positional arguments of procedures calls.
+func (e *Engine) ProcArgs(dbs *sqldb.Session, proc string) ([]string, error) {
+	schema, name := "DATABASE()", proc
+	params := []any{name}
+	if i := strings.Index(proc, "."); i > 0 {
+		schema, name = sqldb.SQL_PLACEHOLDER, proc[i+1:]
+		params = []any{proc[:i], name}
+	}
+	rows, err := dbs.Fetch(
+		"SELECT parameter_name AS name FROM information_schema.parameters "+
+			"WHERE specific_schema="+schema+" AND specific_name=? "+
+			"AND ordinal_position>0 ORDER BY ordinal_position;", params...)
+	if err != nil {
+		return nil, err
+	}
+	result := []string{}
+	for _, r := range rows {
+		name, _ := sqldb.Row(r).String("name")
+		result = append(result, name)
+	}
+	return result, nil
+}
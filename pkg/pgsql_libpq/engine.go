@@ -6,6 +6,8 @@ package pgsqldb
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 	"sync"
@@ -113,6 +115,58 @@ func (e *Engine) CanRetryErr(err error) bool {
 	return false
 }
 
+// IsDuplicateErr checks weather an operation error is for creating
+// an already existing database object.
+func (e *Engine) IsDuplicateErr(err error) bool {
+	var perr *pgsql.Error
+	if errors.As(err, &perr) {
+		switch perr.Code {
+		case "42P07", // "duplicate_table"
+			"42701", // "duplicate_column"
+			"42710": // "duplicate_object"
+			return true
+		}
+	}
+	return false
+}
+
+// IsMissingTableErr checks weather an operation error is for accessing
+// a not existing table.
+func (e *Engine) IsMissingTableErr(err error) bool {
+	var perr *pgsql.Error
+	if errors.As(err, &perr) {
+		return perr.Code == "42P01" // "undefined_table"
+	}
+	return false
+}
+
+// TransactionalDDL checks weather the table schema statments can run
+// within a transaction and be rolled back on failure.
+func (e *Engine) TransactionalDDL(meta *sqldb.TableMeta) bool {
+	return true
+}
+
+// Types defines the pgsql native types of portable column kinds.
+var Types = &sqldb.TypeMap{
+	Types: map[sqldb.Kind]string{
+		sqldb.KindJSON:    "JSONB",
+		sqldb.KindString:  "VARCHAR",
+		sqldb.KindText:    "TEXT",
+		sqldb.KindInt:     "INTEGER",
+		sqldb.KindBigInt:  "BIGINT",
+		sqldb.KindBool:    "BOOLEAN",
+		sqldb.KindFloat:   "DOUBLE PRECISION",
+		sqldb.KindDecimal: "NUMERIC",
+		sqldb.KindTime:    "TIMESTAMP",
+		sqldb.KindDate:    "DATE",
+		sqldb.KindBlob:    "BYTEA",
+		sqldb.KindUUID:    "UUID",
+	},
+	True:          "TRUE",
+	False:         "FALSE",
+	AutoIncrement: "GENERATED BY DEFAULT AS IDENTITY",
+}
+
 // SqlGenerator represents postgres SQL statment generator.
 type SqlGenerator struct {
 	sqldb.StdSqlGenerator
@@ -128,7 +182,183 @@ func (*SqlGenerator) FormatStmt(stmt string) string {
 	return stmt
 }
 
+// ColumnType generates the column type definition
+func (g *SqlGenerator) ColumnType(c *sqldb.ColumnMeta) string {
+	return Types.ColumnType(c)
+}
+
+// JSONValue generates the expression extracting a JSON document value
+// using the path operator, which resolves both the object keys and the
+// array indexes path elements.
+func (*SqlGenerator) JSONValue(
+	column, path string, numeric bool) (string, error) {
+	keys, err := sqldb.JSONPathKeys(path)
+	if err != nil {
+		return "", err
+	}
+	expr := fmt.Sprintf("%s#>>'{%s}'", column, strings.Join(keys, ","))
+	if numeric {
+		return "(" + expr + ")::numeric", nil
+	}
+	return expr, nil
+}
+
+// FullTextMatch generates the full-text search filter expression using
+// the text search functions on columns.
+func (*SqlGenerator) FullTextMatch(tablename string, meta *sqldb.TableMeta,
+	columns []string, text string) (string, []any) {
+	lang := fulltext_language(meta)
+	stmt := fmt.Sprintf("%s @@ plainto_tsquery('%s', %s)",
+		fulltext_document(lang, columns), lang, sqldb.SQL_PLACEHOLDER)
+	return stmt, []any{text}
+}
+
+// Schema generates table schema statments from metainfo
+func (g *SqlGenerator) Schema(tablename string, meta *sqldb.TableMeta) []string {
+	if meta.View != nil {
+		create := "CREATE OR REPLACE VIEW"
+		if meta.View.Materialized {
+			create = "CREATE MATERIALIZED VIEW IF NOT EXISTS"
+		}
+		return []string{fmt.Sprintf("%s %s AS\n%s;",
+			create, tablename, sqldb.ViewSelect(g, meta.View, Types))}
+	}
+
+	stmts := sqldb.GenerateSchema(g, tablename, meta)
+
+	// create GIN index on text search document of full-text columns
+	if meta.FullText != nil && len(meta.FullText.Columns) > 0 {
+		index_exists := " IF NOT EXISTS"
+		if dictx.Fetch(meta.Args, "disable_index_exists", false) {
+			index_exists = ""
+		}
+		stmts = append(stmts, fmt.Sprintf(
+			"CREATE INDEX%s ix_%s_fts ON %s USING GIN (%s);",
+			index_exists, tablename, tablename, fulltext_document(
+				fulltext_language(meta), meta.FullText.Columns)))
+	}
+
+	return stmts
+}
+
+// returns the text search language config of table
+func fulltext_language(meta *sqldb.TableMeta) string {
+	if meta != nil && meta.FullText != nil &&
+		sqldb.SqlIdent(meta.FullText.Language) {
+		return meta.FullText.Language
+	}
+	return "simple"
+}
+
+// returns the text search document expression of columns, which must
+// match the index expression to use the full-text index.
+func fulltext_document(lang string, columns []string) string {
+	cols := []string{}
+	for _, c := range columns {
+		cols = append(cols, fmt.Sprintf("coalesce(%s,'')", c))
+	}
+	return fmt.Sprintf("to_tsvector('%s', %s)",
+		lang, strings.Join(cols, " || ' ' || "))
+}
+
+// Index generates the table index creation statment
+func (*SqlGenerator) Index(
+	tablename string, meta *sqldb.TableMeta, ix *sqldb.IndexMeta) string {
+	return sqldb.GenerateIndex(tablename, meta, ix, 63)
+}
+
+// ForeignKey generates the foreign key constraint definition
+func (*SqlGenerator) ForeignKey(tablename string, fk *sqldb.ForeignKeyMeta) string {
+	return sqldb.GenerateForeignKey(tablename, fk, 63)
+}
+
+// AddForeignKey generates the statments adding foreign key constraint
+// if not exists, as failing statments abort the running transaction.
+func (g *SqlGenerator) AddForeignKey(
+	tablename string, fk *sqldb.ForeignKeyMeta) []string {
+	return []string{fmt.Sprintf(
+		"DO $$\nBEGIN\nIF NOT EXISTS (SELECT 1 FROM pg_constraint "+
+			"WHERE conname='%s') THEN\n%s\nEND IF;\nEND $$;",
+		sqldb.ForeignKeyName(tablename, fk, 63),
+		sqldb.GenerateAddForeignKey(g, tablename, fk)[0])}
+}
+
+// AlterSchema generates the statments adding the missing columns
+func (g *SqlGenerator) AlterSchema(diff *sqldb.SchemaDiff) ([]string, error) {
+	return sqldb.GenerateAlterSchema(g, diff)
+}
+
+// Truncate generates the statments deleting all table rows and
+// restarting the table identity sequences. tables referenced by foreign
+// keys can not be truncated.
+func (*SqlGenerator) Truncate(tablename string, meta *sqldb.TableMeta) []string {
+	return []string{
+		fmt.Sprintf("TRUNCATE TABLE %s RESTART IDENTITY;", tablename)}
+}
+
+// ForeignKeyChecks generates the statments disabling or enabling the
+// foreign key triggers within transaction, using the replica session
+// replication role which requires superuser privileges.
+func (*SqlGenerator) ForeignKeyChecks(tablenames []string, enable bool) []string {
+	if enable {
+		return []string{"SET LOCAL session_replication_role = DEFAULT;"}
+	}
+	return []string{"SET LOCAL session_replication_role = replica;"}
+}
+
+// ResetIdentity generates the statment setting the table identity or
+// serial column sequence after the column max value, as the sequence is
+// not advanced by explicit values inserts.
+func (*SqlGenerator) ResetIdentity(tablename, column string) []string {
+	return []string{fmt.Sprintf(
+		"SELECT setval(pg_get_serial_sequence('%s', '%s'), "+
+			"coalesce(max(%s), 0) + 1, false) FROM %s;",
+		tablename, column, column, tablename)}
+}
+
+// DropSchema generates the statments dropping table or view schema
+func (*SqlGenerator) DropSchema(tablename string, meta *sqldb.TableMeta) []string {
+	if meta != nil && meta.View != nil && meta.View.Materialized {
+		return []string{
+			fmt.Sprintf("DROP MATERIALIZED VIEW IF EXISTS %s;", tablename)}
+	}
+	if meta != nil && meta.View != nil {
+		return []string{fmt.Sprintf("DROP VIEW IF EXISTS %s;", tablename)}
+	}
+	return []string{fmt.Sprintf("DROP TABLE IF EXISTS %s;", tablename)}
+}
+
+// RefreshView generates the statments refreshing materialized view data
+func (*SqlGenerator) RefreshView(tablename string, meta *sqldb.TableMeta) []string {
+	if meta == nil || meta.View == nil || !meta.View.Materialized {
+		return nil
+	}
+	return []string{fmt.Sprintf("REFRESH MATERIALIZED VIEW %s;", tablename)}
+}
+
+// Call generates the function SELECT statment with named args, where
+// the function output args are returned as result columns.
+func (*SqlGenerator) Call(proc string, args []sqldb.CallArg) ([]string, []any) {
+	exprs, params := []string{}, []any{}
+	for _, a := range args {
+		if !a.Out {
+			exprs = append(exprs, a.Name+" => "+sqldb.SQL_PLACEHOLDER)
+			params = append(params, a.Value)
+		}
+	}
+	return []string{fmt.Sprintf("SELECT * FROM %s(%s);",
+		proc, strings.Join(exprs, ", "))}, params
+}
+
 // SqlGenerator returns the engine SQL statment generator.
 func (e *Engine) SqlGenerator() sqldb.SqlGenerator {
 	return &SqlGenerator{}
 }
+
+// registers the backend SQL generator for statments generation without
+// database connection.
+func init() {
+	sqldb.RegisterGenerator("pgsql", func() sqldb.SqlGenerator {
+		return &SqlGenerator{}
+	})
+}
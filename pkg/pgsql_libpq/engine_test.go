@@ -0,0 +1,56 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package pgsqldb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+func TestJSONValue(t *testing.T) {
+	g := &SqlGenerator{}
+	tests := []struct {
+		path    string
+		numeric bool
+		want    string
+	}{
+		{"$.a", false, "doc#>>'{a}'"},
+		{"$[0]", false, "doc#>>'{0}'"},
+		{"$.a[1].b", true, "(doc#>>'{a,1,b}')::numeric"},
+	}
+	for _, tt := range tests {
+		got, err := g.JSONValue("doc", tt.path, tt.numeric)
+		if err != nil || got != tt.want {
+			t.Errorf("JSONValue(%q) = %q, %v, want %q", tt.path, got, err, tt.want)
+		}
+	}
+	if _, err := g.JSONValue("doc", "$.a'--", false); err == nil {
+		t.Errorf("JSONValue() invalid path error not returned")
+	}
+}
+
+func TestCall(t *testing.T) {
+	g := &SqlGenerator{}
+	stmts, params := g.Call("public.fn", []sqldb.CallArg{
+		{Name: "a", Value: 1},
+		{Name: "total", Value: 0, Out: true},
+		{Name: "b", Value: "x"},
+	})
+	want := []string{"SELECT * FROM public.fn(a => ?, b => ?);"}
+	if !reflect.DeepEqual(stmts, want) {
+		t.Errorf("Call() = %q, want %q", stmts, want)
+	}
+	if !reflect.DeepEqual(params, []any{1, "x"}) {
+		t.Errorf("Call() params = %v", params)
+	}
+
+	// the formatted statment uses numbered placeholders
+	if stmt := g.FormatStmt(stmts[0]); stmt !=
+		"SELECT * FROM public.fn(a => $1, b => $2);" {
+		t.Errorf("FormatStmt() = %q", stmt)
+	}
+}
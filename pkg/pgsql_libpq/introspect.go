@@ -0,0 +1,194 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package pgsqldb
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+// foreign key definition format from pg_get_constraintdef()
+var foreignKeyRegex = regexp.MustCompile(
+	`^FOREIGN KEY \((.+?)\) REFERENCES ([^\s(]+)\((.+?)\)(.*)$`)
+
+// foreign key referential action in constraint definition
+var fkActionRegex = regexp.MustCompile(
+	`ON (UPDATE|DELETE) (CASCADE|RESTRICT|SET NULL|SET DEFAULT|NO ACTION)`)
+
+// ListTables returns the database tables names.
+func (e *Engine) ListTables(dbs *sqldb.Session) ([]string, error) {
+	rows, err := dbs.Fetch(
+		"SELECT tablename AS name FROM pg_catalog.pg_tables " +
+			"WHERE schemaname=current_schema() ORDER BY tablename;")
+	if err != nil {
+		return nil, err
+	}
+	result := []string{}
+	for _, r := range rows {
+		name, err := sqldb.Row(r).String("name")
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, name)
+	}
+	return result, nil
+}
+
+// DescribeTable returns the table metainfo from database.
+func (e *Engine) DescribeTable(
+	dbs *sqldb.Session, tablename string) (*sqldb.TableMeta, error) {
+	rows, err := dbs.Fetch(
+		"SELECT a.attname AS name, "+
+			"format_type(a.atttypid, a.atttypmod) AS type, "+
+			"a.attnotnull AS notnull, "+
+			"pg_get_expr(d.adbin, d.adrelid) AS dflt, "+
+			"a.attidentity::text AS identity "+
+			"FROM pg_catalog.pg_attribute a "+
+			"LEFT JOIN pg_catalog.pg_attrdef d "+
+			"ON d.adrelid=a.attrelid AND d.adnum=a.attnum "+
+			"WHERE a.attrelid=("+table_oid+") "+
+			"AND a.attnum>0 AND NOT a.attisdropped "+
+			"ORDER BY a.attnum;", tablename)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w - %s", sqldb.ErrNoTable, tablename)
+	}
+
+	columns := []sqldb.ColumnMeta{}
+	for _, r := range rows {
+		row := sqldb.Row(r)
+		c := sqldb.ColumnMeta{}
+		c.Name, _ = row.String("name")
+		c.Type, _ = row.String("type")
+		c.Type = strings.ToUpper(c.Type)
+		if notnull, _ := row.Bool("notnull"); notnull {
+			c.Type += " NOT NULL"
+		}
+		if dflt, err := row.String("dflt"); err == nil {
+			c.Type += " DEFAULT " + dflt
+		}
+		switch identity, _ := row.String("identity"); identity {
+		case "a":
+			c.Type += " GENERATED ALWAYS AS IDENTITY"
+		case "d":
+			c.Type += " GENERATED BY DEFAULT AS IDENTITY"
+		}
+		columns = append(columns, c)
+	}
+
+	indexes, err := e.ListIndexes(dbs, tablename)
+	if err != nil {
+		return nil, err
+	}
+
+	// foreign keys
+	rows, err = dbs.Fetch(
+		"SELECT conname AS name, pg_get_constraintdef(oid) AS def "+
+			"FROM pg_catalog.pg_constraint "+
+			"WHERE contype='f' AND conrelid=("+table_oid+") "+
+			"ORDER BY conname;", tablename)
+	if err != nil {
+		return nil, err
+	}
+	constraints := []sqldb.ConstraintMeta{}
+	foreignkeys := []sqldb.ForeignKeyMeta{}
+	for _, r := range rows {
+		row := sqldb.Row(r)
+		name, _ := row.String("name")
+		def, _ := row.String("def")
+		m := foreignKeyRegex.FindStringSubmatch(def)
+		if m == nil {
+			constraints = append(constraints, sqldb.ConstraintMeta{
+				Name: name, Definition: def})
+			continue
+		}
+		fk := sqldb.ForeignKeyMeta{
+			Name:       name,
+			Columns:    split_names(m[1]),
+			RefTable:   m[2],
+			RefColumns: split_names(m[3]),
+		}
+		for _, a := range fkActionRegex.FindAllStringSubmatch(m[4], -1) {
+			if a[1] == "UPDATE" {
+				fk.OnUpdate = a[2]
+			} else {
+				fk.OnDelete = a[2]
+			}
+		}
+		foreignkeys = append(foreignkeys, fk)
+	}
+
+	return sqldb.NewTableMeta(columns, indexes, constraints, foreignkeys), nil
+}
+
+// ListIndexes returns the table indexes.
+func (e *Engine) ListIndexes(
+	dbs *sqldb.Session, tablename string) ([]sqldb.IndexMeta, error) {
+	rows, err := dbs.Fetch(
+		"SELECT c.relname AS name, i.indisunique AS is_unique, "+
+			"i.indisprimary AS is_primary, a.attname AS col "+
+			"FROM pg_catalog.pg_index i "+
+			"JOIN pg_catalog.pg_class c ON c.oid=i.indexrelid "+
+			"CROSS JOIN LATERAL unnest(i.indkey) WITH ORDINALITY AS k(attnum, n) "+
+			"LEFT JOIN pg_catalog.pg_attribute a "+
+			"ON a.attrelid=i.indrelid AND a.attnum=k.attnum "+
+			"WHERE i.indrelid=("+table_oid+") "+
+			"ORDER BY c.relname, k.n;", tablename)
+	if err != nil {
+		return nil, err
+	}
+	result := []sqldb.IndexMeta{}
+	for _, r := range rows {
+		row := sqldb.Row(r)
+		name, _ := row.String("name")
+		n := len(result)
+		if n == 0 || result[n-1].Name != name {
+			ix := sqldb.IndexMeta{Name: name}
+			ix.Unique, _ = row.Bool("is_unique")
+			ix.Primary, _ = row.Bool("is_primary")
+			result = append(result, ix)
+			n++
+		}
+		// expression indexes have no column name
+		if col, err := row.String("col"); err == nil {
+			result[n-1].Columns = append(result[n-1].Columns, col)
+		}
+	}
+	return result, nil
+}
+
+// ServerVersion returns the database server version.
+func (e *Engine) ServerVersion(dbs *sqldb.Session) (string, error) {
+	rows, err := dbs.Fetch(
+		"SELECT current_setting('server_version') AS version;")
+	if err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return "", fmt.Errorf("%w - invalid query result", sqldb.ErrOperation)
+	}
+	return sqldb.Row(rows[0]).String("version")
+}
+
+////////////////////////////////////////////////////
+
+// query of the table oid in current schema
+const table_oid = "SELECT c.oid FROM pg_catalog.pg_class c " +
+	"JOIN pg_catalog.pg_namespace n ON n.oid=c.relnamespace " +
+	"WHERE n.nspname=current_schema() AND c.relname=? AND c.relkind='r'"
+
+// splits the comma separated names list
+func split_names(names string) []string {
+	result := []string{}
+	for _, n := range strings.Split(names, ",") {
+		result = append(result, strings.TrimSpace(n))
+	}
+	return result
+}
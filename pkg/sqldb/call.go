@@ -0,0 +1,283 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CallArg represents a stored procedure call argument.
+type CallArg struct {
+	// the argument name without backend prefix.
+	Name string
+	// the input argument value, or the output argument initial value,
+	// which sets the output value type for backends binding output args.
+	Value any
+	// set for output arguments.
+	Out bool
+}
+
+// ProcArgsLister defines the interface of engines listing the stored
+// procedures arguments, used to order the call arguments for backends
+// with positional arguments.
+type ProcArgsLister interface {
+	// ProcArgs returns the stored procedure arguments names in order.
+	ProcArgs(dbs *Session, proc string) ([]string, error)
+}
+
+// CallOutDest returns the destination pointer of output argument, typed
+// as the argument initial value. it is used by the backends generators
+// binding the output arguments, where nil initial values return untyped
+// destinations that are rejected by Call.
+func CallOutDest(value any) any {
+	if value == nil {
+		return new(any)
+	}
+	dest := reflect.New(reflect.TypeOf(value))
+	dest.Elem().Set(reflect.ValueOf(value))
+	return dest.Interface()
+}
+
+// Call runs a stored procedure and returns all its result sets. the in
+// map defines the input arguments and the out map defines the output
+// arguments, which are set with the output values after the call.
+// the initial values of output arguments set the output values types
+// for backends binding output arguments (mssql), where typed initial
+// values are required.
+//
+// the procedure is called using EXEC (mssql), CALL (mysql) or as a
+// function SELECT (pgsql), where the function output arguments are
+// returned as result columns. sqlite doesn't support stored procedures.
+// raw calls are not allowed when session default filters are defined
+// unless AllowRawExec is set.
+func (s *Session) Call(proc string, in map[string]any,
+	out map[string]any) ([][]Data, error) {
+	if len(s.filters) > 0 && !s.AllowRawExec {
+		return nil, ErrRawExec
+	}
+	if err := s.check_run(); err != nil {
+		return nil, err
+	}
+	for _, p := range strings.Split(proc, ".") {
+		if !SqlIdent(p) {
+			return nil, fmt.Errorf("%w - invalid procedure name %s",
+				ErrOperation, proc)
+		}
+	}
+	args, err := s.call_args(proc, in, out)
+	if err != nil {
+		return nil, err
+	}
+
+	g := s.db.engine.SqlGenerator()
+	stmts, params := g.Call(proc, args)
+	if len(stmts) == 0 {
+		return nil, fmt.Errorf("%w - stored procedures", ErrNotSupported)
+	}
+	if err := call_out_check(params); err != nil {
+		return nil, err
+	}
+	stmt := stmts[0]
+	if len(params) > 0 {
+		stmt = g.FormatStmt(stmt)
+	}
+
+	if s.db.Log != nil {
+		if len(params) > 0 {
+			s.db.Log.Trace("SQL: %s %v", stmt, params)
+		} else {
+			s.db.Log.Trace("SQL: %s", stmt)
+		}
+	}
+
+	var ctx context.Context
+	s.breakEvent.Clear()
+	if s.OperationTimeout > 0 {
+		ctx, s.ctxBreak = context.WithDeadline(s.db.ctx, time.Now().Add(
+			time.Duration(s.OperationTimeout*float64(time.Second))))
+	} else {
+		ctx, s.ctxBreak = context.WithCancel(s.db.ctx)
+	}
+	defer s.ctxBreak()
+
+	// the call and the output args statments run on the same connection
+	var conn interface {
+		QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+	}
+	if s.sdb != nil && s.stx != nil {
+		conn = s.stx
+	} else {
+		sdb, err := s.db.engine.SqlDB()
+		if err != nil {
+			return nil, fmt.Errorf("%w - %v", ErrOpen, err)
+		}
+		defer s.db.engine.Release(sdb)
+		c, err := sdb.Conn(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%w - %v", ErrOpen, err)
+		}
+		defer c.Close()
+		conn = c
+	}
+
+	// the call statment is not retried as procedures may not be
+	// safe to run again.
+	rows, err := conn.QueryContext(ctx, stmt, params...)
+	if err != nil {
+		return nil, call_error(err)
+	}
+	result := [][]Data{}
+	for {
+		data, err := scan_rows(rows, nil)
+		if err != nil {
+			rows.Close()
+			return nil, call_error(err)
+		}
+		result = append(result, data)
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return nil, call_error(err)
+	}
+
+	// set the output args values, which are bound as output params or
+	// fetched by the output args statments or returned as result columns.
+	done := map[string]bool{}
+	for _, p := range params {
+		if n, ok := p.(sql.NamedArg); ok {
+			if o, ok := n.Value.(sql.Out); ok {
+				out[n.Name] = reflect.ValueOf(o.Dest).Elem().Interface()
+				done[n.Name] = true
+			}
+		}
+	}
+	for _, stmt := range stmts[1:] {
+		if s.db.Log != nil {
+			s.db.Log.Trace("SQL: %s", stmt)
+		}
+		rows, err := conn.QueryContext(ctx, stmt)
+		if err != nil {
+			return nil, call_error(err)
+		}
+		data, err := scan_rows(rows, nil)
+		rows.Close()
+		if err != nil {
+			return nil, call_error(err)
+		}
+		if len(data) > 0 {
+			for name := range out {
+				if v, ok := data[0][name]; ok {
+					out[name] = v
+					done[name] = true
+				}
+			}
+		}
+	}
+	if len(result) > 0 && len(result[0]) > 0 {
+		for name := range out {
+			if v, ok := result[0][0][name]; ok && !done[name] {
+				out[name] = v
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// returns the call args from in and out args maps. the args are ordered
+// by the engine procedure args order if supported, otherwise by names.
+func (s *Session) call_args(proc string, in map[string]any,
+	out map[string]any) ([]CallArg, error) {
+	args := []CallArg{}
+	for name, value := range in {
+		args = append(args, CallArg{Name: name, Value: value})
+	}
+	for name, value := range out {
+		if _, ok := in[name]; ok {
+			return nil, fmt.Errorf("%w - duplicate argument %s",
+				ErrOperation, name)
+		}
+		args = append(args, CallArg{Name: name, Value: value, Out: true})
+	}
+	for _, a := range args {
+		if !SqlIdent(a.Name) {
+			return nil, fmt.Errorf("%w - invalid argument name %s",
+				ErrOperation, a.Name)
+		}
+	}
+	sort.Slice(args, func(i, j int) bool { return args[i].Name < args[j].Name })
+
+	lister, ok := s.db.engine.(ProcArgsLister)
+	if !ok || len(args) == 0 {
+		return args, nil
+	}
+	names, err := lister.ProcArgs(s, proc)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return args, nil
+	}
+	result := []CallArg{}
+	for _, name := range names {
+		for _, a := range args {
+			if strings.EqualFold(a.Name, name) {
+				result = append(result, a)
+				break
+			}
+		}
+	}
+	if len(result) < len(args) {
+		for _, a := range args {
+			found := false
+			for _, name := range names {
+				if strings.EqualFold(a.Name, name) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("%w - unknown argument %s for %s",
+					ErrOperation, a.Name, proc)
+			}
+		}
+	}
+	return result, nil
+}
+
+// checks the bound output args destinations are typed, as the drivers
+// can not bind untyped null output params.
+func call_out_check(params []any) error {
+	for _, p := range params {
+		if n, ok := p.(sql.NamedArg); ok {
+			o, ok := n.Value.(sql.Out)
+			if ok && reflect.ValueOf(o.Dest).Elem().Interface() == nil {
+				return fmt.Errorf(
+					"%w - output argument %s requires typed initial value",
+					ErrOperation, n.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// returns the call operation error
+func call_error(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return ErrBreak
+	} else if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w - %v", ErrTimeout, err)
+	}
+	return fmt.Errorf("%w - %w", ErrOperation, err)
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestCallOutDest(t *testing.T) {
+	dest, ok := CallOutDest(int64(5)).(*int64)
+	if !ok || *dest != 5 {
+		t.Errorf("CallOutDest(int64) = %v", dest)
+	}
+	if s, ok := CallOutDest("").(*string); !ok || *s != "" {
+		t.Errorf("CallOutDest(string) = %v", s)
+	}
+
+	// untyped output destinations are rejected
+	params := []any{1, sql.Named("a", 2),
+		sql.Named("b", sql.Out{Dest: CallOutDest(0)})}
+	if err := call_out_check(params); err != nil {
+		t.Errorf("typed output error = %v", err)
+	}
+	params = append(params, sql.Named("c", sql.Out{Dest: CallOutDest(nil)}))
+	if err := call_out_check(params); !errors.Is(err, ErrOperation) {
+		t.Errorf("untyped output error = %v", err)
+	}
+}
@@ -0,0 +1,376 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Codec defines the column codec interface, which converts the column
+// values between the application and the database representations.
+type Codec interface {
+	// Encode converts a value before writing to database.
+	Encode(v any) (any, error)
+	// Decode converts a value after reading from database.
+	Decode(v any) (any, error)
+}
+
+// Built-in column codecs.
+var (
+	// JSONCodec stores values as JSON text.
+	JSONCodec Codec = &jsonCodec{}
+	// CSVCodec stores string slices as CSV text.
+	CSVCodec Codec = &csvCodec{}
+	// HexCodec stores bytes as hex text.
+	HexCodec Codec = &hexCodec{}
+	// Base64Codec stores bytes as base64 text.
+	Base64Codec Codec = &base64Codec{}
+	// GobCodec stores values as gob binary data. custom types must be
+	// registered using gob.Register() to be decoded.
+	GobCodec Codec = &gobCodec{}
+	// TimeCodec stores time values as RFC3339 text.
+	TimeCodec Codec = &timeCodec{}
+	// UnixTimeCodec stores time values as unix timestamp seconds.
+	UnixTimeCodec Codec = &timeCodec{unix: true}
+	// BoolIntCodec stores boolean values as 0 or 1 integers.
+	BoolIntCodec Codec = &boolIntCodec{}
+)
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		"json":      JSONCodec,
+		"csv":       CSVCodec,
+		"hex":       HexCodec,
+		"base64":    Base64Codec,
+		"gob":       GobCodec,
+		"time":      TimeCodec,
+		"unix_time": UnixTimeCodec,
+		"bool_int":  BoolIntCodec,
+	}
+)
+
+// RegisterCodec adds a named codec to the codecs registry, replacing
+// any existing codec with same name.
+func RegisterCodec(name string, codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if codec == nil {
+		delete(codecs, name)
+	} else {
+		codecs[name] = codec
+	}
+}
+
+// GetCodec returns a named codec from the codecs registry or
+// nil if not registered.
+func GetCodec(name string) Codec {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	return codecs[name]
+}
+
+// EncodeData applies the table columns codecs on data before writing
+// to database. nil values are not encoded. for index codecs, the index
+// column value is also set in data.
+func EncodeData(meta *TableMeta, data []Data) error {
+	if meta == nil {
+		return nil
+	}
+	for _, c := range meta.Columns {
+		codec := c.codec()
+		if codec == nil {
+			continue
+		}
+		idx, _ := codec.(IndexCodec)
+		for i := range data {
+			v, ok := data[i][c.Name]
+			if !ok {
+				continue
+			}
+			if idx != nil {
+				if err := encode_index(idx, c.Name, data[i], v); err != nil {
+					return fmt.Errorf("column '%s', %v", c.Name, err)
+				}
+			}
+			if v == nil {
+				continue
+			}
+			res, err := codec.Encode(v)
+			if err != nil {
+				return fmt.Errorf("column '%s', %v", c.Name, err)
+			}
+			data[i][c.Name] = res
+		}
+	}
+	return nil
+}
+
+// DecodeData applies the table columns codecs on data after reading
+// from database. nil values are not decoded.
+func DecodeData(meta *TableMeta, data []Data) error {
+	if meta == nil {
+		return nil
+	}
+	for _, c := range meta.Columns {
+		codec := c.codec()
+		if codec == nil {
+			continue
+		}
+		for i := range data {
+			v, ok := data[i][c.Name]
+			if !ok || v == nil {
+				continue
+			}
+			res, err := codec.Decode(v)
+			if err != nil {
+				return fmt.Errorf("column '%s', %v", c.Name, err)
+			}
+			data[i][c.Name] = res
+		}
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////
+
+// sets the separate index column value in data for index codecs
+func encode_index(codec IndexCodec, column string, data Data, v any) error {
+	col, res, err := codec.Index(column, v)
+	if err != nil {
+		return err
+	}
+	if col != "" && col != column {
+		if v == nil {
+			res = nil
+		}
+		data[col] = res
+	}
+	return nil
+}
+
+// returns the text form of value read from database
+func codec_text(v any) (string, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case []byte:
+		return string(t), nil
+	}
+	return "", fmt.Errorf("invalid value type %T", v)
+}
+
+// returns the bytes form of value written to database
+func codec_bytes(v any) ([]byte, error) {
+	switch t := v.(type) {
+	case []byte:
+		return t, nil
+	case string:
+		return []byte(t), nil
+	}
+	return nil, fmt.Errorf("invalid value type %T", v)
+}
+
+type jsonCodec struct{}
+
+func (*jsonCodec) Encode(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (*jsonCodec) Decode(v any) (any, error) {
+	s, err := codec_text(v)
+	if err != nil {
+		return nil, err
+	}
+	var res any
+	if err := json.Unmarshal([]byte(s), &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type csvCodec struct{}
+
+func (*csvCodec) Encode(v any) (any, error) {
+	var record []string
+	switch t := v.(type) {
+	case []string:
+		record = t
+	case []any:
+		for _, item := range t {
+			record = append(record, fmt.Sprint(item))
+		}
+	default:
+		return nil, fmt.Errorf("invalid value type %T", v)
+	}
+	if len(record) == 0 {
+		return "", nil
+	}
+
+	buff := &bytes.Buffer{}
+	w := csv.NewWriter(buff)
+	if err := w.Write(record); err != nil {
+		return nil, err
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return strings.TrimRight(buff.String(), "\r\n"), nil
+}
+
+func (*csvCodec) Decode(v any) (any, error) {
+	s, err := codec_text(v)
+	if err != nil {
+		return nil, err
+	}
+	if s == "" {
+		return []string{}, nil
+	}
+	r := csv.NewReader(strings.NewReader(s))
+	r.FieldsPerRecord = -1
+	return r.Read()
+}
+
+type hexCodec struct{}
+
+func (*hexCodec) Encode(v any) (any, error) {
+	b, err := codec_bytes(v)
+	if err != nil {
+		return nil, err
+	}
+	return fmt.Sprintf("%X", b), nil
+}
+
+func (*hexCodec) Decode(v any) (any, error) {
+	s, err := codec_text(v)
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(s)
+}
+
+type base64Codec struct{}
+
+func (*base64Codec) Encode(v any) (any, error) {
+	b, err := codec_bytes(v)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+func (*base64Codec) Decode(v any) (any, error) {
+	s, err := codec_text(v)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+type gobCodec struct{}
+
+func (*gobCodec) Encode(v any) (any, error) {
+	buff := &bytes.Buffer{}
+	if err := gob.NewEncoder(buff).Encode(&v); err != nil {
+		return nil, err
+	}
+	return buff.Bytes(), nil
+}
+
+func (*gobCodec) Decode(v any) (any, error) {
+	b, err := codec_bytes(v)
+	if err != nil {
+		return nil, err
+	}
+	var res any
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type timeCodec struct {
+	unix bool
+}
+
+func (c *timeCodec) Encode(v any) (any, error) {
+	t, ok := v.(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("invalid value type %T", v)
+	}
+	if c.unix {
+		return t.Unix(), nil
+	}
+	return t.Format(time.RFC3339Nano), nil
+}
+
+func (c *timeCodec) Decode(v any) (any, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case int64:
+		return time.Unix(t, 0).UTC(), nil
+	case float64:
+		return time.Unix(int64(t), 0).UTC(), nil
+	}
+
+	s, err := codec_text(v)
+	if err != nil {
+		return nil, err
+	}
+	if c.unix {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return time.Unix(n, 0).UTC(), nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+type boolIntCodec struct{}
+
+func (*boolIntCodec) Encode(v any) (any, error) {
+	b, ok := v.(bool)
+	if !ok {
+		return nil, fmt.Errorf("invalid value type %T", v)
+	}
+	if b {
+		return int64(1), nil
+	}
+	return int64(0), nil
+}
+
+func (*boolIntCodec) Decode(v any) (any, error) {
+	switch t := v.(type) {
+	case bool:
+		return t, nil
+	case int64:
+		return t != 0, nil
+	case float64:
+		return t != 0, nil
+	}
+
+	s, err := codec_text(v)
+	if err != nil {
+		return nil, err
+	}
+	return strconv.ParseBool(s)
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCodecsRoundTrip(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 20, 30, 0, time.UTC)
+	tests := []struct {
+		name   string
+		codec  Codec
+		value  any
+		stored any
+	}{
+		{"json", JSONCodec, map[string]any{"a": 1.0, "b": []any{"x"}},
+			`{"a":1,"b":["x"]}`},
+		{"csv", CSVCodec, []string{"a", "b,c", `d"e`}, `a,"b,c","d""e"`},
+		{"csv_empty", CSVCodec, []string{}, ""},
+		{"hex", HexCodec, []byte{0x01, 0xab}, "01AB"},
+		{"base64", Base64Codec, []byte("data"), "ZGF0YQ=="},
+		{"gob", GobCodec, "text", nil},
+		{"time", TimeCodec, now, "2024-05-01T10:20:30Z"},
+		{"unix_time", UnixTimeCodec, now, now.Unix()},
+		{"bool_int", BoolIntCodec, true, int64(1)},
+		{"bool_int_false", BoolIntCodec, false, int64(0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := tt.codec.Encode(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.stored != nil && !reflect.DeepEqual(enc, tt.stored) {
+				t.Errorf("encoded %#v, want %#v", enc, tt.stored)
+			}
+			dec, err := tt.codec.Decode(enc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(dec, tt.value) {
+				t.Errorf("decoded %#v, want %#v", dec, tt.value)
+			}
+		})
+	}
+}
+
+func TestCodecsDecodeDriverValues(t *testing.T) {
+	now := time.Unix(1714558830, 0).UTC()
+	tests := []struct {
+		name  string
+		codec Codec
+		value any
+		want  any
+	}{
+		{"json_bytes", JSONCodec, []byte(`[1]`), []any{1.0}},
+		{"hex_bytes", HexCodec, []byte("01ab"), []byte{0x01, 0xab}},
+		{"unix_time_text", UnixTimeCodec, "1714558830", now},
+		{"unix_time_float", UnixTimeCodec, 1714558830.0, now},
+		{"bool_int_int64", BoolIntCodec, int64(1), true},
+		{"bool_int_text", BoolIntCodec, []byte("0"), false},
+	}
+	for _, tt := range tests {
+		dec, err := tt.codec.Decode(tt.value)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(dec, tt.want) {
+			t.Errorf("%s: decoded %#v, want %#v", tt.name, dec, tt.want)
+		}
+	}
+
+	if _, err := BoolIntCodec.Encode("true"); err == nil {
+		t.Errorf("invalid value type encoded")
+	}
+}
+
+func TestEncodeDecodeData(t *testing.T) {
+	meta := &TableMeta{
+		Columns: []ColumnMeta{
+			{Name: "tags", Type: "TEXT", Codec: CSVCodec},
+			{Name: "attrs", Kind: KindJSON},
+			{Name: "name", Type: "TEXT"},
+		},
+	}
+	data := []Data{
+		{"tags": []string{"a", "b"}, "attrs": map[string]any{"k": "v"},
+			"name": "x"},
+		{"tags": nil, "name": "y"},
+	}
+	if err := EncodeData(meta, data); err != nil {
+		t.Fatal(err)
+	}
+	if data[0]["tags"] != "a,b" || data[0]["attrs"] != `{"k":"v"}` ||
+		data[0]["name"] != "x" {
+		t.Errorf("encoded data %v", data[0])
+	}
+	if data[1]["tags"] != nil {
+		t.Errorf("nil value encoded %v", data[1]["tags"])
+	}
+	if err := DecodeData(meta, data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(data[0]["tags"], []string{"a", "b"}) ||
+		!reflect.DeepEqual(data[0]["attrs"], map[string]any{"k": "v"}) {
+		t.Errorf("decoded data %v", data[0])
+	}
+}
+
+type nopCodec struct{}
+
+func (nopCodec) Encode(v any) (any, error) { return v, nil }
+func (nopCodec) Decode(v any) (any, error) { return v, nil }
+
+func TestRegisterCodec(t *testing.T) {
+	if GetCodec("csv") != CSVCodec || GetCodec("unix_time") != UnixTimeCodec {
+		t.Errorf("builtin codecs not registered")
+	}
+	RegisterCodec("nop", nopCodec{})
+	if _, ok := GetCodec("nop").(nopCodec); !ok {
+		t.Errorf("registered codec = %v", GetCodec("nop"))
+	}
+	RegisterCodec("nop", nil)
+	if GetCodec("nop") != nil {
+		t.Errorf("codec not removed")
+	}
+}
@@ -0,0 +1,296 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CopyOptions represents the models copy options.
+type CopyOptions struct {
+	// BatchSize is the number of rows fetched and inserted per batch,
+	// defaults to 1000.
+	BatchSize int
+	// DisableForeignKeys disables the target foreign key checks while
+	// copying rows. the tables are copied in dependency order, so the
+	// checks need to be disabled only for circular references. disabling
+	// the checks on pgsql requires superuser privileges.
+	DisableForeignKeys bool
+	// Progress is called after each batch with the table name and the
+	// total number of copied table rows.
+	Progress func(table string, count int)
+}
+
+// CopyReport represents the models copy report.
+type CopyReport struct {
+	// the tables reports in copy order.
+	Tables []*TableCopy
+}
+
+// TableCopy represents the table copy report.
+type TableCopy struct {
+	// the table name.
+	Table string
+	// the number of source table rows, copied rows and target table rows
+	// after copy.
+	Source, Copied, Target int
+}
+
+// String returns the table copy report description.
+func (t *TableCopy) String() string {
+	return fmt.Sprintf("%s: %d source, %d copied, %d target",
+		t.Table, t.Source, t.Copied, t.Target)
+}
+
+// CopyModels copies the models tables from the src database into the
+// dst database, which can be of different backends. the target schema
+// is created using the dst SQL generator without running the models
+// hooks and initial data, and the target tables must be empty.
+//
+// the rows are copied in dependency order in batches within one target
+// transaction, where the fetched values are normalized by the models
+// columns types and the guids and auto increment columns values are
+// preserved, then the auto increment sequences are reset after the
+// copied values. a failed sequence reset fails the copy. the foreign key
+// checks are disabled during copy only if DisableForeignKeys is set, and
+// are enabled again before the transaction ends. the tables rows counts
+// are verified after copy.
+func CopyModels(src, dst *Database, metainfo []ModelMeta,
+	opts *CopyOptions) (*CopyReport, error) {
+	if src == nil || dst == nil {
+		return nil, ErrDBHandler
+	}
+	o := CopyOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 1000
+	}
+
+	ordered := models_order(metainfo)
+	report := &CopyReport{}
+	tables := []string{}
+	for _, m := range ordered {
+		if m.meta.Model.TableMeta().View == nil {
+			report.Tables = append(report.Tables, &TableCopy{Table: m.meta.Table})
+			tables = append(tables, m.meta.Table)
+		}
+	}
+
+	// create target schema
+	if dst.Log != nil {
+		dst.Log.Debug("creating target models schema")
+	}
+	stmts, err := models_schema(dst.engine.SqlGenerator(), ordered, dst.Log)
+	if err != nil {
+		return report, err
+	}
+	dbs := dst.Session()
+	for _, s := range stmts {
+		if _, err := dbs.Exec(s.stmt); err != nil &&
+			!dst.engine.IsDuplicateErr(err) {
+			return report, fmt.Errorf("%w - table %s", err, s.table)
+		}
+	}
+
+	// count source rows and check empty target tables
+	srcs := src.Session()
+	models := map[string]Model{}
+	for _, m := range ordered {
+		models[m.meta.Table] = m.meta.Model
+	}
+	for _, t := range report.Tables {
+		if t.Source, err = srcs.Query(models[t.Table]).
+			TableName(t.Table).Count(); err != nil {
+			return report, err
+		}
+		if n, err := dbs.Query(models[t.Table]).
+			TableName(t.Table).Count(); err != nil {
+			return report, err
+		} else if n > 0 {
+			return report, fmt.Errorf("%w - target table %s is not empty",
+				ErrOperation, t.Table)
+		}
+	}
+
+	// copy tables rows
+	if err := copy_rows(srcs, dbs, report, models, tables, &o); err != nil {
+		return report, err
+	}
+
+	// verify the target rows counts
+	mismatch := []string{}
+	for _, t := range report.Tables {
+		if t.Target, err = dbs.Query(models[t.Table]).
+			TableName(t.Table).Count(); err != nil {
+			return report, err
+		}
+		if t.Target != t.Source || t.Copied != t.Source {
+			mismatch = append(mismatch, t.String())
+		}
+		if dst.Log != nil {
+			dst.Log.Debug("copied table %s", t)
+		}
+	}
+	if len(mismatch) > 0 {
+		return report, fmt.Errorf("%w - rows count mismatch, %s",
+			ErrOperation, strings.Join(mismatch, "; "))
+	}
+	return report, nil
+}
+
+// copies the tables rows within one target transaction. the explicit
+// values inserts into auto increment columns are allowed per table and
+// the columns sequences are reset after copy. the session scope settings
+// are reverted on all exit paths, before the transaction ends.
+func copy_rows(srcs, dbs *Session, report *CopyReport,
+	models map[string]Model, tables []string, o *CopyOptions) (err error) {
+	if err := dbs.Begin(); err != nil {
+		return err
+	}
+	g := dbs.db.engine.SqlGenerator()
+
+	// the reverting statments stack
+	reverts := [][]string{}
+	revert := func() error {
+		var rerr error
+		for len(reverts) > 0 {
+			for _, stmt := range reverts[len(reverts)-1] {
+				if _, err := dbs.Exec(stmt); err != nil && rerr == nil {
+					rerr = err
+				}
+			}
+			reverts = reverts[:len(reverts)-1]
+		}
+		return rerr
+	}
+	exec := func(stmts []string) error {
+		for _, stmt := range stmts {
+			if _, err := dbs.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	defer func() {
+		if rerr := revert(); rerr != nil && err == nil {
+			err = rerr
+		}
+		if err != nil {
+			dbs.RollBack()
+		} else {
+			err = dbs.Commit()
+		}
+	}()
+
+	if o.DisableForeignKeys {
+		reverts = append(reverts, g.ForeignKeyChecks(tables, true))
+		if err := exec(g.ForeignKeyChecks(tables, false)); err != nil {
+			return err
+		}
+	}
+	for _, t := range report.Tables {
+		if dbs.db.Log != nil {
+			dbs.db.Log.Debug("copying table %s", t.Table)
+		}
+		key := auto_column(models[t.Table].TableMeta())
+		if key != "" {
+			reverts = append(reverts, g.IdentityInsert(t.Table, false))
+			if err := exec(g.IdentityInsert(t.Table, true)); err != nil {
+				return fmt.Errorf("%w - table %s", err, t.Table)
+			}
+		}
+		if err := copy_table(srcs, dbs, t, models[t.Table], o); err != nil {
+			return fmt.Errorf("%w - table %s", err, t.Table)
+		}
+		if key != "" {
+			// the identity inserts are allowed for one table at a time
+			stmts := reverts[len(reverts)-1]
+			reverts = reverts[:len(reverts)-1]
+			if err := exec(stmts); err != nil {
+				return fmt.Errorf("%w - table %s", err, t.Table)
+			}
+			if err := exec(g.ResetIdentity(t.Table, key)); err != nil {
+				return fmt.Errorf("%w - reset table %s identity", err, t.Table)
+			}
+		}
+	}
+	return nil
+}
+
+// returns the table auto increment column name, or empty if not defined
+func auto_column(meta *TableMeta) string {
+	for _, c := range meta.Columns {
+		if c.AutoIncrement {
+			return c.Name
+		}
+	}
+	return ""
+}
+
+// copies the table rows in batches, ordered by the table primary column
+// and following the last copied key, or by offset ordered by all columns
+// if no primary column is defined or its values are encoded by codec.
+func copy_table(srcs, dbs *Session, t *TableCopy, model Model,
+	o *CopyOptions) error {
+	meta := model.TableMeta()
+	columns := []string{}
+	if meta.AutoGuid &&
+		(len(meta.Columns) == 0 || meta.Columns[0].Name != "guid") {
+		columns = append(columns, "guid")
+	}
+	for _, c := range meta.Columns {
+		columns = append(columns, c.Name)
+	}
+	orders := []string{}
+	key := PrimaryColumn(meta)
+	for _, c := range meta.Columns {
+		if c.Name == key && c.Codec != nil {
+			key = ""
+		}
+	}
+	if key != "" {
+		orders = append(orders, key+" ASC")
+	} else {
+		for _, c := range columns {
+			orders = append(orders, c+" ASC")
+		}
+	}
+
+	var last any
+	for {
+		q := srcs.Query(model).TableName(t.Table).Columns(columns...).
+			OrderBy(orders...).Limit(o.BatchSize)
+		if key == "" {
+			q.Offset(t.Copied)
+		} else if t.Copied > 0 {
+			q.Filters(key+">"+SQL_PLACEHOLDER, last)
+		}
+		rows, err := q.All()
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		if key != "" {
+			last = rows[len(rows)-1][key]
+		}
+		if _, err := dbs.Query(model).TableName(t.Table).
+			InsertMany(rows); err != nil {
+			return err
+		}
+		t.Copied += len(rows)
+		if o.Progress != nil {
+			o.Progress(t.Table, t.Copied)
+		}
+		if len(rows) < o.BatchSize {
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb_test
+
+import (
+	"testing"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+	sqlitedb "github.com/exonlabs/go-sqldb/pkg/sqlite_modernc"
+)
+
+func TestCopyAutoIncrement(t *testing.T) {
+	src, dst := testDatabase(t), testDatabase(t)
+	model := &testModel{meta: &sqldb.TableMeta{
+		Columns: []sqldb.ColumnMeta{
+			{Name: "id", Kind: sqldb.KindInt, Primary: true, AutoIncrement: true},
+			{Name: "name", Kind: sqldb.KindText},
+		},
+	}}
+	metainfo := []sqldb.ModelMeta{{Table: "t", Model: model}}
+	if err := sqldb.InitializeModels(src, metainfo); err != nil {
+		t.Fatal(err)
+	}
+	srcs := src.Session()
+	for _, stmt := range []string{
+		"INSERT INTO t (name) VALUES ('a'), ('b'), ('c');",
+		"DELETE FROM t WHERE id=2;",
+	} {
+		if _, err := srcs.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the introspected column is portable auto increment
+	meta, err := (&sqlitedb.Engine{}).DescribeTable(srcs, "t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := sqldb.PortableColumn(meta.Columns[0]); !c.AutoIncrement {
+		t.Errorf("portable column %+v is not auto increment", c)
+	}
+
+	// the rows are paged by key in single row batches
+	_, err = sqldb.CopyModels(src, dst, metainfo,
+		&sqldb.CopyOptions{BatchSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbs := dst.Session()
+	if n := testCount(t, dbs,
+		"SELECT count(*) AS n FROM t WHERE id IN (1, 3);"); n != 2 {
+		t.Errorf("copied ids not preserved")
+	}
+	if _, err := dbs.Exec("INSERT INTO t (name) VALUES ('d');"); err != nil {
+		t.Fatal(err)
+	}
+	// the sequence continues after the copied values
+	if n := testCount(t, dbs, "SELECT max(id) AS n FROM t;"); n != 4 {
+		t.Errorf("next id = %d, want 4", n)
+	}
+}
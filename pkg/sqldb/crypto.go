@@ -0,0 +1,251 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ciphertext text format prefix
+const cipherPrefix = "enc:"
+
+// KeyProvider defines the encryption keys provider interface.
+type KeyProvider interface {
+	// CurrentKey returns the active key id and key used for encryption.
+	CurrentKey() (string, []byte, error)
+	// Key returns the key for key id, used for decryption.
+	Key(id string) ([]byte, error)
+}
+
+// KeyRing represents a static encryption keys provider.
+type KeyRing struct {
+	// Current is the active key id used for encryption.
+	Current string
+	// Keys maps the key ids to AES keys of 16, 24 or 32 bytes.
+	// key ids must not contain the ':' character.
+	Keys map[string][]byte
+}
+
+// CurrentKey returns the active key id and key used for encryption.
+func (k *KeyRing) CurrentKey() (string, []byte, error) {
+	key, err := k.Key(k.Current)
+	if err != nil {
+		return "", nil, err
+	}
+	return k.Current, key, nil
+}
+
+// Key returns the key for key id, used for decryption.
+func (k *KeyRing) Key(id string) ([]byte, error) {
+	if key, ok := k.Keys[id]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("key id '%s' not found", id)
+}
+
+// IndexCodec defines the codecs supporting equality filters on the
+// encoded column values.
+type IndexCodec interface {
+	Codec
+	// Index returns the column name and value to use in equality filters
+	// for value v of column, or empty column name if filters are not
+	// supported. the returned column is set with the index value on
+	// writes when it differs from the encoded column.
+	Index(column string, v any) (string, any, error)
+}
+
+// EncryptCodec represents an AES-GCM column encryption codec. encrypted
+// values are stored as text including the key id used for encryption,
+// which allows keys rotation without re-encrypting the old values.
+//
+// Supported plain values are strings, bytes and JSON serializable types,
+// where JSON values are decoded into generic types.
+type EncryptCodec struct {
+	// Keys is the encryption keys provider.
+	Keys KeyProvider
+	// Deterministic enables deterministic encryption, where the same plain
+	// values produce the same ciphertext for the same key. this allows
+	// equality filters on column as long as the current key is not rotated.
+	// the nonce is the HMAC of plain value using a nonce key derived from
+	// the encryption key by HKDF, so the encryption key is used only
+	// for AES-GCM.
+	Deterministic bool
+	// IndexColumn defines the blind index column name, which holds a keyed
+	// hash of the plain values to allow equality filters across keys
+	// rotation. leave empty to disable blind index.
+	IndexColumn string
+	// IndexKey is the HMAC key used for blind index values.
+	IndexKey []byte
+}
+
+// NewEncryptCodec creates a new random encryption codec.
+func NewEncryptCodec(keys KeyProvider) *EncryptCodec {
+	return &EncryptCodec{Keys: keys}
+}
+
+// Encode encrypts a value before writing to database.
+func (c *EncryptCodec) Encode(v any) (any, error) {
+	if c.Keys == nil {
+		return nil, errors.New("undefined encryption keys")
+	}
+	plain, err := crypt_marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	id, key, err := c.Keys.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(id, ":") {
+		return nil, fmt.Errorf("invalid key id '%s'", id)
+	}
+	aead, err := crypt_aead(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if c.Deterministic {
+		copy(nonce, crypt_hmac(crypt_hkdf(key, "nonce"), plain))
+	} else if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	b := aead.Seal(nonce, nonce, plain, []byte(id))
+
+	return cipherPrefix + id + ":" +
+		base64.StdEncoding.EncodeToString(b), nil
+}
+
+// Decode decrypts a value after reading from database.
+func (c *EncryptCodec) Decode(v any) (any, error) {
+	if c.Keys == nil {
+		return nil, errors.New("undefined encryption keys")
+	}
+	s, err := codec_text(v)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(s, cipherPrefix) {
+		return nil, errors.New("invalid ciphertext format")
+	}
+	id, data, ok := strings.Cut(strings.TrimPrefix(s, cipherPrefix), ":")
+	if !ok {
+		return nil, errors.New("invalid ciphertext format")
+	}
+	b, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+	key, err := c.Keys.Key(id)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := crypt_aead(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < aead.NonceSize() {
+		return nil, errors.New("invalid ciphertext length")
+	}
+	n := aead.NonceSize()
+	plain, err := aead.Open(nil, b[:n], b[n:], []byte(id))
+	if err != nil {
+		return nil, err
+	}
+	return crypt_unmarshal(plain)
+}
+
+// Index returns the column name and value to use in equality filters.
+// the blind index column is used if defined, else the deterministic
+// encrypted value is used for column. filters are not supported for
+// random encryption without blind index.
+func (c *EncryptCodec) Index(column string, v any) (string, any, error) {
+	if c.IndexColumn != "" {
+		if len(c.IndexKey) == 0 {
+			return "", nil, errors.New("undefined blind index key")
+		}
+		plain, err := crypt_marshal(v)
+		if err != nil {
+			return "", nil, err
+		}
+		return c.IndexColumn, hex.EncodeToString(
+			crypt_hmac(c.IndexKey, plain)), nil
+	}
+	if c.Deterministic {
+		res, err := c.Encode(v)
+		return column, res, err
+	}
+	return "", nil, nil
+}
+
+////////////////////////////////////////////////////
+
+// creates AES-GCM cipher from key
+func crypt_aead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// computes HMAC-SHA256 of data
+func crypt_hmac(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// derives a 32 bytes subkey from key for info label, using HKDF-SHA256
+// without salt (RFC 5869) where one expand block is needed.
+func crypt_hkdf(key []byte, info string) []byte {
+	prk := crypt_hmac(nil, key)
+	return crypt_hmac(prk, append([]byte(info), 1))
+}
+
+// serialize plain value with leading type marker
+func crypt_marshal(v any) ([]byte, error) {
+	switch t := v.(type) {
+	case string:
+		return append([]byte{'s'}, t...), nil
+	case []byte:
+		return append([]byte{'b'}, t...), nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{'j'}, b...), nil
+}
+
+// deserialize plain value using leading type marker
+func crypt_unmarshal(b []byte) (any, error) {
+	if len(b) == 0 {
+		return nil, errors.New("invalid plain data")
+	}
+	switch b[0] {
+	case 's':
+		return string(b[1:]), nil
+	case 'b':
+		return b[1:], nil
+	case 'j':
+		var res any
+		if err := json.Unmarshal(b[1:], &res); err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+	return nil, errors.New("invalid plain data")
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testKeyRing() *KeyRing {
+	return &KeyRing{
+		Current: "k1",
+		Keys: map[string][]byte{
+			"k1": bytes.Repeat([]byte{1}, 32),
+			"k2": bytes.Repeat([]byte{2}, 16),
+		},
+	}
+}
+
+func TestEncryptCodecRoundTrip(t *testing.T) {
+	for _, deterministic := range []bool{false, true} {
+		c := &EncryptCodec{Keys: testKeyRing(), Deterministic: deterministic}
+		for _, v := range []any{
+			"secret", "", []byte{0, 1, 2},
+			map[string]any{"a": 1.0, "b": []any{"x", true}},
+		} {
+			enc, err := c.Encode(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := enc.(string); !strings.HasPrefix(s, "enc:k1:") {
+				t.Errorf("ciphertext format %q", s)
+			}
+			dec, err := c.Decode(enc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(dec, v) {
+				t.Errorf("decoded %#v, want %#v", dec, v)
+			}
+		}
+	}
+}
+
+func TestEncryptCodecDeterministic(t *testing.T) {
+	keys := testKeyRing()
+	c := &EncryptCodec{Keys: keys, Deterministic: true}
+	a, _ := c.Encode("value")
+	b, _ := c.Encode("value")
+	if a != b {
+		t.Errorf("deterministic ciphertexts differ")
+	}
+	r := &EncryptCodec{Keys: keys}
+	a, _ = r.Encode("value")
+	b, _ = r.Encode("value")
+	if a == b {
+		t.Errorf("random ciphertexts are equal")
+	}
+
+	// the nonce is derived from the nonce key, not the encryption key
+	enc, _ := c.Encode("value")
+	_, data, _ := strings.Cut(strings.TrimPrefix(enc.(string), cipherPrefix), ":")
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, _ := crypt_marshal("value")
+	key := keys.Keys["k1"]
+	if bytes.Equal(raw[:12], crypt_hmac(key, plain)[:12]) {
+		t.Errorf("nonce uses the encryption key")
+	}
+	if !bytes.Equal(raw[:12], crypt_hmac(crypt_hkdf(key, "nonce"), plain)[:12]) {
+		t.Errorf("nonce not derived from the nonce key")
+	}
+}
+
+func TestEncryptCodecRotation(t *testing.T) {
+	keys := testKeyRing()
+	c := &EncryptCodec{Keys: keys}
+	old, err := c.Encode("value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys.Current = "k2"
+	if v, err := c.Decode(old); err != nil || v != "value" {
+		t.Errorf("old key decode = %v, %v", v, err)
+	}
+
+	// tampered key id or data fail authentication
+	s := old.(string)
+	if _, err := c.Decode(strings.Replace(s, "enc:k1:", "enc:k2:", 1)); err == nil {
+		t.Errorf("decode with other key id succeeded")
+	}
+	if _, err := c.Decode(s[:len(s)-4] + "AAA="); err == nil {
+		t.Errorf("decode of tampered data succeeded")
+	}
+}
+
+func TestCryptHKDF(t *testing.T) {
+	// RFC 5869 test case 3, first 32 bytes of OKM
+	ikm := bytes.Repeat([]byte{0x0b}, 22)
+	want := "8da4e775a563c18f715f802a063c5a31b8a11f5c5ee1879ec3454e5f3c738d2d"
+	if got := hex.EncodeToString(crypt_hkdf(ikm, "")); got != want {
+		t.Errorf("crypt_hkdf() = %s, want %s", got, want)
+	}
+}
@@ -26,11 +26,30 @@ type Engine interface {
 
 	// CanRetryErr checks weather an operation error type can be retried.
 	CanRetryErr(err error) bool
+	// IsDuplicateErr checks weather an operation error is for creating
+	// an already existing database object, using the driver error codes.
+	IsDuplicateErr(err error) bool
+	// IsMissingTableErr checks weather an operation error is for accessing
+	// a not existing table, using the driver error codes.
+	IsMissingTableErr(err error) bool
+	// TransactionalDDL checks weather the table schema statments can run
+	// within a transaction and be rolled back on failure.
+	TransactionalDDL(meta *TableMeta) bool
 
 	// SqlGenerator returns the engine SQL statment generator.
 	SqlGenerator() SqlGenerator
 }
 
+// NotSupportedChecker defines the classification of operation errors
+// caused by database features not available in the backend build,
+// implemented by the backends engines supporting it. the classified
+// errors are returned as ErrNotSupported instead of ErrOperation.
+type NotSupportedChecker interface {
+	// IsNotSupportedErr checks weather an operation error is caused by a
+	// database feature not available in the backend build.
+	IsNotSupportedErr(err error) bool
+}
+
 // Database represents the database object.
 type Database struct {
 	// Log is the logger instance for database logging.
@@ -50,6 +69,9 @@ type Database struct {
 	// trials are done untill operation is done or timeout is reached.
 	// retry interval value must be > 0. (default 0.1 sec)
 	RetryInterval float64
+	// SchemaSync sets the models schema sync mode in InitializeModels,
+	// either "report" or "apply". leave empty to disable. (default "")
+	SchemaSync string
 }
 
 // NewDatabase creates a new database handler.
@@ -60,6 +82,9 @@ type Database struct {
 //   - retry_interval: (float64) the interval in seconds between operation retries.
 //     trials are done untill operation is done or timeout is reached.
 //     retry interval value must be > 0. (default 0.1 sec)
+//   - schema_sync: (string) the models schema sync mode, "report" to log the
+//     models and tables differences, or "apply" to also add the missing
+//     columns and indexes. (default disabled)
 func NewDatabase(log *logging.Logger, engine Engine, opts dictx.Dict) *Database {
 	db := &Database{
 		Log:    log,
@@ -75,6 +100,7 @@ func NewDatabase(log *logging.Logger, engine Engine, opts dictx.Dict) *Database
 	if v := dictx.GetFloat(opts, "retry_interval", 0.1); v > 0 {
 		db.RetryInterval = v
 	}
+	db.SchemaSync = dictx.GetString(opts, "schema_sync", "")
 
 	return db
 }
@@ -0,0 +1,477 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TableRebuilder defines the SQL generators altering tables schema by
+// rebuilding the tables, where the rebuild statments run in transaction
+// on a dedicated connection.
+type TableRebuilder interface {
+	// RebuildConn returns the statments run on the connection before and
+	// after the rebuild transaction, and the query run before commit that
+	// returns rows on constraints violations. it returns nil statments if
+	// the table is altered without rebuild.
+	RebuildConn(diff *SchemaDiff) (pre, post []string, check string)
+}
+
+// Schema sync modes for InitializeModels.
+const (
+	// SchemaSyncReport logs the differences between models and tables.
+	SchemaSyncReport = "report"
+	// SchemaSyncApply logs the differences and applies the additive
+	// changes, which are creating missing columns and indexes.
+	SchemaSyncApply = "apply"
+)
+
+// ColumnDrift represents a column definition mismatch.
+type ColumnDrift struct {
+	Column   string
+	Expected string
+	Actual   string
+}
+
+// SchemaDiff represents the differences between a model table metainfo
+// and the actual database table.
+type SchemaDiff struct {
+	// the table name
+	Table string
+	// the model table metainfo
+	Meta *TableMeta
+	// MissingTable is true if the table doesn't exist in database.
+	MissingTable bool
+	// MissingColumns are the columns defined in model but not in table.
+	MissingColumns []ColumnMeta
+	// ExtraColumns are the table columns not defined in model.
+	ExtraColumns []string
+	// MissingIndexes are the indexes defined in model but not in table,
+	// compared by their columns. it is set for engines supporting
+	// introspection only.
+	MissingIndexes []IndexMeta
+	// TypeDrift are the columns with different data types.
+	TypeDrift []ColumnDrift
+	// NullDrift are the columns with different nullability.
+	NullDrift []ColumnDrift
+}
+
+// IsEmpty returns true if there are no differences.
+func (d *SchemaDiff) IsEmpty() bool {
+	return !d.MissingTable && len(d.MissingColumns) == 0 &&
+		len(d.ExtraColumns) == 0 && len(d.MissingIndexes) == 0 &&
+		len(d.TypeDrift) == 0 && len(d.NullDrift) == 0
+}
+
+// String returns the differences report.
+func (d *SchemaDiff) String() string {
+	if d.MissingTable {
+		return fmt.Sprintf("table %s: missing table", d.Table)
+	}
+	lines := []string{}
+	for _, c := range d.MissingColumns {
+		lines = append(lines, "missing column "+c.Name)
+	}
+	for _, c := range d.ExtraColumns {
+		lines = append(lines, "extra column "+c)
+	}
+	for _, ix := range d.MissingIndexes {
+		lines = append(lines, fmt.Sprintf("missing index (%s)",
+			strings.Join(ix.Columns, ", ")))
+	}
+	for _, c := range d.TypeDrift {
+		lines = append(lines, fmt.Sprintf("column %s type %s, expected %s",
+			c.Column, c.Actual, c.Expected))
+	}
+	for _, c := range d.NullDrift {
+		lines = append(lines, fmt.Sprintf("column %s %s, expected %s",
+			c.Column, c.Actual, c.Expected))
+	}
+	if len(lines) == 0 {
+		return fmt.Sprintf("table %s: no changes", d.Table)
+	}
+	return fmt.Sprintf("table %s: %s", d.Table, strings.Join(lines, ", "))
+}
+
+// DiffModels compares the models tables metainfo with the actual
+// database tables and returns the tables differences. the view models
+// are not compared.
+func DiffModels(db *Database, metainfo []ModelMeta) ([]*SchemaDiff, error) {
+	if db == nil {
+		return nil, ErrDBHandler
+	}
+	dbs := db.Session()
+	result := []*SchemaDiff{}
+	for _, meta := range metainfo {
+		// views have no table schema to compare
+		if tmeta := meta.Model.TableMeta(); tmeta != nil && tmeta.View != nil {
+			continue
+		}
+		diff, err := DiffTable(dbs, meta.Table, meta.Model.TableMeta())
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, diff)
+	}
+	return result, nil
+}
+
+// DiffTable compares the table metainfo with the actual database table.
+//
+// The column types are compared by their value type class, so types
+// with the same values representation are considered equal. the table
+// columns are described using the engine introspector if supported,
+// otherwise nullability is compared only for backends drivers reporting
+// columns nullability.
+func DiffTable(dbs *Session, tablename string, meta *TableMeta) (*SchemaDiff, error) {
+	if meta == nil {
+		return nil, fmt.Errorf("%w - undefined table meta", ErrOperation)
+	}
+	diff := &SchemaDiff{Table: tablename, Meta: meta}
+
+	live, err := live_columns(dbs, tablename)
+	if err != nil {
+		if errors.Is(err, ErrNoTable) ||
+			dbs.db.engine.IsMissingTableErr(err) {
+			diff.MissingTable = true
+			return diff, nil
+		}
+		return nil, err
+	}
+	g := dbs.db.engine.SqlGenerator()
+
+	columns := model_columns(meta)
+	for _, c := range columns {
+		found := false
+		for _, t := range live {
+			if !strings.EqualFold(t.name, c.Name) {
+				continue
+			}
+			found = true
+
+			expected := g.ColumnType(&c)
+			ek := type_family(column_value_kind(&c))
+			ak := type_family(sql_value_kind(t.sqltype))
+			if ek != valueAny && ak != valueAny && ek != ak {
+				diff.TypeDrift = append(diff.TypeDrift, ColumnDrift{
+					Column: c.Name, Expected: expected, Actual: t.sqltype})
+			}
+
+			if t.known_null {
+				notnull := c.Primary ||
+					strings.Contains(strings.ToUpper(expected), "NOT NULL")
+				if t.nullable == notnull {
+					diff.NullDrift = append(diff.NullDrift, ColumnDrift{
+						Column:   c.Name,
+						Expected: null_label(!notnull),
+						Actual:   null_label(t.nullable),
+					})
+				}
+			}
+			break
+		}
+		if !found {
+			diff.MissingColumns = append(diff.MissingColumns, c)
+		}
+	}
+	for _, t := range live {
+		found := false
+		for _, c := range columns {
+			if strings.EqualFold(t.name, c.Name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diff.ExtraColumns = append(diff.ExtraColumns, t.name)
+		}
+	}
+
+	if i, ok := dbs.db.engine.(Introspector); ok {
+		indexes, err := i.ListIndexes(dbs, tablename)
+		if err != nil {
+			return nil, err
+		}
+		for _, ix := range model_indexes(meta) {
+			found := false
+			for _, t := range indexes {
+				if same_columns(ix.Columns, t.Columns) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				diff.MissingIndexes = append(diff.MissingIndexes, ix)
+			}
+		}
+	}
+
+	return diff, nil
+}
+
+// ApplyDiff applies the additive changes of tables differences, which
+// are creating the missing tables, columns and indexes. the type and
+// nullability drifts are not changed. the tables rebuilt by generators
+// implementing TableRebuilder are altered on a dedicated connection.
+func ApplyDiff(db *Database, diffs []*SchemaDiff) error {
+	if db == nil {
+		return ErrDBHandler
+	}
+	if err := db.check_run(); err != nil {
+		return err
+	}
+	g := db.engine.SqlGenerator()
+
+	for _, diff := range diffs {
+		if !diff.MissingTable && len(diff.MissingColumns) == 0 &&
+			len(diff.MissingIndexes) == 0 {
+			continue
+		}
+
+		stmts := []string{}
+		if !diff.MissingTable {
+			alter, err := g.AlterSchema(diff)
+			if err != nil {
+				return fmt.Errorf("%w - table %s, %v",
+					ErrOperation, diff.Table, err)
+			}
+			stmts = append(stmts, alter...)
+		}
+		// the schema statments create the missing table or indexes
+		schema := g.Schema(diff.Table, diff.Meta)
+		if diff.MissingTable {
+			stmts = append(stmts, schema...)
+		} else {
+			stmts = append(stmts, schema[1:]...)
+		}
+
+		if db.Log != nil {
+			db.Log.Debug("applying schema changes, %s", diff)
+		}
+		if r, ok := g.(TableRebuilder); ok && !diff.MissingTable {
+			pre, post, check := r.RebuildConn(diff)
+			if len(pre) > 0 || len(post) > 0 || check != "" {
+				if err := rebuild_table(db, diff.Table, stmts,
+					pre, post, check); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		dbs := db.Session()
+		if err := dbs.Begin(); err != nil {
+			return err
+		}
+		for _, stmt := range stmts {
+			if _, err := dbs.Exec(stmt); err != nil && !db.engine.IsDuplicateErr(err) {
+				dbs.RollBack()
+				return err
+			}
+		}
+		if err := dbs.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runs the table rebuild statments in transaction on a dedicated
+// connection, running the pre and post statments on the connection
+// outside the transaction, and the check query before commit.
+func rebuild_table(db *Database, tablename string, stmts, pre, post []string,
+	check string) (err error) {
+	dbs := db.Session()
+	sdb, writer, err := dbs.acquire(db.ctx)
+	if err != nil {
+		return err
+	}
+	defer dbs.release(sdb, writer)
+	conn, err := sdb.Conn(db.ctx)
+	if err != nil {
+		return fmt.Errorf("%w - %v", ErrOpen, err)
+	}
+	defer conn.Close()
+
+	for _, stmt := range pre {
+		if db.Log != nil {
+			db.Log.Trace("SQL: %s", stmt)
+		}
+		if _, err := conn.ExecContext(db.ctx, stmt); err != nil {
+			return fmt.Errorf("%w - %w", ErrOperation, err)
+		}
+	}
+	// the post statments run on all exit paths
+	defer func() {
+		for _, stmt := range post {
+			if db.Log != nil {
+				db.Log.Trace("SQL: %s", stmt)
+			}
+			if _, perr := conn.ExecContext(db.ctx, stmt); perr != nil && err == nil {
+				err = fmt.Errorf("%w - %w", ErrOperation, perr)
+			}
+		}
+	}()
+
+	tx, err := conn.BeginTx(db.ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w - %w", ErrOperation, err)
+	}
+	for _, stmt := range stmts {
+		if db.Log != nil {
+			db.Log.Trace("SQL: %s", stmt)
+		}
+		if _, err := tx.ExecContext(db.ctx, stmt); err != nil &&
+			!db.engine.IsDuplicateErr(err) {
+			tx.Rollback()
+			return fmt.Errorf("%w - %w", ErrOperation, err)
+		}
+	}
+	if check != "" {
+		rows, err := tx.QueryContext(db.ctx, check)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%w - %w", ErrOperation, err)
+		}
+		violated := rows.Next()
+		rows.Close()
+		if violated {
+			tx.Rollback()
+			return fmt.Errorf("%w - table %s rebuild violates constraints",
+				ErrOperation, tablename)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w - %w", ErrOperation, err)
+	}
+	return nil
+}
+
+// GenerateAlterSchema generates the standard statments adding the missing
+// columns of table difference, using the columns types definitions of
+// generator g. it is used by the backends generators extending the
+// standard alter schema.
+func GenerateAlterSchema(g SqlGenerator, diff *SchemaDiff) ([]string, error) {
+	stmts := []string{}
+	for _, c := range diff.MissingColumns {
+		if c.Primary {
+			return nil, fmt.Errorf(
+				"can not add primary column '%s' to existing table", c.Name)
+		}
+		typedef := g.ColumnType(&c)
+		if c.Unique {
+			typedef += " UNIQUE"
+		}
+		stmts = append(stmts, fmt.Sprintf(
+			"ALTER TABLE %s ADD COLUMN %s %s;", diff.Table, c.Name, typedef))
+	}
+	return stmts, nil
+}
+
+////////////////////////////////////////////////////
+
+// returns the table columns including the AutoGuid column
+func model_columns(meta *TableMeta) []ColumnMeta {
+	if meta.AutoGuid &&
+		(len(meta.Columns) == 0 || meta.Columns[0].Name != "guid") {
+		return append([]ColumnMeta{
+			{Name: "guid", Type: "VARCHAR(32) NOT NULL", Primary: true},
+		}, meta.Columns...)
+	}
+	return meta.Columns
+}
+
+// returns the table indexes defined in metainfo, excluding the primary
+// columns indexes as primary keys are always indexed.
+func model_indexes(meta *TableMeta) []IndexMeta {
+	list := []IndexMeta{}
+	for _, c := range meta.Columns {
+		if c.Index && !c.Primary {
+			list = append(list, IndexMeta{Columns: []string{c.Name}})
+		}
+	}
+	return append(list, meta.Indexes...)
+}
+
+// checks whether the indexes columns are the same, ignoring the columns
+// sort order.
+func same_columns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		fa, fb := strings.Fields(a[i]), strings.Fields(b[i])
+		if len(fa) == 0 || len(fb) == 0 || !strings.EqualFold(fa[0], fb[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// returns the comparable value type class, where boolean and integer
+// types are considered equal as booleans are stored as integers on many
+// backends, and uuid types are considered strings.
+func type_family(kind valueKind) valueKind {
+	switch kind {
+	case valueBool:
+		return valueInt
+	case valueUUID:
+		return valueString
+	}
+	return kind
+}
+
+// database table column definition
+type live_column struct {
+	name       string
+	sqltype    string
+	nullable   bool
+	known_null bool
+}
+
+// returns the database table columns definitions
+func live_columns(dbs *Session, tablename string) ([]live_column, error) {
+	result := []live_column{}
+
+	if i, ok := dbs.db.engine.(Introspector); ok {
+		meta, err := i.DescribeTable(dbs, tablename)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range meta.Columns {
+			result = append(result, live_column{
+				name:    c.Name,
+				sqltype: c.Type,
+				nullable: !c.Primary &&
+					!strings.Contains(strings.ToUpper(c.Type), "NOT NULL"),
+				known_null: true,
+			})
+		}
+		return result, nil
+	}
+
+	types, err := dbs.column_types(tablename)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range types {
+		nullable, ok := t.Nullable()
+		result = append(result, live_column{
+			name:       t.Name(),
+			sqltype:    t.DatabaseTypeName(),
+			nullable:   nullable,
+			known_null: ok,
+		})
+	}
+	return result, nil
+}
+
+func null_label(nullable bool) string {
+	if nullable {
+		return "NULL"
+	}
+	return "NOT NULL"
+}
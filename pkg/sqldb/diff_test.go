@@ -0,0 +1,57 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb_test
+
+import (
+	"testing"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+func TestDiffMissingIndexes(t *testing.T) {
+	db := testDatabase(t)
+	dbs := db.Session()
+	if _, err := dbs.Exec(
+		"CREATE TABLE t (id INTEGER PRIMARY KEY, a TEXT, b TEXT);"); err != nil {
+		t.Fatal(err)
+	}
+	meta := &sqldb.TableMeta{
+		Columns: []sqldb.ColumnMeta{
+			{Name: "id", Type: "INTEGER", Primary: true},
+			{Name: "a", Type: "TEXT", Index: true},
+			{Name: "b", Type: "TEXT"},
+		},
+		Indexes: []sqldb.IndexMeta{{Columns: []string{"a", "b DESC"}}},
+	}
+	diff, err := sqldb.DiffTable(dbs, "t", meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.MissingIndexes) != 2 {
+		t.Fatalf("missing indexes = %v, want 2", diff.MissingIndexes)
+	}
+	if err := sqldb.ApplyDiff(db, []*sqldb.SchemaDiff{diff}); err != nil {
+		t.Fatal(err)
+	}
+	if diff, err = sqldb.DiffTable(dbs, "t", meta); err != nil {
+		t.Fatal(err)
+	} else if len(diff.MissingIndexes) != 0 {
+		t.Errorf("missing indexes after apply = %v", diff.MissingIndexes)
+	}
+}
+
+func TestDiffMissingTable(t *testing.T) {
+	db := testDatabase(t)
+	meta := &sqldb.TableMeta{
+		Columns: []sqldb.ColumnMeta{{Name: "id", Type: "INTEGER", Primary: true}},
+	}
+	diff, err := sqldb.DiffTable(db.Session(), "missing", meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !diff.MissingTable {
+		t.Errorf("missing table not reported")
+	}
+}
@@ -0,0 +1,437 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+// Package dump exports and imports models tables data as JSON Lines or
+// CSV files, used for backups and data sharing across environments.
+//
+// Each table is dumped into a separate file starting with a header that
+// describes the columns value types, followed by the table rows:
+//
+//	{"table":"users","columns":[{"name":"guid","type":"string"},...]}
+//	{"guid":"0f1e...","name":"alice","created":"2024-01-01T10:00:00Z"}
+//
+// the CSV files header row holds the columns as "name:type" and the null
+// values are written as \N. the columns value types are:
+// string, int, float, decimal, bool, time, date, bytes, uuid, json,
+// encrypted and any, where time values are formatted as RFC3339, bytes
+// are base64 encoded and json and any values are JSON encoded in CSV
+// files.
+//
+// rows are read and written using the models queries, so the models
+// DataDecode and DataEncode and the columns codecs are applied. the
+// encrypted columns values are exported as stored in database, and can
+// be imported only into databases using the same encryption keys. the
+// Plaintext option exports them decrypted and encrypts them again on
+// import, where the dump files must be protected as the database itself.
+package dump
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+// Format represents the dump files format.
+type Format string
+
+const (
+	// JSONL writes the rows as JSON objects, one object per line.
+	JSONL Format = "jsonl"
+	// CSV writes the rows as comma separated values.
+	CSV Format = "csv"
+)
+
+// CSV null value
+const csvNull = `\N`
+
+// date values format
+const dateLayout = "2006-01-02"
+
+// Options represents the export and import options.
+type Options struct {
+	// Format is the dump files format, defaults to JSONL.
+	Format Format
+	// BatchSize is the number of rows fetched or inserted per batch,
+	// defaults to 1000.
+	BatchSize int
+	// Progress is called after each batch with the table name and the
+	// total number of processed table rows.
+	Progress func(table string, count int)
+	// Plaintext exports the encrypted columns values decrypted and
+	// encrypts them on import. by default the values are exported and
+	// imported as stored in database.
+	Plaintext bool
+}
+
+// returns the options with default values
+func options(opts *Options) (*Options, error) {
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Format == "" {
+		o.Format = JSONL
+	} else if o.Format != JSONL && o.Format != CSV {
+		return nil, fmt.Errorf("%w - invalid format '%s'", ErrDump, o.Format)
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 1000
+	}
+	return &o, nil
+}
+
+// Header represents the dump file header, describing the table columns.
+type Header struct {
+	Table   string   `json:"table"`
+	Columns []Column `json:"columns"`
+}
+
+// Column represents the dump column name and values type.
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// NewHeader creates the dump header of table metainfo.
+func NewHeader(table string, meta *sqldb.TableMeta) *Header {
+	h := &Header{Table: table}
+	if meta.AutoGuid &&
+		(len(meta.Columns) == 0 || meta.Columns[0].Name != "guid") {
+		h.Columns = append(h.Columns, Column{Name: "guid", Type: "string"})
+	}
+	for _, c := range meta.Columns {
+		h.Columns = append(h.Columns, Column{
+			Name: c.Name, Type: column_type(c)})
+	}
+	return h
+}
+
+// returns the dump header of table metainfo, where the encrypted columns
+// are marked if their values are dumped as stored in database.
+func dump_header(table string, meta *sqldb.TableMeta, o *Options) *Header {
+	h := NewHeader(table, meta)
+	if o.Plaintext {
+		return h
+	}
+	for i, col := range h.Columns {
+		for _, c := range meta.Columns {
+			if _, ok := c.Codec.(*sqldb.EncryptCodec); ok && c.Name == col.Name {
+				h.Columns[i].Type = "encrypted"
+			}
+		}
+	}
+	return h
+}
+
+// model with the encrypted columns values used as stored in database
+type raw_model struct {
+	sqldb.Model
+	meta *sqldb.TableMeta
+}
+
+func (m *raw_model) TableMeta() *sqldb.TableMeta {
+	return m.meta
+}
+
+// returns the model used for dump queries, where the encrypted columns
+// codecs are removed unless the Plaintext option is set.
+func dump_model(model sqldb.Model, o *Options) sqldb.Model {
+	if o.Plaintext {
+		return model
+	}
+	meta := model.TableMeta()
+	var raw *sqldb.TableMeta
+	for i, c := range meta.Columns {
+		if _, ok := c.Codec.(*sqldb.EncryptCodec); !ok {
+			continue
+		}
+		if raw == nil {
+			m := *meta
+			m.Columns = slices.Clone(meta.Columns)
+			raw = &m
+		}
+		raw.Columns[i].Codec = nil
+	}
+	if raw == nil {
+		return model
+	}
+	return &raw_model{Model: model, meta: raw}
+}
+
+// returns the dump values type of column
+func column_type(c sqldb.ColumnMeta) string {
+	if c.Codec != nil {
+		return "any"
+	}
+	if c.Kind == sqldb.KindRaw {
+		c = sqldb.PortableColumn(c)
+	}
+	switch c.Kind {
+	case sqldb.KindJSON:
+		return "json"
+	case sqldb.KindString, sqldb.KindText:
+		return "string"
+	case sqldb.KindInt, sqldb.KindBigInt:
+		return "int"
+	case sqldb.KindBool:
+		return "bool"
+	case sqldb.KindFloat:
+		return "float"
+	case sqldb.KindDecimal:
+		return "decimal"
+	case sqldb.KindTime:
+		return "time"
+	case sqldb.KindDate:
+		return "date"
+	case sqldb.KindBlob:
+		return "bytes"
+	case sqldb.KindUUID:
+		return "uuid"
+	}
+	return "any"
+}
+
+////////////////////////////////////////////////////
+
+// Export writes the rows of models tables into files in dir, one file per
+// table named <table>.jsonl or <table>.csv. view models are skipped.
+// Export should run in a session transaction to dump a consistent
+// snapshot of tables.
+func Export(dbs *sqldb.Session, metainfo []sqldb.ModelMeta,
+	dir string, opts *Options) error {
+	o, err := options(opts)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("%w - %v", ErrDump, err)
+	}
+	for _, m := range sqldb.OrderModels(metainfo) {
+		if m.Model.TableMeta().View != nil {
+			continue
+		}
+		f, err := os.Create(filepath.Join(dir, m.Table+"."+string(o.Format)))
+		if err != nil {
+			return fmt.Errorf("%w - %v", ErrDump, err)
+		}
+		_, err = ExportTable(dbs, m.Table, m.Model, f, o)
+		if cerr := f.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("%w - %v", ErrDump, cerr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportTable writes the model table rows to writer in batches and
+// returns the number of written rows. the rows batches are fetched
+// ordered by the table primary column and following the last fetched
+// key, or by offset ordered by all columns if no primary column is
+// defined or its values are encoded by codec.
+func ExportTable(dbs *sqldb.Session, table string, model sqldb.Model,
+	w io.Writer, opts *Options) (int, error) {
+	o, err := options(opts)
+	if err != nil {
+		return 0, err
+	}
+	header := dump_header(table, model.TableMeta(), o)
+	model = dump_model(model, o)
+	names, orders := []string{}, []string{}
+	for _, c := range header.Columns {
+		names = append(names, c.Name)
+	}
+	key := paging_key(model.TableMeta())
+	if key != "" {
+		orders = append(orders, key+" ASC")
+	} else {
+		for _, name := range names {
+			orders = append(orders, name+" ASC")
+		}
+	}
+
+	var wr row_writer
+	if o.Format == CSV {
+		wr = &csv_writer{w: csv.NewWriter(w)}
+	} else {
+		wr = &jsonl_writer{w: bufio.NewWriter(w)}
+	}
+	if err := wr.header(header); err != nil {
+		return 0, fmt.Errorf("%w - %v", ErrDump, err)
+	}
+
+	var last any
+	count := 0
+	for {
+		q := dbs.Query(model).TableName(table).Columns(names...).
+			OrderBy(orders...).Limit(o.BatchSize)
+		if key == "" {
+			q.Offset(count)
+		} else if count > 0 {
+			q.Filters(key+">"+sqldb.SQL_PLACEHOLDER, last)
+		}
+		rows, err := q.All()
+		if err != nil {
+			return count, err
+		}
+		if key != "" && len(rows) > 0 {
+			last = rows[len(rows)-1][key]
+		}
+		for _, row := range rows {
+			if err := wr.row(header, row); err != nil {
+				return count, fmt.Errorf("%w - table %s, %v",
+					ErrDump, table, err)
+			}
+		}
+		count += len(rows)
+		if err := wr.flush(); err != nil {
+			return count, fmt.Errorf("%w - %v", ErrDump, err)
+		}
+		if o.Progress != nil && len(rows) > 0 {
+			o.Progress(table, count)
+		}
+		if len(rows) < o.BatchSize {
+			break
+		}
+	}
+	return count, nil
+}
+
+// returns the table primary column used for paging the rows by key, or
+// empty if not defined or its values are encoded by codec.
+func paging_key(meta *sqldb.TableMeta) string {
+	key := sqldb.PrimaryColumn(meta)
+	for _, c := range meta.Columns {
+		if c.Name == key && c.Codec != nil {
+			return ""
+		}
+	}
+	return key
+}
+
+// dump rows writer
+type row_writer interface {
+	header(h *Header) error
+	row(h *Header, data sqldb.Data) error
+	flush() error
+}
+
+// JSON Lines rows writer
+type jsonl_writer struct {
+	w *bufio.Writer
+}
+
+func (j *jsonl_writer) header(h *Header) error {
+	return j.write(h)
+}
+
+func (j *jsonl_writer) row(h *Header, data sqldb.Data) error {
+	row := map[string]any{}
+	for _, c := range h.Columns {
+		row[c.Name] = json_value(c.Type, data[c.Name])
+	}
+	return j.write(row)
+}
+
+func (j *jsonl_writer) write(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	j.w.Write(b)
+	return j.w.WriteByte('\n')
+}
+
+func (j *jsonl_writer) flush() error {
+	return j.w.Flush()
+}
+
+// CSV rows writer
+type csv_writer struct {
+	w *csv.Writer
+}
+
+func (c *csv_writer) header(h *Header) error {
+	record := []string{}
+	for _, col := range h.Columns {
+		record = append(record, col.Name+":"+col.Type)
+	}
+	return c.w.Write(record)
+}
+
+func (c *csv_writer) row(h *Header, data sqldb.Data) error {
+	record := []string{}
+	for _, col := range h.Columns {
+		v, err := csv_value(col.Type, data[col.Name])
+		if err != nil {
+			return fmt.Errorf("column %s, %v", col.Name, err)
+		}
+		record = append(record, v)
+	}
+	return c.w.Write(record)
+}
+
+func (c *csv_writer) flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+////////////////////////////////////////////////////
+
+// returns the value in JSON Lines format of values type
+func json_value(typ string, v any) any {
+	switch t := v.(type) {
+	case time.Time:
+		if typ == "date" {
+			return t.Format(dateLayout)
+		}
+		return t.Format(time.RFC3339Nano)
+	case []byte:
+		if typ == "json" && json.Valid(t) {
+			return json.RawMessage(t)
+		} else if typ == "encrypted" {
+			return string(t)
+		}
+		return base64.StdEncoding.EncodeToString(t)
+	}
+	return v
+}
+
+// returns the value in CSV format of values type
+func csv_value(typ string, v any) (string, error) {
+	switch t := v.(type) {
+	case nil:
+		return csvNull, nil
+	case string:
+		if typ != "json" && typ != "any" {
+			return t, nil
+		}
+	case bool:
+		return strconv.FormatBool(t), nil
+	case int64:
+		return strconv.FormatInt(t, 10), nil
+	case float64:
+		return strconv.FormatFloat(t, 'g', -1, 64), nil
+	}
+	v = json_value(typ, v)
+	if s, ok := v.(string); ok && typ != "json" && typ != "any" {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
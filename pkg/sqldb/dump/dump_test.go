@@ -0,0 +1,170 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package dump
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/exonlabs/go-utils/pkg/abc/dictx"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+	sqlitedb "github.com/exonlabs/go-sqldb/pkg/sqlite_modernc"
+)
+
+type testModel struct {
+	sqldb.BaseModel
+}
+
+func (m *testModel) TableMeta() *sqldb.TableMeta {
+	return &sqldb.TableMeta{
+		Columns: []sqldb.ColumnMeta{
+			{Name: "id", Kind: sqldb.KindInt, Primary: true},
+			{Name: "name", Kind: sqldb.KindText},
+		},
+	}
+}
+
+func testSession(t *testing.T) *sqldb.Session {
+	t.Helper()
+	opts := dictx.Dict{
+		"database": filepath.Join(t.TempDir(), "test.db"),
+	}
+	engine, err := sqlitedb.NewEngine(nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { engine.Close(nil) })
+	db := sqldb.NewDatabase(nil, engine, opts)
+	err = sqldb.InitializeModels(db, []sqldb.ModelMeta{
+		{Table: "t", Model: &testModel{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db.Session()
+}
+
+func TestExportImport(t *testing.T) {
+	dbs := testSession(t)
+	if _, err := dbs.Exec("INSERT INTO t (id, name) VALUES " +
+		"(5, 'e'), (1, 'a'), (4, 'd'), (2, 'b'), (3, 'c');"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the rows are paged by key in batches
+	var buff bytes.Buffer
+	opts := &Options{BatchSize: 2}
+	n, err := ExportTable(dbs, "t", &testModel{}, &buff, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buff.String()), "\n")
+	if n != 5 || len(lines) != 6 {
+		t.Fatalf("exported %d rows, %d lines", n, len(lines))
+	}
+	for i, name := range []string{"a", "b", "c", "d", "e"} {
+		if !strings.Contains(lines[i+1], `"name":"`+name+`"`) {
+			t.Errorf("line %d = %s, want name %s", i+1, lines[i+1], name)
+		}
+	}
+
+	// the import runs within the session transaction
+	data := buff.String()
+	if _, err := dbs.Exec("DELETE FROM t;"); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbs.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	n, err = ImportTable(dbs, "t", &testModel{}, strings.NewReader(data), opts)
+	if err != nil || n != 5 {
+		t.Fatalf("imported %d rows, %v", n, err)
+	}
+	if err := dbs.RollBack(); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := dbs.Query(&testModel{}).TableName("t").Count(); err != nil {
+		t.Fatal(err)
+	} else if n != 0 {
+		t.Errorf("rows after rollback = %d, want 0", n)
+	}
+}
+
+type secretModel struct {
+	sqldb.BaseModel
+	keys sqldb.KeyProvider
+}
+
+func (m *secretModel) TableMeta() *sqldb.TableMeta {
+	return &sqldb.TableMeta{
+		Columns: []sqldb.ColumnMeta{
+			{Name: "id", Kind: sqldb.KindInt, Primary: true},
+			{Name: "secret", Kind: sqldb.KindText,
+				Codec: sqldb.NewEncryptCodec(m.keys)},
+		},
+	}
+}
+
+func TestExportEncrypted(t *testing.T) {
+	dbs := testSession(t)
+	model := &secretModel{keys: &sqldb.KeyRing{Current: "k1",
+		Keys: map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)}}}
+	if err := sqldb.InitializeModels(dbs.Database(), []sqldb.ModelMeta{
+		{Table: "s", Model: model}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.Query(model).TableName("s").Insert(
+		sqldb.Data{"id": 1, "secret": "plain text"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []Format{JSONL, CSV} {
+		// the encrypted values are exported as stored by default
+		var buff bytes.Buffer
+		opts := &Options{Format: format}
+		if _, err := ExportTable(dbs, "s", model, &buff, opts); err != nil {
+			t.Fatal(err)
+		}
+		raw := buff.String()
+		if strings.Contains(raw, "plain text") ||
+			!strings.Contains(raw, "encrypted") {
+			t.Errorf("%s raw export:\n%s", format, raw)
+		}
+
+		// plaintext dumps are not imported as encrypted values
+		buff.Reset()
+		plain := &Options{Format: format, Plaintext: true}
+		if _, err := ExportTable(dbs, "s", model, &buff, plain); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buff.String(), "plain text") {
+			t.Errorf("%s plaintext export:\n%s", format, buff.String())
+		}
+		if _, err := ImportTable(dbs, "s", model,
+			strings.NewReader(buff.String()), opts); !errors.Is(err, ErrImport) {
+			t.Errorf("%s mode mismatch error = %v", format, err)
+		}
+
+		// the raw values are imported as is and decrypted by queries
+		if _, err := dbs.Exec("DELETE FROM s;"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ImportTable(dbs, "s", model,
+			strings.NewReader(raw), opts); err != nil {
+			t.Fatal(err)
+		}
+		row, err := dbs.Query(model).TableName("s").First()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if row["secret"] != "plain text" {
+			t.Errorf("%s imported secret = %v", format, row["secret"])
+		}
+	}
+}
@@ -0,0 +1,18 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package dump
+
+import (
+	"fmt"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+var (
+	// ErrDump indicates a dump file format or writing error.
+	ErrDump = fmt.Errorf("%wdump error", sqldb.ErrError)
+	// ErrImport indicates a dump file reading or import error.
+	ErrImport = fmt.Errorf("%wimport error", sqldb.ErrError)
+)
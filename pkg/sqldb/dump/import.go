@@ -0,0 +1,305 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package dump
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+// Import inserts the rows from the tables files in dir into the models
+// tables, in dependency order where the tables referenced by foreign
+// keys are imported first. the tables without files in dir are skipped.
+func Import(dbs *sqldb.Session, metainfo []sqldb.ModelMeta,
+	dir string, opts *Options) error {
+	o, err := options(opts)
+	if err != nil {
+		return err
+	}
+	for _, m := range sqldb.OrderModels(metainfo) {
+		if m.Model.TableMeta().View != nil {
+			continue
+		}
+		f, err := os.Open(filepath.Join(dir, m.Table+"."+string(o.Format)))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("%w - %v", ErrImport, err)
+		}
+		_, err = ImportTable(dbs, m.Table, m.Model, f, o)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportTable inserts the rows read from reader into the model table
+// and returns the number of inserted rows. the rows are inserted using
+// Query.InsertMany in batches, each batch in a separate transaction or
+// within the session transaction if already started. the dump encrypted
+// columns values must match the Plaintext option.
+func ImportTable(dbs *sqldb.Session, table string, model sqldb.Model,
+	r io.Reader, opts *Options) (int, error) {
+	o, err := options(opts)
+	if err != nil {
+		return 0, err
+	}
+
+	var rd row_reader
+	if o.Format == CSV {
+		rd = &csv_reader{r: csv.NewReader(r)}
+	} else {
+		rd = &jsonl_reader{r: bufio.NewReader(r)}
+	}
+	header, err := rd.header()
+	if err != nil {
+		return 0, fmt.Errorf("%w - table %s header, %v", ErrImport, table, err)
+	}
+	columns := map[string]string{}
+	for _, c := range dump_header(table, model.TableMeta(), o).Columns {
+		columns[c.Name] = c.Type
+	}
+	for _, c := range header.Columns {
+		typ, ok := columns[c.Name]
+		if !ok {
+			return 0, fmt.Errorf("%w - table %s has no column %s",
+				ErrImport, table, c.Name)
+		}
+		if (typ == "encrypted") != (c.Type == "encrypted") {
+			return 0, fmt.Errorf(
+				"%w - table %s column %s encrypted values mode mismatch",
+				ErrImport, table, c.Name)
+		}
+	}
+	model = dump_model(model, o)
+
+	count, line := 0, 1
+	for {
+		batch := []sqldb.Data{}
+		for len(batch) < o.BatchSize {
+			line++
+			data, err := rd.row(header)
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return count, fmt.Errorf("%w - table %s line %d, %v",
+					ErrImport, table, line, err)
+			}
+			batch = append(batch, data)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		if _, err := dbs.Query(model).TableName(table).
+			InsertMany(batch); err != nil {
+			return count, err
+		}
+		count += len(batch)
+		if o.Progress != nil {
+			o.Progress(table, count)
+		}
+		if len(batch) < o.BatchSize {
+			break
+		}
+	}
+	return count, nil
+}
+
+// dump rows reader
+type row_reader interface {
+	header() (*Header, error)
+	row(h *Header) (sqldb.Data, error)
+}
+
+// JSON Lines rows reader
+type jsonl_reader struct {
+	r *bufio.Reader
+}
+
+func (j *jsonl_reader) header() (*Header, error) {
+	h := &Header{}
+	if err := j.read(h); err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if len(h.Columns) == 0 {
+		return nil, errors.New("no columns defined")
+	}
+	return h, nil
+}
+
+func (j *jsonl_reader) row(h *Header) (sqldb.Data, error) {
+	row := map[string]any{}
+	if err := j.read(&row); err != nil {
+		return nil, err
+	}
+	data := sqldb.Data{}
+	for _, c := range h.Columns {
+		v, ok := row[c.Name]
+		if !ok {
+			continue
+		}
+		v, err := parse_value(c.Type, v)
+		if err != nil {
+			return nil, fmt.Errorf("column %s, %v", c.Name, err)
+		}
+		data[c.Name] = v
+	}
+	return data, nil
+}
+
+// reads the next non-empty line JSON value
+func (j *jsonl_reader) read(v any) error {
+	for {
+		line, err := j.r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			dec := json.NewDecoder(bytes.NewReader(line))
+			dec.UseNumber()
+			return dec.Decode(v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// CSV rows reader
+type csv_reader struct {
+	r *csv.Reader
+}
+
+func (c *csv_reader) header() (*Header, error) {
+	record, err := c.r.Read()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
+	}
+	h := &Header{}
+	for _, field := range record {
+		name, typ, ok := strings.Cut(field, ":")
+		if !ok {
+			typ = "any"
+		}
+		h.Columns = append(h.Columns, Column{Name: name, Type: typ})
+	}
+	return h, nil
+}
+
+func (c *csv_reader) row(h *Header) (sqldb.Data, error) {
+	record, err := c.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	data := sqldb.Data{}
+	for i, col := range h.Columns {
+		if record[i] == csvNull {
+			data[col.Name] = nil
+			continue
+		}
+		var v any = record[i]
+		if col.Type == "json" || col.Type == "any" {
+			dec := json.NewDecoder(strings.NewReader(record[i]))
+			dec.UseNumber()
+			if err := dec.Decode(&v); err != nil {
+				return nil, fmt.Errorf("column %s, %v", col.Name, err)
+			}
+		}
+		if v, err = parse_value(col.Type, v); err != nil {
+			return nil, fmt.Errorf("column %s, %v", col.Name, err)
+		}
+		data[col.Name] = v
+	}
+	return data, nil
+}
+
+////////////////////////////////////////////////////
+
+// converts the decoded JSON or CSV value into the Go type of values type
+func parse_value(typ string, v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	s, isstr := v.(string)
+	switch typ {
+	case "string", "uuid", "decimal", "encrypted":
+		if n, ok := v.(json.Number); ok {
+			return n.String(), nil
+		} else if isstr {
+			return s, nil
+		}
+	case "int":
+		if n, ok := v.(json.Number); ok {
+			return n.Int64()
+		} else if isstr {
+			return strconv.ParseInt(s, 10, 64)
+		}
+	case "float":
+		if n, ok := v.(json.Number); ok {
+			return n.Float64()
+		} else if isstr {
+			return strconv.ParseFloat(s, 64)
+		}
+	case "bool":
+		if b, ok := v.(bool); ok {
+			return b, nil
+		} else if isstr {
+			return strconv.ParseBool(s)
+		}
+	case "time":
+		if isstr {
+			return time.Parse(time.RFC3339Nano, s)
+		}
+	case "date":
+		if isstr {
+			return time.Parse(dateLayout, s)
+		}
+	case "bytes":
+		if isstr {
+			return base64.StdEncoding.DecodeString(s)
+		}
+	default:
+		return json_number(v), nil
+	}
+	return nil, fmt.Errorf("invalid %s value %v", typ, v)
+}
+
+// converts the JSON numbers into int64 or float64 values
+func json_number(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return n
+		}
+		n, _ := t.Float64()
+		return n
+	case map[string]any:
+		for k, e := range t {
+			t[k] = json_number(e)
+		}
+	case []any:
+		for i, e := range t {
+			t[i] = json_number(e)
+		}
+	}
+	return v
+}
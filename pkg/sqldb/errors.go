@@ -39,4 +39,14 @@ var (
 	ErrTimeout = fmt.Errorf("%woperation timeout", ErrError)
 	// ErrOperation indicates a database operation error.
 	ErrOperation = fmt.Errorf("%woperation error", ErrError)
+	// ErrNotSupported indicates an operation not supported by backend.
+	ErrNotSupported = fmt.Errorf("%wnot supported", ErrError)
+	// ErrNoTable indicates that the table doesn't exist in database.
+	ErrNoTable = fmt.Errorf("%wtable not found", ErrError)
+	// ErrValue indicates an invalid or not convertible column value.
+	ErrValue = fmt.Errorf("%winvalid column value", ErrError)
+	// ErrRawExec indicates that raw statments execution is not allowed.
+	ErrRawExec = fmt.Errorf("%wraw exec not allowed", ErrError)
+	// ErrReadOnly indicates a write operation on read-only view model.
+	ErrReadOnly = fmt.Errorf("%wread-only view model", ErrError)
 )
@@ -0,0 +1,18 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package fixtures
+
+import (
+	"fmt"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+var (
+	// ErrFixture indicates a fixtures definition or loading error.
+	ErrFixture = fmt.Errorf("%wfixture error", sqldb.ErrError)
+	// ErrReference indicates an unresolved fixture reference.
+	ErrReference = fmt.Errorf("%wunresolved fixture reference", sqldb.ErrError)
+)
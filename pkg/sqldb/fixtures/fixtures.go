@@ -0,0 +1,401 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+// Package fixtures loads seed data from JSON or YAML files into models.
+//
+// The fixtures files are keyed by table name, where each table holds its
+// rows keyed by symbolic labels, or a list of rows without labels:
+//
+//	roles:
+//	  admin:
+//	    name: Administrator
+//	users:
+//	  alice:
+//	    name: Alice
+//	    role_id: $roles.admin
+//
+// rows of tables with guid column get a stable guid generated from their
+// table and label, unless set explicitly, and the "$<table>.<label>"
+// string values are references resolved to the referenced rows guids.
+// the referenced tables must have guid key, else ErrReference is returned.
+// string values starting with "$$" are written with a single "$".
+//
+// rows are upserted by the table key, the guid or the primary columns,
+// so loading the same fixtures again updates the existing rows, and rows
+// without labels must set their key columns. the rows are written using
+// the models queries, so the models DataEncode and the columns codecs
+// are applied.
+package fixtures
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/yaml.v3"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+// fixture reference value: $<table>.<label>
+var referenceRegex = regexp.MustCompile(
+	`^\$([a-zA-Z0-9_]+)\.([a-zA-Z0-9_\-]+)$`)
+
+// fixture row
+type row struct {
+	label string
+	data  sqldb.Data
+}
+
+// Fixtures represents the loaded fixtures rows of tables.
+type Fixtures struct {
+	// tables names in loading order
+	tables []string
+	// tables rows in files order
+	rows map[string][]*row
+	// key columns of models tables being applied
+	keys map[string][]string
+}
+
+// New creates a new empty fixtures set.
+func New() *Fixtures {
+	return &Fixtures{
+		rows: map[string][]*row{},
+		keys: map[string][]string{},
+	}
+}
+
+// Tables returns the loaded fixtures tables names.
+func (f *Fixtures) Tables() []string {
+	return append([]string{}, f.tables...)
+}
+
+// Guid returns the symbolic guid of fixture row, which is the explicit
+// row guid if set, or the guid generated from table and label.
+func (f *Fixtures) Guid(table, label string) string {
+	for _, r := range f.rows[table] {
+		if r.label == label {
+			if guid, ok := r.data["guid"].(string); ok && guid != "" {
+				return guid
+			}
+			break
+		}
+	}
+	return fixture_guid(table, label)
+}
+
+// LoadFS loads the fixtures files matching the patterns in file system,
+// which can be an embedded file system. files with the ".json", ".yaml"
+// or ".yml" extensions are loaded in names order.
+func (f *Fixtures) LoadFS(fsys fs.FS, patterns ...string) error {
+	for _, pattern := range patterns {
+		names, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return fmt.Errorf("%w - %v", ErrFixture, err)
+		}
+		for _, name := range names {
+			switch strings.ToLower(path.Ext(name)) {
+			case ".json", ".yaml", ".yml":
+			default:
+				continue
+			}
+			data, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return fmt.Errorf("%w - %v", ErrFixture, err)
+			}
+			if err := f.Parse(name, data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LoadFile loads the fixtures files from paths.
+func (f *Fixtures) LoadFile(paths ...string) error {
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("%w - %v", ErrFixture, err)
+		}
+		if err := f.Parse(p, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Parse parses the fixtures JSON or YAML data, where name is the data
+// source name used in errors. JSON data is parsed as YAML, which keeps
+// the rows in their defined order.
+func (f *Fixtures) Parse(name string, data []byte) error {
+	var doc yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return fmt.Errorf("%w - %s, %v", ErrFixture, name, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%w - %s, tables mapping expected", ErrFixture, name)
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		table, node := root.Content[i].Value, root.Content[i+1]
+		if !sqldb.SqlIdent(table) {
+			return fmt.Errorf("%w - %s, invalid table name '%s'",
+				ErrFixture, name, table)
+		}
+		rows := []*row{}
+		switch node.Kind {
+		case yaml.MappingNode:
+			for j := 0; j+1 < len(node.Content); j += 2 {
+				r := &row{label: node.Content[j].Value}
+				if err := node.Content[j+1].Decode(&r.data); err != nil {
+					return fmt.Errorf("%w - %s, table %s row %s, %v",
+						ErrFixture, name, table, r.label, err)
+				}
+				rows = append(rows, r)
+			}
+		case yaml.SequenceNode:
+			for j, n := range node.Content {
+				r := &row{}
+				if err := n.Decode(&r.data); err != nil {
+					return fmt.Errorf("%w - %s, table %s row %d, %v",
+						ErrFixture, name, table, j, err)
+				}
+				rows = append(rows, r)
+			}
+		default:
+			return fmt.Errorf("%w - %s, table %s rows expected",
+				ErrFixture, name, table)
+		}
+		if err := f.add(table, rows); err != nil {
+			return fmt.Errorf("%w - %s", err, name)
+		}
+	}
+	return nil
+}
+
+// adds the rows to table fixtures, checking for duplicate labels
+func (f *Fixtures) add(table string, rows []*row) error {
+	existing, ok := f.rows[table]
+	if !ok {
+		f.tables = append(f.tables, table)
+	}
+	for _, r := range rows {
+		if r.data == nil {
+			r.data = sqldb.Data{}
+		}
+		if r.label == "" {
+			existing = append(existing, r)
+			continue
+		}
+		for _, e := range existing {
+			if e.label == r.label {
+				return fmt.Errorf("%w - duplicate row %s.%s",
+					ErrFixture, table, r.label)
+			}
+		}
+		existing = append(existing, r)
+	}
+	f.rows[table] = existing
+	return nil
+}
+
+// Apply writes the fixtures rows of models tables in dependency order,
+// where the tables referenced by foreign keys are written first. all
+// the fixtures tables must have models in metainfo. Apply should run
+// in a session transaction to load the fixtures atomically.
+func (f *Fixtures) Apply(dbs *sqldb.Session, metainfo []sqldb.ModelMeta) error {
+	models := map[string]bool{}
+	for _, m := range metainfo {
+		models[m.Table] = true
+		f.keys[m.Table] = table_keys(m.Model.TableMeta())
+	}
+	for _, table := range f.tables {
+		if !models[table] {
+			return fmt.Errorf("%w - no model for table %s", ErrFixture, table)
+		}
+	}
+	for _, m := range sqldb.OrderModels(metainfo) {
+		if err := f.ApplyModel(dbs, m.Table, m.Model); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyModel writes the fixtures rows of one model table, which can be
+// used in the model InitialData.
+func (f *Fixtures) ApplyModel(
+	dbs *sqldb.Session, table string, model sqldb.Model) error {
+	rows, ok := f.rows[table]
+	if !ok {
+		return nil
+	}
+	meta := model.TableMeta()
+	if meta != nil && meta.View != nil {
+		return fmt.Errorf("%w - table %s, %v",
+			ErrFixture, table, sqldb.ErrReadOnly)
+	}
+	keys := table_keys(meta)
+	if len(keys) == 0 {
+		return fmt.Errorf("%w - table %s has no key columns",
+			ErrFixture, table)
+	}
+	f.keys[table] = keys
+
+	for i, r := range rows {
+		data, err := f.resolve(table, r, keys)
+		if err != nil {
+			return err
+		}
+		if err := upsert(dbs, table, model, keys, data); err != nil {
+			return fmt.Errorf("%w - table %s row %s, %v",
+				ErrFixture, table, row_name(r, i), err)
+		}
+	}
+	return nil
+}
+
+// returns the row data copy with symbolic guid and resolved references
+func (f *Fixtures) resolve(
+	table string, r *row, keys []string) (sqldb.Data, error) {
+	data := sqldb.Data{}
+	for k, v := range r.data {
+		s, ok := v.(string)
+		if !ok || !strings.HasPrefix(s, "$") {
+			data[k] = v
+			continue
+		}
+		if strings.HasPrefix(s, "$$") {
+			data[k] = s[1:]
+			continue
+		}
+		match := referenceRegex.FindStringSubmatch(s)
+		if match == nil {
+			data[k] = v
+			continue
+		}
+		if !f.has(match[1], match[2]) {
+			return nil, fmt.Errorf("%w - %s in table %s",
+				ErrReference, s, table)
+		}
+		// the referenced rows are resolved by guid
+		if k, ok := f.keys[match[1]]; ok && (len(k) == 0 || k[0] != "guid") {
+			return nil, fmt.Errorf("%w - %s in table %s, table %s key "+
+				"is not guid", ErrReference, s, table, match[1])
+		}
+		data[k] = f.Guid(match[1], match[2])
+	}
+	if _, ok := data["guid"]; !ok && r.label != "" && keys[0] == "guid" {
+		data["guid"] = fixture_guid(table, r.label)
+	}
+	return data, nil
+}
+
+// checks if fixtures has labeled row in table
+func (f *Fixtures) has(table, label string) bool {
+	for _, r := range f.rows[table] {
+		if r.label == label {
+			return true
+		}
+	}
+	return false
+}
+
+////////////////////////////////////////////////////
+
+// inserts the row data or updates the existing row with same keys
+func upsert(dbs *sqldb.Session, table string, model sqldb.Model,
+	keys []string, data sqldb.Data) error {
+	filtered := func() *sqldb.Query {
+		q := dbs.Query(model).TableName(table)
+		for _, k := range keys {
+			q = q.FilterBy(k, data[k])
+		}
+		return q
+	}
+	for _, k := range keys {
+		if data[k] == nil {
+			return fmt.Errorf("missing key column %s", k)
+		}
+	}
+
+	n, err := filtered().Count()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		_, err = dbs.Query(model).TableName(table).Insert(data)
+		return err
+	}
+
+	update := sqldb.Data{}
+	for k, v := range data {
+		if !is_key(keys, k) {
+			update[k] = v
+		}
+	}
+	if len(update) > 0 {
+		_, err = filtered().Update(update)
+	}
+	return err
+}
+
+// returns the table key columns, which is the guid column if defined,
+// else the primary columns.
+func table_keys(meta *sqldb.TableMeta) []string {
+	if meta == nil {
+		return nil
+	}
+	keys := []string{}
+	for _, c := range meta.Columns {
+		if c.Name == "guid" {
+			return []string{"guid"}
+		} else if c.Primary {
+			keys = append(keys, c.Name)
+		}
+	}
+	if meta.AutoGuid {
+		return []string{"guid"}
+	}
+	return keys
+}
+
+func is_key(keys []string, name string) bool {
+	for _, k := range keys {
+		if k == name {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the generated symbolic guid of fixture row
+func fixture_guid(table, label string) string {
+	u := uuid.NewV5(uuid.NamespaceOID, "fixtures:"+table+"."+label)
+	return fmt.Sprintf("%x", u.Bytes())
+}
+
+// returns the row label or index for errors
+func row_name(r *row, index int) string {
+	if r.label != "" {
+		return r.label
+	}
+	return fmt.Sprint(index)
+}
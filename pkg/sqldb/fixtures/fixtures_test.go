@@ -0,0 +1,92 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package fixtures
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/exonlabs/go-utils/pkg/abc/dictx"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+	sqlitedb "github.com/exonlabs/go-sqldb/pkg/sqlite_modernc"
+)
+
+type testModel struct {
+	sqldb.BaseModel
+	meta *sqldb.TableMeta
+}
+
+func (m *testModel) TableMeta() *sqldb.TableMeta {
+	return m.meta
+}
+
+func testSession(t *testing.T, metainfo []sqldb.ModelMeta) *sqldb.Session {
+	t.Helper()
+	opts := dictx.Dict{
+		"database": filepath.Join(t.TempDir(), "test.db"),
+	}
+	engine, err := sqlitedb.NewEngine(nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { engine.Close(nil) })
+	db := sqldb.NewDatabase(nil, engine, opts)
+	if err := sqldb.InitializeModels(db, metainfo); err != nil {
+		t.Fatal(err)
+	}
+	return db.Session()
+}
+
+func TestApplyReference(t *testing.T) {
+	roles := &testModel{meta: &sqldb.TableMeta{Columns: []sqldb.ColumnMeta{
+		{Name: "guid", Kind: sqldb.KindString, Size: 32, Primary: true},
+		{Name: "name", Kind: sqldb.KindText},
+	}}}
+	groups := &testModel{meta: &sqldb.TableMeta{Columns: []sqldb.ColumnMeta{
+		{Name: "id", Kind: sqldb.KindInt, Primary: true},
+		{Name: "name", Kind: sqldb.KindText},
+	}}}
+	users := &testModel{meta: &sqldb.TableMeta{Columns: []sqldb.ColumnMeta{
+		{Name: "guid", Kind: sqldb.KindString, Size: 32, Primary: true},
+		{Name: "ref", Kind: sqldb.KindString, Size: 32},
+	}}}
+	metainfo := []sqldb.ModelMeta{
+		{Table: "roles", Model: roles},
+		{Table: "groups", Model: groups},
+		{Table: "users", Model: users},
+	}
+	dbs := testSession(t, metainfo)
+
+	f := New()
+	if err := f.Parse("a.yaml", []byte(
+		"roles:\n  admin:\n    name: Administrator\n"+
+			"groups:\n  staff:\n    id: 1\n    name: Staff\n"+
+			"users:\n  alice:\n    ref: $roles.admin\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Apply(dbs, metainfo); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := dbs.Query(users).TableName("users").All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0]["ref"] != f.Guid("roles", "admin") {
+		t.Errorf("users rows %v", rows)
+	}
+
+	// references to tables without guid key are not resolved
+	f = New()
+	if err := f.Parse("b.yaml", []byte(
+		"groups:\n  staff:\n    id: 1\n    name: Staff\n"+
+			"users:\n  bob:\n    ref: $groups.staff\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Apply(dbs, metainfo); !errors.Is(err, ErrReference) {
+		t.Errorf("error = %v, want ErrReference", err)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Introspector defines the database schema introspection interface,
+// implemented by the backends engines supporting it.
+type Introspector interface {
+	// ListTables returns the database tables names.
+	ListTables(dbs *Session) ([]string, error)
+	// DescribeTable returns the table metainfo from database, including
+	// the columns, primary keys, unique keys, indexes and foreign keys.
+	// ErrNoTable is returned if table doesn't exist.
+	DescribeTable(dbs *Session, tablename string) (*TableMeta, error)
+	// ListIndexes returns the table indexes.
+	ListIndexes(dbs *Session, tablename string) ([]IndexMeta, error)
+	// ServerVersion returns the database server version.
+	ServerVersion(dbs *Session) (string, error)
+}
+
+// Introspector returns the engine schema introspector, or ErrNotSupported
+// if the engine doesn't support introspection.
+func (db *Database) Introspector() (Introspector, error) {
+	if err := db.check_run(); err != nil {
+		return nil, err
+	}
+	if i, ok := db.engine.(Introspector); ok {
+		return i, nil
+	}
+	return nil, fmt.Errorf("%w - schema introspection", ErrNotSupported)
+}
+
+// NewTableMeta creates table metainfo from the introspected columns,
+// indexes, constraints and foreign keys. single column primary, unique
+// and plain indexes are set in columns, multi columns primary and unique
+// indexes are added as table constraints, and multi columns plain
+// indexes are added as table indexes.
+func NewTableMeta(columns []ColumnMeta, indexes []IndexMeta,
+	constraints []ConstraintMeta, foreignkeys []ForeignKeyMeta) *TableMeta {
+	meta := &TableMeta{Columns: columns}
+
+	set := func(name string, fn func(c *ColumnMeta)) {
+		for i := range meta.Columns {
+			if meta.Columns[i].Name == name {
+				fn(&meta.Columns[i])
+			}
+		}
+	}
+	for _, ix := range indexes {
+		cols := strings.Join(ix.Columns, ", ")
+		switch {
+		case ix.Primary && len(ix.Columns) == 1:
+			set(ix.Columns[0], func(c *ColumnMeta) { c.Primary = true })
+		case ix.Primary:
+			meta.Constraints = append(meta.Constraints, ConstraintMeta{
+				Definition: fmt.Sprintf("PRIMARY KEY (%s)", cols)})
+		case ix.Unique && len(ix.Columns) == 1:
+			set(ix.Columns[0], func(c *ColumnMeta) { c.Unique = true })
+		case ix.Unique:
+			meta.Constraints = append(meta.Constraints, ConstraintMeta{
+				Name: ix.Name, Definition: fmt.Sprintf("UNIQUE (%s)", cols)})
+		case len(ix.Columns) == 1:
+			set(ix.Columns[0], func(c *ColumnMeta) { c.Index = true })
+		default:
+			meta.Indexes = append(meta.Indexes, IndexMeta{
+				Name: ix.Name, Columns: ix.Columns})
+		}
+	}
+	meta.Constraints = append(meta.Constraints, constraints...)
+	for _, fk := range foreignkeys {
+		fk.OnUpdate = fk_action(fk.OnUpdate)
+		fk.OnDelete = fk_action(fk.OnDelete)
+		meta.ForeignKeys = append(meta.ForeignKeys, fk)
+	}
+
+	return meta
+}
+
+// ForeignKeyDefinition formats a foreign key constraint definition.
+// the referential actions are omitted if empty or "NO ACTION".
+func ForeignKeyDefinition(columns []string, table string, refs []string,
+	onUpdate, onDelete string) string {
+	def := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)",
+		strings.Join(columns, ", "), table, strings.Join(refs, ", "))
+	if onUpdate = fk_action(onUpdate); onUpdate != "" {
+		def += " ON UPDATE " + onUpdate
+	}
+	if onDelete = fk_action(onDelete); onDelete != "" {
+		def += " ON DELETE " + onDelete
+	}
+	return def
+}
+
+// returns the normalized foreign key referential action, or empty
+// string for the default "NO ACTION".
+func fk_action(action string) string {
+	action = strings.ToUpper(strings.Join(
+		strings.Fields(strings.ReplaceAll(action, "_", " ")), " "))
+	if action == "NO ACTION" {
+		return ""
+	}
+	return action
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// valid JSON path format "$.key1.key2[0]"
+var jsonPathRegex = regexp.MustCompile(`^\$(\.[a-zA-Z0-9_]+|\[[0-9]+\])*$`)
+
+// JSON path tokens, keys or array indexes
+var jsonTokenRegex = regexp.MustCompile(`\.([a-zA-Z0-9_]+)|\[([0-9]+)\]`)
+
+// Condition defines the filter conditions rendered per SQL dialect.
+type Condition interface {
+	// Expr returns the condition SQL expression and args using the
+	// SQL statment generator g.
+	Expr(g SqlGenerator) (string, []any, error)
+}
+
+// JSONField represents a value inside a JSON document column.
+type JSONField struct {
+	Column string
+	Path   string
+}
+
+// JSONPath creates a JSON field filter for the value at path inside the
+// JSON document column. path has the format "$.key1.key2[0]" where keys
+// are identifiers and array indexes are integers.
+//
+// The value is compared as text for string values and as number for
+// numeric values. ex.
+//
+//	dbs.Query(model).Where(sqldb.JSONPath("attrs", "$.color").Eq("red"))
+func JSONPath(column, path string) JSONField {
+	return JSONField{Column: column, Path: path}
+}
+
+// Eq creates the equal condition.
+func (f JSONField) Eq(v any) Condition { return &jsonCondition{f, "=", v} }
+
+// Ne creates the not equal condition.
+func (f JSONField) Ne(v any) Condition { return &jsonCondition{f, "<>", v} }
+
+// Gt creates the greater than condition.
+func (f JSONField) Gt(v any) Condition { return &jsonCondition{f, ">", v} }
+
+// Ge creates the greater than or equal condition.
+func (f JSONField) Ge(v any) Condition { return &jsonCondition{f, ">=", v} }
+
+// Lt creates the less than condition.
+func (f JSONField) Lt(v any) Condition { return &jsonCondition{f, "<", v} }
+
+// Le creates the less than or equal condition.
+func (f JSONField) Le(v any) Condition { return &jsonCondition{f, "<=", v} }
+
+// Like creates the pattern matching condition.
+func (f JSONField) Like(pattern string) Condition {
+	return &jsonCondition{f, " LIKE ", pattern}
+}
+
+// IsNull creates the condition for null or missing value.
+func (f JSONField) IsNull() Condition {
+	return &jsonCondition{f, " IS NULL", nil}
+}
+
+// NotNull creates the condition for existing non null value.
+func (f JSONField) NotNull() Condition {
+	return &jsonCondition{f, " IS NOT NULL", nil}
+}
+
+// JSONPathKeys returns the keys and array indexes of JSON path.
+func JSONPathKeys(path string) ([]string, error) {
+	if !jsonPathRegex.MatchString(path) {
+		return nil, fmt.Errorf("invalid JSON path '%s'", path)
+	}
+	keys := []string{}
+	for _, m := range jsonTokenRegex.FindAllStringSubmatch(path, -1) {
+		keys = append(keys, m[1]+m[2])
+	}
+	return keys, nil
+}
+
+////////////////////////////////////////////////////
+
+type jsonCondition struct {
+	field JSONField
+	op    string
+	value any
+}
+
+func (c *jsonCondition) Expr(g SqlGenerator) (string, []any, error) {
+	if !SqlIdent(c.field.Column) {
+		return "", nil, fmt.Errorf(
+			"invalid JSON column '%s'", c.field.Column)
+	}
+	if !jsonPathRegex.MatchString(c.field.Path) {
+		return "", nil, fmt.Errorf("invalid JSON path '%s'", c.field.Path)
+	}
+
+	numeric := false
+	switch c.value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32,
+		uint64, float32, float64:
+		numeric = true
+	}
+	expr, err := g.JSONValue(c.field.Column, c.field.Path, numeric)
+	if err != nil {
+		return "", nil, err
+	}
+	expr += c.op
+
+	if strings.HasPrefix(c.op, " IS ") {
+		return expr, nil, nil
+	}
+	return expr + SQL_PLACEHOLDER, []any{c.value}, nil
+}
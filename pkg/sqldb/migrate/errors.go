@@ -0,0 +1,20 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+var (
+	// ErrMigration indicates a migration definition or run error.
+	ErrMigration = fmt.Errorf("%wmigration error", sqldb.ErrError)
+	// ErrChecksum indicates an applied migration changed after apply.
+	ErrChecksum = fmt.Errorf("%wmigration checksum mismatch", sqldb.ErrError)
+	// ErrLocked indicates that the migrations lock is held by another runner.
+	ErrLocked = fmt.Errorf("%wmigrations locked", sqldb.ErrError)
+)
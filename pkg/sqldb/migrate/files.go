@@ -0,0 +1,108 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package migrate
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+// migration file name format: <version>_<name>[.<backend>].up|down.sql
+var fileRegex = regexp.MustCompile(
+	`^([0-9]+)_([a-zA-Z0-9_\-]+?)(\.(sqlite|mysql|pgsql|mssql))?\.(up|down)\.sql$`)
+
+// LoadFS loads the SQL migrations files from dir in file system, which
+// can be an embedded file system. the files names have the format:
+//
+//	<version>_<name>[.<backend>].up|down.sql
+//	ex. 0001_create_users.up.sql, 0001_create_users.pgsql.up.sql
+//
+// files of the database backend take precedence over the generic files
+// of same version, and files of other backends are ignored. each file is
+// executed as one statments batch, so mysql connections require the
+// "multiStatements=true" connect arg for files with many statments.
+func (m *Migrator) LoadFS(fsys fs.FS, dir string) error {
+	if m.db == nil {
+		return sqldb.ErrDBHandler
+	}
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("%w - %v", ErrMigration, err)
+	}
+
+	backend := m.db.Backend()
+	type source struct {
+		name     string
+		specific bool
+	}
+	migrations := map[int64]*Migration{}
+	sources := map[string]source{}
+
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		match := fileRegex.FindStringSubmatch(e.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w - invalid file '%s'", ErrMigration, e.Name())
+		}
+		name, fbackend, action := match[2], match[4], match[5]
+		if fbackend != "" && fbackend != backend {
+			continue
+		}
+
+		mg, ok := migrations[version]
+		if !ok {
+			mg = &Migration{Version: version, Name: name}
+			migrations[version] = mg
+		} else if mg.Name != name {
+			return fmt.Errorf("%w - version %d has different names",
+				ErrMigration, version)
+		}
+
+		// keep the backend specific files over generic files
+		key := fmt.Sprintf("%d.%s", version, action)
+		if src, ok := sources[key]; ok {
+			if src.specific == (fbackend != "") {
+				return fmt.Errorf("%w - duplicate files '%s' and '%s'",
+					ErrMigration, src.name, e.Name())
+			}
+			if src.specific {
+				continue
+			}
+		}
+		sources[key] = source{name: e.Name(), specific: fbackend != ""}
+
+		b, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return fmt.Errorf("%w - %v", ErrMigration, err)
+		}
+		if action == "up" {
+			mg.UpSQL = string(b)
+		} else {
+			mg.DownSQL = string(b)
+		}
+	}
+
+	for _, mg := range migrations {
+		if mg.UpSQL == "" {
+			return fmt.Errorf("%w - version %d has no up file",
+				ErrMigration, mg.Version)
+		}
+		if err := m.Add(mg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package migrate
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+// the lock row id in lock table
+const lockId = 1
+
+// lock table model, holding a single lock row updated atomically by
+// the migrations runners.
+type locks struct{ sqldb.BaseModel }
+
+var lockModel = &locks{}
+
+func (*locks) TableMeta() *sqldb.TableMeta {
+	return &sqldb.TableMeta{
+		Columns: []sqldb.ColumnMeta{
+			{Name: "id", Type: "INTEGER NOT NULL", Primary: true},
+			{Name: "owner", Type: "VARCHAR(64) NOT NULL"},
+			{Name: "locked_at", Type: "BIGINT NOT NULL"},
+		},
+	}
+}
+
+// InitialData creates the lock row if not exist.
+func (*locks) InitialData(dbs *sqldb.Session, tablename string) error {
+	q := dbs.Query(lockModel).TableName(tablename)
+	if n, err := q.FilterBy("id", lockId).Count(); err != nil || n > 0 {
+		return err
+	}
+	_, err := dbs.Query(lockModel).TableName(tablename).Insert(sqldb.Data{
+		"id": lockId, "owner": "", "locked_at": 0,
+	})
+	if err != nil {
+		// the lock row may be created by a concurrent runner
+		q := dbs.Query(lockModel).TableName(tablename)
+		if n, e := q.FilterBy("id", lockId).Count(); e == nil && n > 0 {
+			return nil
+		}
+	}
+	return err
+}
+
+// acquires the migrations lock and returns the lock owner id. the lock
+// is taken if free or expired, else retried until lock timeout.
+func (m *Migrator) lock() (string, error) {
+	owner := sqldb.NewGuid()
+	dbs := m.db.Session()
+	timeout := time.Now().Add(
+		time.Duration(m.LockTimeout * float64(time.Second)))
+
+	for {
+		now := time.Now().Unix()
+		n, err := dbs.Query(lockModel).TableName(m.Table+"_lock").
+			Filters("id=? AND (owner='' OR locked_at<?)",
+				lockId, now-int64(m.LockExpiry)).
+			Update(sqldb.Data{"owner": owner, "locked_at": now})
+		if err != nil {
+			return "", err
+		}
+		if n > 0 {
+			return owner, nil
+		}
+		if time.Now().After(timeout) {
+			return "", ErrLocked
+		}
+		if m.db.Log != nil {
+			m.db.Log.Debug("waiting for migrations lock")
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// heldLock represents the migrations lock held by runner, which is
+// refreshed periodically while the migrations run.
+type heldLock struct {
+	owner  string
+	expiry time.Duration
+
+	mu        sync.Mutex
+	refreshed time.Time
+	lost      bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// holds the acquired lock of owner, refreshing the lock time every third
+// of lock expiry until released.
+func (m *Migrator) hold(owner string) *heldLock {
+	l := &heldLock{
+		owner:     owner,
+		expiry:    time.Duration(m.LockExpiry * float64(time.Second)),
+		refreshed: time.Now(),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go func() {
+		defer close(l.done)
+		ticker := time.NewTicker(max(l.expiry/3, time.Millisecond))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.stop:
+				return
+			case <-ticker.C:
+				m.refresh(l)
+			}
+		}
+	}()
+	return l
+}
+
+// updates the held lock time, where the lock is lost if taken over by
+// other runner. failed updates are retried on next refresh.
+func (m *Migrator) refresh(l *heldLock) {
+	now := time.Now()
+	n, err := m.db.Session().Query(lockModel).TableName(m.Table+"_lock").
+		Filters("id=? AND owner=?", lockId, l.owner).
+		Update(sqldb.Data{"locked_at": now.Unix()})
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch {
+	case err != nil:
+		if m.db.Log != nil {
+			m.db.Log.Warn("refresh migrations lock failed, %v", err)
+		}
+	case n == 0:
+		l.lost = true
+	default:
+		l.refreshed = now
+	}
+}
+
+// checks the lock is still held, where the lock is considered lost if
+// taken over or not refreshed within two thirds of expiry, before other
+// runners can take it over.
+func (l *heldLock) check() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.lost || time.Since(l.refreshed) > l.expiry*2/3 {
+		return fmt.Errorf("%w - lock lost while running", ErrLocked)
+	}
+	return nil
+}
+
+// stops refreshing the held lock
+func (l *heldLock) release() {
+	close(l.stop)
+	<-l.done
+}
+
+// releases the migrations lock held by owner
+func (m *Migrator) unlock(owner string) error {
+	_, err := m.db.Session().Query(lockModel).TableName(m.Table+"_lock").
+		Filters("id=? AND owner=?", lockId, owner).
+		Update(sqldb.Data{"owner": "", "locked_at": 0})
+	if err != nil {
+		return fmt.Errorf("%w - release lock, %v", ErrMigration, err)
+	}
+	return nil
+}
@@ -0,0 +1,448 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+// Package migrate provides versioned database schema migrations.
+//
+// Migrations are ordered by version and applied each in a separate
+// transaction, where the applied versions are recorded in a bookkeeping
+// table with the migrations checksums. a lock row guards against
+// concurrent migrations runners.
+//
+// NOTE: DDL statments are not transactional on mysql and cause implicit
+// commits, so failed migrations may be partially applied on mysql.
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+// Migration represents a versioned schema migration. the migration
+// actions are defined either by Go functions or by SQL statments.
+type Migration struct {
+	// Version is the migration version, unique and ordered. ex. 1, 2, 3
+	// or timestamps formatted as 20240101120000.
+	Version int64
+	// Name is the migration descriptive name.
+	Name string
+
+	// Up applies the migration using the transactional session.
+	Up func(dbs *sqldb.Session) error
+	// Down reverts the migration using the transactional session.
+	Down func(dbs *sqldb.Session) error
+
+	// UpSQL holds the SQL statments applying the migration, used when
+	// Up function is not defined.
+	UpSQL string
+	// DownSQL holds the SQL statments reverting the migration, used when
+	// Down function is not defined.
+	DownSQL string
+}
+
+// Checksum returns the migration checksum. for SQL migrations it is the
+// hash of the up and down statments, else it is the hash of version and
+// name only, as Go functions can not be hashed.
+func (m *Migration) Checksum() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d:%s", m.Version, m.Name)
+	if m.Up == nil {
+		h.Write([]byte("\n" + m.UpSQL))
+	}
+	if m.Down == nil {
+		h.Write([]byte("\n" + m.DownSQL))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// runs the migration up or down action
+func (m *Migration) run(dbs *sqldb.Session, up bool) error {
+	fn, stmt := m.Up, m.UpSQL
+	if !up {
+		fn, stmt = m.Down, m.DownSQL
+	}
+	switch {
+	case fn != nil:
+		return fn(dbs)
+	case stmt != "":
+		_, err := dbs.Exec(stmt)
+		return err
+	case up:
+		return fmt.Errorf("%w - migration %d has no up action",
+			ErrMigration, m.Version)
+	}
+	return fmt.Errorf("%w - migration %d is not reversible",
+		ErrMigration, m.Version)
+}
+
+// Status represents a migration status.
+type Status struct {
+	Version int64
+	Name    string
+	// Applied is true if the migration is applied in database.
+	Applied bool
+	// AppliedAt is the migration apply time.
+	AppliedAt time.Time
+	// Modified is true if the applied migration checksum differs from
+	// the current migration checksum.
+	Modified bool
+	// Missing is true if the applied migration is not defined in
+	// the migrations set.
+	Missing bool
+}
+
+// applied migration record
+type record struct {
+	version   int64
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrator represents the migrations runner.
+type Migrator struct {
+	// the database handler
+	db *sqldb.Database
+	// the migrations ordered by version
+	migrations []*Migration
+
+	// Table is the bookkeeping table name. the lock table is created
+	// with the "_lock" suffix. (default "schema_migrations")
+	Table string
+	// LockTimeout sets the timeout in seconds to wait for migrations
+	// lock held by other runners. (default 30.0 sec)
+	LockTimeout float64
+	// LockExpiry sets the time in seconds after which a held lock is
+	// considered stale and can be taken over. the held lock is refreshed
+	// every third of expiry while migrations run, and the migrations fail
+	// with ErrLocked if the lock could not be refreshed. (default 600.0 sec)
+	LockExpiry float64
+
+	// the lock held while migrations run
+	held *heldLock
+}
+
+// New creates a new migrations runner for database.
+func New(db *sqldb.Database, migrations ...*Migration) (*Migrator, error) {
+	m := &Migrator{
+		db:          db,
+		Table:       "schema_migrations",
+		LockTimeout: 30.0,
+		LockExpiry:  600.0,
+	}
+	if err := m.Add(migrations...); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Add adds migrations to the migrations set.
+func (m *Migrator) Add(migrations ...*Migration) error {
+	for _, mg := range migrations {
+		if mg == nil {
+			continue
+		}
+		if mg.Version <= 0 {
+			return fmt.Errorf("%w - invalid version %d",
+				ErrMigration, mg.Version)
+		}
+		if m.find(mg.Version) != nil {
+			return fmt.Errorf("%w - duplicate version %d",
+				ErrMigration, mg.Version)
+		}
+		m.migrations = append(m.migrations, mg)
+	}
+	sort.Slice(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
+	})
+	return nil
+}
+
+// Migrations returns the migrations set ordered by version.
+func (m *Migrator) Migrations() []*Migration {
+	return m.migrations
+}
+
+// Status returns the status of defined and applied migrations
+// ordered by version.
+func (m *Migrator) Status() ([]Status, error) {
+	if err := m.prepare(); err != nil {
+		return nil, err
+	}
+	records, err := m.records(m.db.Session())
+	if err != nil {
+		return nil, err
+	}
+
+	result := []Status{}
+	for _, mg := range m.migrations {
+		st := Status{Version: mg.Version, Name: mg.Name}
+		if r, ok := records[mg.Version]; ok {
+			st.Applied = true
+			st.AppliedAt = r.appliedAt
+			st.Modified = r.checksum != mg.Checksum()
+		}
+		result = append(result, st)
+	}
+	for _, r := range records {
+		if m.find(r.version) == nil {
+			result = append(result, Status{
+				Version:   r.version,
+				Name:      r.name,
+				Applied:   true,
+				AppliedAt: r.appliedAt,
+				Missing:   true,
+			})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+	return result, nil
+}
+
+// Version returns the latest applied migration version, or 0 if no
+// migrations are applied.
+func (m *Migrator) Version() (int64, error) {
+	if err := m.prepare(); err != nil {
+		return 0, err
+	}
+	records, err := m.records(m.db.Session())
+	if err != nil {
+		return 0, err
+	}
+	var version int64
+	for v := range records {
+		version = max(version, v)
+	}
+	return version, nil
+}
+
+// Up applies all pending migrations in order.
+func (m *Migrator) Up() error {
+	return m.migrate(func(records map[int64]*record) error {
+		for _, mg := range m.migrations {
+			if _, ok := records[mg.Version]; !ok {
+				if err := m.apply(mg, true); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Down reverts the latest applied migration.
+func (m *Migrator) Down() error {
+	return m.migrate(func(records map[int64]*record) error {
+		var version int64
+		for v := range records {
+			version = max(version, v)
+		}
+		if version == 0 {
+			return nil
+		}
+		return m.revert(version)
+	})
+}
+
+// To migrates the database to the target version, by reverting the
+// applied migrations after version then applying the pending migrations
+// up to version. use version 0 to revert all migrations.
+func (m *Migrator) To(version int64) error {
+	if version > 0 && m.find(version) == nil {
+		return fmt.Errorf("%w - unknown version %d", ErrMigration, version)
+	}
+	return m.migrate(func(records map[int64]*record) error {
+		versions := []int64{}
+		for v := range records {
+			if v > version {
+				versions = append(versions, v)
+			}
+		}
+		sort.Slice(versions, func(i, j int) bool {
+			return versions[i] > versions[j]
+		})
+		for _, v := range versions {
+			if err := m.revert(v); err != nil {
+				return err
+			}
+		}
+		for _, mg := range m.migrations {
+			if mg.Version > version {
+				break
+			}
+			if _, ok := records[mg.Version]; !ok {
+				if err := m.apply(mg, true); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+////////////////////////////////////////////////////
+
+// returns the migration for version or nil if not found
+func (m *Migrator) find(version int64) *Migration {
+	for _, mg := range m.migrations {
+		if mg.Version == version {
+			return mg
+		}
+	}
+	return nil
+}
+
+// runs the migration operation holding the migrations lock, after
+// verifying the applied migrations checksums.
+func (m *Migrator) migrate(fn func(map[int64]*record) error) (err error) {
+	if err := m.prepare(); err != nil {
+		return err
+	}
+
+	owner, err := m.lock()
+	if err != nil {
+		return err
+	}
+	m.held = m.hold(owner)
+	defer func() {
+		m.held.release()
+		m.held = nil
+		if e := m.unlock(owner); e != nil {
+			if err == nil {
+				err = e
+			} else if m.db.Log != nil {
+				m.db.Log.Error("%v", e)
+			}
+		}
+	}()
+
+	records, err := m.records(m.db.Session())
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		if mg := m.find(r.version); mg != nil && mg.Checksum() != r.checksum {
+			return fmt.Errorf("%w - version %d", ErrChecksum, r.version)
+		}
+	}
+	return fn(records)
+}
+
+// reverts an applied migration by version
+func (m *Migrator) revert(version int64) error {
+	mg := m.find(version)
+	if mg == nil {
+		return fmt.Errorf("%w - applied version %d is not defined",
+			ErrMigration, version)
+	}
+	return m.apply(mg, false)
+}
+
+// applies or reverts a migration and its record in one transaction
+func (m *Migrator) apply(mg *Migration, up bool) error {
+	if m.db.Log != nil {
+		if up {
+			m.db.Log.Info("applying migration %d: %s", mg.Version, mg.Name)
+		} else {
+			m.db.Log.Info("reverting migration %d: %s", mg.Version, mg.Name)
+		}
+	}
+
+	if err := m.held.check(); err != nil {
+		return err
+	}
+	dbs := m.db.Session()
+	if err := dbs.Begin(); err != nil {
+		return err
+	}
+	err := mg.run(dbs, up)
+	if err == nil {
+		// the lock may be lost while running long migrations
+		if e := m.held.check(); e != nil {
+			dbs.RollBack()
+			return e
+		}
+		q := dbs.Query(migrationsModel).TableName(m.Table)
+		if up {
+			_, err = q.Insert(sqldb.Data{
+				"version":    mg.Version,
+				"name":       mg.Name,
+				"checksum":   mg.Checksum(),
+				"applied_at": time.Now().Unix(),
+			})
+		} else {
+			_, err = q.FilterBy("version", mg.Version).Delete()
+		}
+	}
+	if err != nil {
+		dbs.RollBack()
+		return fmt.Errorf("%w - version %d, %v", ErrMigration, mg.Version, err)
+	}
+	return dbs.Commit()
+}
+
+// returns the applied migrations records
+func (m *Migrator) records(dbs *sqldb.Session) (map[int64]*record, error) {
+	data, err := dbs.Query(migrationsModel).TableName(m.Table).All()
+	if err != nil {
+		return nil, err
+	}
+	result := map[int64]*record{}
+	for _, d := range data {
+		row := sqldb.Row(d)
+		version, err := row.Int64("version")
+		if err != nil {
+			return nil, err
+		}
+		r := &record{version: version}
+		r.name, _ = row.String("name")
+		r.checksum, _ = row.String("checksum")
+		if ts, err := row.Int64("applied_at"); err == nil {
+			r.appliedAt = time.Unix(ts, 0)
+		}
+		result[version] = r
+	}
+	return result, nil
+}
+
+// creates the bookkeeping and lock tables if not exist
+func (m *Migrator) prepare() error {
+	if m.db == nil {
+		return sqldb.ErrDBHandler
+	}
+	if !sqldb.SqlIdent(m.Table) {
+		return fmt.Errorf("%w - invalid table name '%s'",
+			ErrMigration, m.Table)
+	}
+	return sqldb.InitializeModels(m.db, []sqldb.ModelMeta{
+		{Table: m.Table, Model: migrationsModel},
+		{Table: m.Table + "_lock", Model: lockModel},
+	})
+}
+
+////////////////////////////////////////////////////
+
+// bookkeeping table model
+type migrations struct{ sqldb.BaseModel }
+
+var migrationsModel = &migrations{sqldb.BaseModel{
+	DefaultOrders: []string{"version ASC"},
+}}
+
+func (*migrations) TableMeta() *sqldb.TableMeta {
+	return &sqldb.TableMeta{
+		Columns: []sqldb.ColumnMeta{
+			{Name: "version", Type: "BIGINT NOT NULL", Primary: true},
+			{Name: "name", Type: "VARCHAR(255) NOT NULL"},
+			{Name: "checksum", Type: "VARCHAR(64) NOT NULL"},
+			{Name: "applied_at", Type: "BIGINT NOT NULL"},
+		},
+	}
+}
@@ -0,0 +1,303 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package migrate
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/exonlabs/go-utils/pkg/abc/dictx"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+	sqlitedb "github.com/exonlabs/go-sqldb/pkg/sqlite_modernc"
+)
+
+func testDatabase(t *testing.T) *sqldb.Database {
+	t.Helper()
+	opts := dictx.Dict{
+		"database": filepath.Join(t.TempDir(), "test.db"),
+	}
+	engine, err := sqlitedb.NewEngine(nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sqldb.NewDatabase(nil, engine, opts)
+	t.Cleanup(db.Shutdown)
+	return db
+}
+
+func testMigrations() []*Migration {
+	return []*Migration{
+		{Version: 1, Name: "t1",
+			UpSQL: "CREATE TABLE t1 (id INTEGER);", DownSQL: "DROP TABLE t1;"},
+		{Version: 2, Name: "t2",
+			UpSQL: "CREATE TABLE t2 (id INTEGER);", DownSQL: "DROP TABLE t2;"},
+		{Version: 3, Name: "t3",
+			UpSQL: "CREATE TABLE t3 (id INTEGER);", DownSQL: "DROP TABLE t3;"},
+	}
+}
+
+// checks the migrator version and the existing migrations tables
+func checkVersion(t *testing.T, m *Migrator, version int64) {
+	t.Helper()
+	v, err := m.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != version {
+		t.Fatalf("version %d, want %d", v, version)
+	}
+	dbs := m.db.Session()
+	for _, mg := range m.Migrations() {
+		rows, err := dbs.Fetch("SELECT count(*) AS n FROM sqlite_master "+
+			"WHERE type='table' AND name=?;", mg.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, _ := sqldb.Row(rows[0]).Int64("n")
+		if exists := n > 0; exists != (mg.Version <= version) {
+			t.Errorf("version %d table %s exists %v", version, mg.Name, exists)
+		}
+	}
+}
+
+func TestUpDownTo(t *testing.T) {
+	m, err := New(testDatabase(t), testMigrations()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkVersion(t, m, 0)
+
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	checkVersion(t, m, 3)
+	if err := m.Down(); err != nil {
+		t.Fatal(err)
+	}
+	checkVersion(t, m, 2)
+	if err := m.To(1); err != nil {
+		t.Fatal(err)
+	}
+	checkVersion(t, m, 1)
+	if err := m.To(3); err != nil {
+		t.Fatal(err)
+	}
+	checkVersion(t, m, 3)
+	if err := m.To(0); err != nil {
+		t.Fatal(err)
+	}
+	checkVersion(t, m, 0)
+
+	if err := m.To(9); !errors.Is(err, ErrMigration) {
+		t.Errorf("unknown version error = %v", err)
+	}
+}
+
+func TestFailedMigration(t *testing.T) {
+	migrations := append(testMigrations(), &Migration{
+		Version: 4, Name: "bad", UpSQL: "CREATE TABLE t1 (id INTEGER);"})
+	m, err := New(testDatabase(t), migrations...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); !errors.Is(err, ErrMigration) {
+		t.Fatalf("failed migration error = %v", err)
+	}
+	// the migrations before the failed one stay applied
+	if v, _ := m.Version(); v != 3 {
+		t.Errorf("version %d, want 3", v)
+	}
+}
+
+func TestStatusChecksum(t *testing.T) {
+	db := testDatabase(t)
+	m, err := New(db, testMigrations()[:2]...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	// version 2 is modified and version 1 is not defined
+	migrations := testMigrations()[1:]
+	migrations[0].UpSQL = "CREATE TABLE t2 (id INTEGER, name TEXT);"
+	m, err = New(db, migrations...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Status{
+		{Version: 1, Name: "t1", Applied: true, Missing: true},
+		{Version: 2, Name: "t2", Applied: true, Modified: true},
+		{Version: 3, Name: "t3"},
+	}
+	if len(status) != len(want) {
+		t.Fatalf("status %+v", status)
+	}
+	for i, st := range status {
+		st.AppliedAt = time.Time{}
+		if st != want[i] {
+			t.Errorf("status %+v, want %+v", st, want[i])
+		}
+	}
+
+	if err := m.Up(); !errors.Is(err, ErrChecksum) {
+		t.Errorf("modified migration error = %v", err)
+	}
+	if v, _ := m.Version(); v != 2 {
+		t.Errorf("version %d after checksum error, want 2", v)
+	}
+}
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/0001_users.up.sql":        {Data: []byte("generic up")},
+		"sql/0001_users.sqlite.up.sql": {Data: []byte("sqlite up")},
+		"sql/0001_users.pgsql.up.sql":  {Data: []byte("pgsql up")},
+		"sql/0001_users.down.sql":      {Data: []byte("generic down")},
+		"sql/0002_roles.down.sql":      {Data: []byte("down only")},
+		"sql/0003_items.up.sql":        {Data: []byte("items up")},
+		"sql/readme.txt":               {Data: []byte("ignored")},
+	}
+	m, err := New(testDatabase(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.LoadFS(fsys, "sql"); !errors.Is(err, ErrMigration) {
+		t.Fatalf("missing up file error = %v", err)
+	}
+
+	delete(fsys, "sql/0002_roles.down.sql")
+	m, _ = New(m.db)
+	if err := m.LoadFS(fsys, "sql"); err != nil {
+		t.Fatal(err)
+	}
+	list := m.Migrations()
+	if len(list) != 2 {
+		t.Fatalf("loaded %d migrations", len(list))
+	}
+	if mg := list[0]; mg.Version != 1 || mg.Name != "users" ||
+		mg.UpSQL != "sqlite up" || mg.DownSQL != "generic down" {
+		t.Errorf("migration %+v", mg)
+	}
+	if mg := list[1]; mg.Version != 3 || mg.UpSQL != "items up" ||
+		mg.DownSQL != "" {
+		t.Errorf("migration %+v", mg)
+	}
+
+	// the specific file takes precedence regardless of files order
+	fsys["sql/0001_users.sqlite.down.sql"] = &fstest.MapFile{
+		Data: []byte("sqlite down")}
+	m, _ = New(m.db)
+	if err := m.LoadFS(fsys, "sql"); err != nil {
+		t.Fatal(err)
+	}
+	if mg := m.Migrations()[0]; mg.DownSQL != "sqlite down" {
+		t.Errorf("down file %q, want sqlite down", mg.DownSQL)
+	}
+
+	fsys["sql/0003_other.up.sql"] = &fstest.MapFile{Data: []byte("x")}
+	m, _ = New(m.db)
+	if err := m.LoadFS(fsys, "sql"); !errors.Is(err, ErrMigration) {
+		t.Errorf("different names error = %v", err)
+	}
+}
+
+func TestLockContention(t *testing.T) {
+	db := testDatabase(t)
+	m1, _ := New(db, testMigrations()...)
+	m2, _ := New(db, testMigrations()...)
+	m2.LockTimeout = 0
+	if err := m1.prepare(); err != nil {
+		t.Fatal(err)
+	}
+
+	owner, err := m1.lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m2.Up(); !errors.Is(err, ErrLocked) {
+		t.Fatalf("locked error = %v", err)
+	}
+	if err := m1.unlock(owner); err != nil {
+		t.Fatal(err)
+	}
+	if err := m2.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the expired lock is taken over
+	if _, err := m1.lock(); err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Session().Exec("UPDATE schema_migrations_lock SET locked_at=?;",
+		time.Now().Unix()-int64(m2.LockExpiry)-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m2.Down(); err != nil {
+		t.Errorf("expired lock takeover error = %v", err)
+	}
+}
+
+func TestLockRefresh(t *testing.T) {
+	db := testDatabase(t)
+	m1, _ := New(db, &Migration{Version: 1, Name: "slow",
+		Up: func(dbs *sqldb.Session) error {
+			time.Sleep(2500 * time.Millisecond)
+			return nil
+		}})
+	m1.LockExpiry = 1.5
+	m2, _ := New(db, m1.Migrations()...)
+	m2.LockExpiry = 1.5
+	m2.LockTimeout = 0
+
+	done := make(chan error)
+	go func() { done <- m1.Up() }()
+
+	// the held lock is not taken over while the slow migration runs
+	time.Sleep(2 * time.Second)
+	if err := m2.Up(); !errors.Is(err, ErrLocked) {
+		t.Errorf("running migration lock error = %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := m1.Version(); v != 1 {
+		t.Errorf("version %d, want 1", v)
+	}
+}
+
+func TestLockLost(t *testing.T) {
+	db := testDatabase(t)
+	var m *Migrator
+	m, _ = New(db, &Migration{Version: 1, Name: "t1",
+		Up: func(dbs *sqldb.Session) error {
+			// the lock is taken over by other runner
+			_, err := db.Session().Exec("UPDATE schema_migrations_lock " +
+				"SET owner='other';")
+			if err != nil {
+				return err
+			}
+			m.refresh(m.held)
+			_, err = dbs.Exec("CREATE TABLE t1 (id INTEGER);")
+			return err
+		}})
+	if err := m.Up(); !errors.Is(err, ErrLocked) {
+		t.Fatalf("lost lock error = %v", err)
+	}
+	// the migration is rolled back
+	if v, _ := m.Version(); v != 0 {
+		t.Errorf("version %d, want 0", v)
+	}
+}
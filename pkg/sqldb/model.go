@@ -4,7 +4,17 @@
 
 package sqldb
 
-import "strings"
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/exonlabs/go-utils/pkg/logging"
+)
+
+// foreign key referenced table in constraint definition
+var referencesRegex = regexp.MustCompile(`(?i)\bREFERENCES\s+([a-zA-Z0-9_]+)`)
 
 // Model defines the model interface.
 type Model interface {
@@ -22,8 +32,10 @@ type Model interface {
 	// IsAutoGuid returns true if the AutoGuid operations are enabled.
 	IsAutoGuid() bool
 	// DataEncode applies encoding to data before writing to database.
+	// it is called before applying the table columns codecs.
 	DataEncode([]Data) error
 	// DataDecode applies decoding on data after reading from database.
+	// it is called after applying the table columns codecs.
 	DataDecode([]Data) error
 
 	// PreSchema is called before creating the table schema in database.
@@ -83,12 +95,14 @@ func (m *BaseModel) IsAutoGuid() bool {
 	return m.AutoGuid
 }
 
-// DataEncode applies encoding to data before writing to database.
+// DataEncode applies extra encoding to data before writing to database.
+// the table columns codecs are applied after it by the queries.
 func (m *BaseModel) DataEncode([]Data) error {
 	return nil
 }
 
-// DataDecode applies decoding on data after reading from database.
+// DataDecode applies extra decoding on data after reading from database.
+// the table columns codecs are applied before it by the queries.
 func (m *BaseModel) DataDecode([]Data) error {
 	return nil
 }
@@ -110,69 +124,462 @@ func (m *BaseModel) InitialData(dbs *Session, tablename string) error {
 
 ////////////////////////////////////////////////////
 
-// checks for errors duplicates or already exist
-func is_duplicates(err error) bool {
-	err_str := strings.ToLower(err.Error())
-
-	if (strings.Contains(err_str, "table") ||
-		strings.Contains(err_str, "index") ||
-		strings.Contains(err_str, "relation")) &&
-		strings.Contains(err_str, "already exists") {
-		return true
-	}
+// ModelReport represents the initialization report of model.
+type ModelReport struct {
+	// the model table name.
+	Table string
+	// the last initialization step of model, one of "pre_schema",
+	// "schema", "post_schema" and "initial_data".
+	Step string
+	// the number of executed schema statments, and the number of skipped
+	// statments creating already existing database objects.
+	Executed, Skipped int
+	// the step error if the model initialization failed.
+	Err error
+}
 
-	if strings.Contains(err_str, "duplicate key name") {
-		return true
+// String returns the model report description.
+func (r *ModelReport) String() string {
+	if r.Err != nil {
+		return fmt.Sprintf("%s: %s failed, %v", r.Table, r.Step, r.Err)
 	}
+	return fmt.Sprintf("%s: %s done, %d executed, %d existing",
+		r.Table, r.Step, r.Executed, r.Skipped)
+}
 
-	return false
+// InitReport represents the models initialization report.
+type InitReport struct {
+	// the models reports in initialization order.
+	Models []*ModelReport
+	// set if the schema statments, the models PostSchema hooks and the
+	// initial data ran in one transaction.
+	Transactional bool
+	// set if the transaction was rolled back on failure.
+	RolledBack bool
 }
 
 // InitializeModels creates and alter the database models schema,
-// then adds the models intial data.
+// then adds the models intial data. when the database SchemaSync mode
+// is set, the models are compared with the existing tables to report
+// or apply the schema changes.
+//
+// the models are created in dependency order, where the models referenced
+// by foreign keys are created first and the view models are created
+// after all tables. the foreign keys forming circular references are
+// added after creating all tables, for backends supporting adding
+// constraints to existing tables.
 func InitializeModels(db *Database, metainfo []ModelMeta) error {
+	_, err := InitializeModelsReport(db, metainfo)
+	return err
+}
+
+// InitializeModelsReport initializes the database models the same as
+// InitializeModels and returns the per-model initialization report.
+//
+// the schema statments, the models PostSchema hooks and the initial data
+// run in one transaction for backends supporting transactional schema
+// statments, where the transaction is rolled back on failure leaving no
+// partially created schema. the statments creating already existing
+// database objects are skipped, classified by the backend error codes.
+func InitializeModelsReport(
+	db *Database, metainfo []ModelMeta) (*InitReport, error) {
 	if db == nil {
-		return ErrDBHandler
+		return nil, ErrDBHandler
 	}
 
 	// create new session
 	dbs := db.Session()
+	g := db.engine.SqlGenerator()
+	ordered := models_order(metainfo)
+
+	report := &InitReport{Transactional: true}
+	models := map[string]*ModelReport{}
+	for _, m := range ordered {
+		r := &ModelReport{Table: m.meta.Table}
+		report.Models = append(report.Models, r)
+		models[m.meta.Table] = r
+		if !db.engine.TransactionalDDL(m.meta.Model.TableMeta()) {
+			report.Transactional = false
+		}
+	}
+	fail := func(r *ModelReport, err error) (*InitReport, error) {
+		r.Err = err
+		if dbs.stx != nil && dbs.RollBack() == nil {
+			report.RolledBack = true
+		}
+		if db.Log != nil {
+			db.Log.Error("model %s", r)
+		}
+		return report, fmt.Errorf("%w - model %s, %s", err, r.Table, r.Step)
+	}
 
 	// create and alter schema
 	if db.Log != nil {
 		db.Log.Debug("creating models schema")
 	}
-	for _, meta := range metainfo {
-		if err := meta.Model.PreSchema(dbs, &meta); err != nil {
+	for i, m := range ordered {
+		report.Models[i].Step = "pre_schema"
+		if err := m.meta.Model.PreSchema(dbs, &m.meta); err != nil {
+			return fail(report.Models[i], err)
+		}
+	}
+	if db.SchemaSync != "" {
+		if err := sync_models(db, metainfo); err != nil {
+			return report, err
+		}
+	}
+	stmts, err := models_schema(g, ordered, db.Log)
+	if err != nil {
+		return report, err
+	}
+
+	if report.Transactional {
+		if err := dbs.Begin(); err != nil {
+			return report, err
+		}
+	}
+	for _, s := range stmts {
+		r := models[s.table]
+		r.Step = "schema"
+		if _, err := dbs.Exec(s.stmt); err == nil {
+			r.Executed++
+		} else if db.engine.IsDuplicateErr(err) {
+			// ignore duplicates errors to allow for databases not
+			// supporting "IF NOT EXISTS" in schema statments.
+			r.Skipped++
+		} else {
+			return fail(r, err)
+		}
+	}
+	for i, m := range ordered {
+		report.Models[i].Step = "post_schema"
+		if err := m.meta.Model.PostSchema(dbs, &m.meta); err != nil {
+			return fail(report.Models[i], err)
+		}
+	}
+
+	// add intial data to tables
+	if db.Log != nil {
+		db.Log.Debug("adding models initial data")
+	}
+	for i, m := range ordered {
+		report.Models[i].Step = "initial_data"
+		err := m.meta.Model.InitialData(dbs, m.meta.Table)
+		if err != nil {
+			return fail(report.Models[i], err)
+		}
+	}
+
+	if report.Transactional {
+		if err := dbs.Commit(); err != nil {
+			return report, err
+		}
+	}
+	if db.Log != nil {
+		for _, r := range report.Models {
+			db.Log.Debug("model %s", r)
+		}
+	}
+	return report, nil
+}
+
+// SchemaSQL returns the models schema statments of backend, as created
+// by InitializeModels, without database connection. the backend engine
+// package must be imported to register its SQL generator. the models
+// PreSchema and PostSchema hooks and the initial data are not included.
+func SchemaSQL(backend string, metainfo []ModelMeta) ([]string, error) {
+	g, err := NewSqlGenerator(backend)
+	if err != nil {
+		return nil, err
+	}
+	list, err := models_schema(g, models_order(metainfo), nil)
+	if err != nil {
+		return nil, err
+	}
+	stmts := []string{}
+	for _, s := range list {
+		stmts = append(stmts, s.stmt)
+	}
+	return stmts, nil
+}
+
+// WriteSchemaSQL writes the models schema script of backend to w, as
+// returned by SchemaSQL, for review before running InitializeModels.
+// the statments are separated by empty lines, and followed by the GO
+// batch separator for mssql backend.
+func WriteSchemaSQL(w io.Writer, backend string, metainfo []ModelMeta) error {
+	stmts, err := SchemaSQL(backend, metainfo)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		s := stmt + "\n"
+		if backend == "mssql" {
+			s += "GO\n"
+		}
+		if _, err := io.WriteString(w, s+"\n"); err != nil {
 			return err
 		}
 	}
-	for _, meta := range metainfo {
+	return nil
+}
+
+// model schema statment
+type model_stmt struct {
+	table string
+	stmt  string
+}
+
+// returns the schema statments of ordered models, where the deferred
+// foreign keys statments come after creating all tables.
+func models_schema(g SqlGenerator, ordered []model_order,
+	log *logging.Logger) ([]model_stmt, error) {
+	stmts, deferred := []model_stmt{}, []model_stmt{}
+	for _, m := range ordered {
+		tmeta := m.meta.Model.TableMeta()
+		if tmeta.View != nil && tmeta.View.Query != nil &&
+			tmeta.View.Query.err != nil {
+			return nil, fmt.Errorf("%w - view %s, %v",
+				ErrOperation, m.meta.Table, tmeta.View.Query.err)
+		}
+		if len(m.deferred) > 0 {
+			t := *tmeta
+			t.ForeignKeys = nil
+			for i, fk := range tmeta.ForeignKeys {
+				fk_stmts := g.AddForeignKey(m.meta.Table, &fk)
+				if !m.deferred[i] || len(fk_stmts) == 0 {
+					t.ForeignKeys = append(t.ForeignKeys, fk)
+					continue
+				}
+				if log != nil {
+					log.Debug("circular reference, deferring foreign key %s",
+						ForeignKeyName(m.meta.Table, &fk, 0))
+				}
+				for _, stmt := range fk_stmts {
+					deferred = append(deferred, model_stmt{m.meta.Table, stmt})
+				}
+			}
+			tmeta = &t
+		}
+		for _, stmt := range g.Schema(m.meta.Table, tmeta) {
+			stmts = append(stmts, model_stmt{m.meta.Table, stmt})
+		}
+	}
+	return append(stmts, deferred...), nil
+}
+
+// OrderModels returns the models in dependency order, where the models
+// referenced by foreign keys come before the referencing models and the
+// view models come after all tables.
+func OrderModels(metainfo []ModelMeta) []ModelMeta {
+	result := []ModelMeta{}
+	for _, m := range models_order(metainfo) {
+		result = append(result, m.meta)
+	}
+	return result
+}
+
+// DropModels drops the database models schema if exists. the models
+// are dropped in reverse dependency order, where models referenced by
+// other models foreign keys are dropped after them.
+func DropModels(db *Database, metainfo []ModelMeta) error {
+	if db == nil {
+		return ErrDBHandler
+	}
+
+	// create new session
+	dbs := db.Session()
+
+	if db.Log != nil {
+		db.Log.Debug("dropping models schema")
+	}
+	ordered := models_order(metainfo)
+	for i := len(ordered) - 1; i >= 0; i-- {
+		meta := ordered[i].meta
 		stmts := db.engine.SqlGenerator().
-			Schema(meta.Table, meta.Model.TableMeta())
+			DropSchema(meta.Table, meta.Model.TableMeta())
 		for _, stmt := range stmts {
-			// ignore duplicates errors during to allow for databases
-			// not supporting "IF NOT EXISTS" in tables and index creation.
-			if _, err := dbs.Exec(stmt); err != nil && !is_duplicates(err) {
+			_, err := dbs.Exec(stmt)
+			if err != nil && !db.engine.IsMissingTableErr(err) {
 				return err
 			}
 		}
 	}
+	return nil
+}
+
+// ResetModels drops and recreates the database models schema with the
+// models initial data. it is intended for tests setup on server
+// backends where the database can not be simply removed.
+func ResetModels(db *Database, metainfo []ModelMeta) error {
+	if err := DropModels(db, metainfo); err != nil {
+		return err
+	}
+	return InitializeModels(db, metainfo)
+}
+
+// model entry in models dependency order
+type model_order struct {
+	meta ModelMeta
+	// the indexes of table foreign keys referencing models created after
+	// this model, which form circular references.
+	deferred map[int]bool
+}
+
+// returns the models sorted in dependency order, where the models
+// referenced by foreign keys come before the referencing models, and
+// the view models come after all table models.
+// circular references are broken at the first model in original order,
+// where its foreign keys referencing the remaining models are deferred.
+// the raw constraints references can not be deferred.
+func models_order(metainfo []ModelMeta) []model_order {
+	tables := map[string]bool{}
 	for _, meta := range metainfo {
-		if err := meta.Model.PostSchema(dbs, &meta); err != nil {
-			return err
+		tables[strings.ToLower(meta.Table)] = true
+	}
+	views := map[string]bool{}
+	for _, meta := range metainfo {
+		if tmeta := meta.Model.TableMeta(); tmeta != nil && tmeta.View != nil {
+			views[strings.ToLower(meta.Table)] = true
 		}
 	}
+	is_dep := func(meta ModelMeta, ref string) bool {
+		ref = strings.ToLower(ref)
+		return tables[ref] && ref != strings.ToLower(meta.Table)
+	}
 
-	// add intial data to tables
-	if db.Log != nil {
-		db.Log.Debug("adding models initial data")
+	// the referenced tables of each model
+	deps := make([]map[string]bool, len(metainfo))
+	for i, meta := range metainfo {
+		deps[i] = map[string]bool{}
+		tmeta := meta.Model.TableMeta()
+		if tmeta == nil {
+			continue
+		}
+		if tmeta.View != nil {
+			for t := range tables {
+				if !views[t] {
+					deps[i][t] = true
+				}
+			}
+			continue
+		}
+		for _, c := range tmeta.Constraints {
+			for _, m := range referencesRegex.FindAllStringSubmatch(
+				c.Definition, -1) {
+				if is_dep(meta, m[1]) {
+					deps[i][strings.ToLower(m[1])] = true
+				}
+			}
+		}
+		for _, fk := range tmeta.ForeignKeys {
+			if is_dep(meta, fk.RefTable) {
+				deps[i][strings.ToLower(fk.RefTable)] = true
+			}
+		}
 	}
-	for _, meta := range metainfo {
-		if err := meta.Model.InitialData(dbs, meta.Table); err != nil {
-			return err
+
+	result := []model_order{}
+	done := map[string]bool{}
+	added := make([]bool, len(metainfo))
+	add := func(i int) {
+		result = append(result, model_order{meta: metainfo[i]})
+		done[strings.ToLower(metainfo[i].Table)] = true
+		added[i] = true
+	}
+	for len(result) < len(metainfo) {
+		progress := false
+		for i := range metainfo {
+			if added[i] {
+				continue
+			}
+			ready := true
+			for ref := range deps[i] {
+				if !done[ref] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				add(i)
+				progress = true
+			}
+		}
+		if progress {
+			continue
 		}
+
+		// circular references, add first remaining model in a cycle and
+		// defer its foreign keys referencing models not created yet
+		k := -1
+		for i := range metainfo {
+			if added[i] {
+				continue
+			}
+			if k < 0 {
+				k = i
+			}
+			if in_cycle(metainfo, deps, i) {
+				k = i
+				break
+			}
+		}
+		deferred := map[int]bool{}
+		if tmeta := metainfo[k].Model.TableMeta(); tmeta != nil {
+			for j, fk := range tmeta.ForeignKeys {
+				ref := strings.ToLower(fk.RefTable)
+				if deps[k][ref] && !done[ref] {
+					deferred[j] = true
+				}
+			}
+		}
+		add(k)
+		result[len(result)-1].deferred = deferred
 	}
+	return result
+}
 
+// checks weather the model at index i references itself through the
+// referenced models dependencies.
+func in_cycle(metainfo []ModelMeta, deps []map[string]bool, i int) bool {
+	index := map[string]int{}
+	for j, meta := range metainfo {
+		index[strings.ToLower(meta.Table)] = j
+	}
+	visited := map[int]bool{}
+	var visit func(j int) bool
+	visit = func(j int) bool {
+		for ref := range deps[j] {
+			k := index[ref]
+			if k == i {
+				return true
+			}
+			if !visited[k] {
+				visited[k] = true
+				if visit(k) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return visit(i)
+}
+
+// reports and applies the models schema differences
+func sync_models(db *Database, metainfo []ModelMeta) error {
+	diffs, err := DiffModels(db, metainfo)
+	if err != nil {
+		return err
+	}
+	for _, diff := range diffs {
+		// missing tables are created with models schema
+		if !diff.IsEmpty() && !diff.MissingTable && db.Log != nil {
+			db.Log.Warn("schema changes, %s", diff)
+		}
+	}
+	if db.SchemaSync == SchemaSyncApply {
+		return ApplyDiff(db, diffs)
+	}
 	return nil
 }
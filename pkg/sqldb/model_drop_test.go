@@ -0,0 +1,114 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb_test
+
+import (
+	"testing"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+func TestDropModels(t *testing.T) {
+	db := testDatabase(t)
+	dbs := db.Session()
+	metainfo := initModels(func(dbs *sqldb.Session, table string) error {
+		_, err := dbs.Exec("INSERT OR IGNORE INTO " + table +
+			" (id, parent_id) VALUES (1, 1);")
+		return err
+	})
+	if err := sqldb.InitializeModels(db, metainfo); err != nil {
+		t.Fatal(err)
+	}
+
+	// the referenced parent rows are dropped after the child table,
+	// otherwise the foreign key enforcement fails the parent drop
+	if err := sqldb.DropModels(db, metainfo); err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"parent", "child"} {
+		if tableExists(t, dbs, table) {
+			t.Errorf("table %s not dropped", table)
+		}
+	}
+
+	// the not existing tables are skipped
+	if err := sqldb.DropModels(db, metainfo); err != nil {
+		t.Errorf("drop missing tables error = %v", err)
+	}
+	if err := sqldb.InitializeModels(db, metainfo[1:]); err != nil {
+		t.Fatal(err)
+	}
+	if err := sqldb.DropModels(db, metainfo); err != nil {
+		t.Errorf("drop partial tables error = %v", err)
+	}
+	if tableExists(t, dbs, "parent") {
+		t.Errorf("table parent not dropped")
+	}
+}
+
+func TestResetModels(t *testing.T) {
+	db := testDatabase(t)
+	dbs := db.Session()
+	metainfo := initModels(nil)
+	if err := sqldb.InitializeModels(db, metainfo); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.Exec("INSERT INTO parent (id, name) VALUES (2, 'b');"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.Exec("INSERT INTO child (id, parent_id) VALUES (1, 2);"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the tables are recreated with the initial data only
+	if err := sqldb.ResetModels(db, metainfo); err != nil {
+		t.Fatal(err)
+	}
+	if n := testCount(t, dbs, "SELECT count(*) AS n FROM parent;"); n != 1 {
+		t.Errorf("parent rows = %d, want 1", n)
+	}
+	if n := testCount(t, dbs, "SELECT count(*) AS n FROM child;"); n != 0 {
+		t.Errorf("child rows = %d, want 0", n)
+	}
+}
+
+func TestTruncateSequence(t *testing.T) {
+	db := testDatabase(t)
+	dbs := db.Session()
+	tests := map[string]sqldb.ColumnMeta{
+		"seq_kind": {Name: "id", Kind: sqldb.KindInt, Primary: true,
+			AutoIncrement: true},
+		"seq_type": {Name: "id", Type: "INTEGER PRIMARY KEY AUTOINCREMENT"},
+	}
+	for table, column := range tests {
+		model := &testModel{meta: &sqldb.TableMeta{Columns: []sqldb.ColumnMeta{
+			column, {Name: "name", Kind: sqldb.KindText}}}}
+		if err := sqldb.InitializeModels(db, []sqldb.ModelMeta{
+			{Table: table, Model: model}}); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 3; i++ {
+			if _, err := dbs.Exec("INSERT INTO " + table +
+				" (name) VALUES ('x');"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := dbs.Query(model).TableName(table).Truncate(); err != nil {
+			t.Fatal(err)
+		}
+		if n := testCount(t, dbs, "SELECT count(*) AS n FROM sqlite_sequence "+
+			"WHERE name=?;", table); n != 0 {
+			t.Errorf("%s sequence not reset", table)
+		}
+		if _, err := dbs.Exec("INSERT INTO " + table +
+			" (name) VALUES ('y');"); err != nil {
+			t.Fatal(err)
+		}
+		if n := testCount(t, dbs, "SELECT max(id) AS n FROM "+table+";"); n != 1 {
+			t.Errorf("%s id after truncate = %d, want 1", table, n)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+// returns the parent and child models metainfo
+func initModels(childData func(*sqldb.Session, string) error) []sqldb.ModelMeta {
+	parent := &testModel{meta: &sqldb.TableMeta{Columns: []sqldb.ColumnMeta{
+		{Name: "id", Kind: sqldb.KindInt, Primary: true},
+		{Name: "name", Kind: sqldb.KindText, Index: true},
+	}}}
+	parent.initialData = func(dbs *sqldb.Session, table string) error {
+		_, err := dbs.Exec("INSERT OR IGNORE INTO " + table +
+			" (id, name) VALUES (1, 'a');")
+		return err
+	}
+	child := &testModel{
+		meta: &sqldb.TableMeta{
+			Columns: []sqldb.ColumnMeta{
+				{Name: "id", Kind: sqldb.KindInt, Primary: true},
+				{Name: "parent_id", Kind: sqldb.KindInt},
+			},
+			ForeignKeys: []sqldb.ForeignKeyMeta{{
+				Columns: []string{"parent_id"}, RefTable: "parent",
+				RefColumns: []string{"id"}}},
+		},
+		initialData: childData,
+	}
+	return []sqldb.ModelMeta{
+		{Table: "child", Model: child},
+		{Table: "parent", Model: parent},
+	}
+}
+
+func TestInitializeModelsReport(t *testing.T) {
+	db := testDatabase(t)
+	report, err := sqldb.InitializeModelsReport(db, initModels(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Transactional || report.RolledBack || len(report.Models) != 2 {
+		t.Fatalf("report %+v", report)
+	}
+	// the models are reported in dependency order
+	for i, table := range []string{"parent", "child"} {
+		r := report.Models[i]
+		if r.Table != table || r.Step != "initial_data" || r.Err != nil ||
+			r.Executed == 0 || r.Skipped != 0 {
+			t.Errorf("model report %+v", r)
+		}
+		if !strings.HasPrefix(r.String(), table+": initial_data done") {
+			t.Errorf("model report %q", r.String())
+		}
+	}
+
+	// the models are initialized again on existing tables
+	report, err = sqldb.InitializeModelsReport(db, initModels(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range report.Models {
+		if r.Step != "initial_data" || r.Err != nil {
+			t.Errorf("model report %+v", r)
+		}
+	}
+}
+
+// returns the report of model table
+func modelReport(report *sqldb.InitReport, table string) *sqldb.ModelReport {
+	for _, r := range report.Models {
+		if r.Table == table {
+			return r
+		}
+	}
+	return &sqldb.ModelReport{}
+}
+
+func TestInitializeModelsRollback(t *testing.T) {
+	db := testDatabase(t)
+	failed := errors.New("child data failed")
+	report, err := sqldb.InitializeModelsReport(db, initModels(
+		func(*sqldb.Session, string) error { return failed }))
+	if !errors.Is(err, failed) {
+		t.Fatalf("error = %v", err)
+	}
+	if !report.RolledBack {
+		t.Errorf("report not rolled back: %+v", report)
+	}
+	if r := modelReport(report, "child"); r.Step != "initial_data" ||
+		!errors.Is(r.Err, failed) {
+		t.Errorf("failed model report %+v", r)
+	}
+	if r := modelReport(report, "parent"); r.Err != nil {
+		t.Errorf("parent model report %+v", r)
+	}
+
+	// no tables or data of the half applied models set are left
+	dbs := db.Session()
+	for _, table := range []string{"parent", "child"} {
+		if tableExists(t, dbs, table) {
+			t.Errorf("table %s not rolled back", table)
+		}
+	}
+
+	// failed schema statments roll back the created tables
+	metainfo := initModels(nil)
+	bad := &testModel{meta: &sqldb.TableMeta{Columns: []sqldb.ColumnMeta{
+		{Name: "id", Type: "INTEGER CHECK (", Primary: true}}}}
+	metainfo = append(metainfo, sqldb.ModelMeta{Table: "bad", Model: bad})
+	report, err = sqldb.InitializeModelsReport(db, metainfo)
+	if err == nil || !report.RolledBack {
+		t.Fatalf("bad schema error = %v, report %+v", err, report)
+	}
+	if r := modelReport(report, "bad"); r.Step != "schema" || r.Err == nil {
+		t.Errorf("failed model report %+v", r)
+	}
+	if tableExists(t, dbs, "parent") {
+		t.Errorf("table parent not rolled back")
+	}
+}
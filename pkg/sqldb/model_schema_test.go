@@ -0,0 +1,39 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+func TestWriteSchemaSQL(t *testing.T) {
+	var buff strings.Builder
+	err := sqldb.WriteSchemaSQL(&buff, "sqlite", []sqldb.ModelMeta{{
+		Table: "t",
+		Model: &testModel{meta: &sqldb.TableMeta{Columns: []sqldb.ColumnMeta{
+			{Name: "id", Type: "INTEGER", Primary: true},
+			{Name: "name", Type: "TEXT", Index: true},
+		}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := buff.String()
+	if !strings.HasPrefix(script, "CREATE TABLE IF NOT EXISTS") ||
+		!strings.Contains(script, "CREATE INDEX") ||
+		!strings.HasSuffix(script, ";\n\n") {
+		t.Errorf("schema script:\n%s", script)
+	}
+
+	// the backend generator must be registered
+	err = sqldb.WriteSchemaSQL(&buff, "nosuchbackend", nil)
+	if !errors.Is(err, sqldb.ErrNotSupported) {
+		t.Errorf("error = %v, want ErrNotSupported", err)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testModel struct {
+	BaseModel
+	meta *TableMeta
+}
+
+func (m *testModel) TableMeta() *TableMeta {
+	return m.meta
+}
+
+// creates the table model meta referencing tables by foreign keys
+func refModel(table string, refs ...string) ModelMeta {
+	meta := &TableMeta{Columns: []ColumnMeta{{Name: "id", Primary: true}}}
+	for _, r := range refs {
+		meta.ForeignKeys = append(meta.ForeignKeys, ForeignKeyMeta{
+			Columns: []string{r + "_id"}, RefTable: r,
+			RefColumns: []string{"id"}})
+	}
+	return ModelMeta{Table: table, Model: &testModel{meta: meta}}
+}
+
+func TestModelsOrder(t *testing.T) {
+	view := ModelMeta{Table: "v", Model: &testModel{meta: &TableMeta{
+		View: &ViewMeta{}}}}
+	tests := []struct {
+		name     string
+		models   []ModelMeta
+		order    string
+		deferred map[string][]int
+	}{
+		{"linear", []ModelMeta{
+			refModel("c", "b"), refModel("b", "a"), refModel("a")},
+			"a b c", nil},
+		{"self reference", []ModelMeta{
+			refModel("a", "a"), refModel("b", "a")},
+			"a b", nil},
+		{"views last", []ModelMeta{
+			view, refModel("b", "a"), refModel("a")},
+			"a b v", nil},
+		{"two tables cycle", []ModelMeta{
+			refModel("a", "b"), refModel("b", "a"), refModel("c", "a")},
+			"a b c", map[string][]int{"a": {0}}},
+		{"three tables cycle", []ModelMeta{
+			refModel("a", "b"), refModel("b", "c"), refModel("c", "a"),
+			refModel("d")},
+			"d a c b", map[string][]int{"a": {0}}},
+		{"dependent before cycle", []ModelMeta{
+			refModel("x", "a"), refModel("a", "c", "b"), refModel("b", "a"),
+			refModel("c")},
+			"c a x b", map[string][]int{"a": {1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := []string{}
+			deferred := map[string][]int{}
+			for _, m := range models_order(tt.models) {
+				names = append(names, m.meta.Table)
+				for i := range m.meta.Model.TableMeta().ForeignKeys {
+					if m.deferred[i] {
+						deferred[m.meta.Table] = append(
+							deferred[m.meta.Table], i)
+					}
+				}
+			}
+			if order := strings.Join(names, " "); order != tt.order {
+				t.Errorf("order %q, want %q", order, tt.order)
+			}
+			if len(deferred) > 0 || len(tt.deferred) > 0 {
+				if !reflect.DeepEqual(deferred, tt.deferred) {
+					t.Errorf("deferred %v, want %v", deferred, tt.deferred)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,349 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// valueKind represents the standard Go type class of column values.
+type valueKind int
+
+const (
+	valueAny valueKind = iota
+	valueString
+	valueInt
+	valueFloat
+	valueDecimal
+	valueBool
+	valueTime
+	valueBytes
+	valueUUID
+)
+
+// time layouts used to parse time values returned as text by drivers
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// NormalizeValue converts a value fetched from database into the standard
+// Go type for the SQL column type, so the same column type is returned
+// with the same Go type on all backends. the standard types are:
+//   - string: for text, JSON and UUID types
+//   - int64: for integer types
+//   - float64: for floating point types
+//   - string: for decimal and numeric types, to keep precision
+//   - bool: for boolean types, including BIT(1) and TINYINT(1)
+//   - time.Time: for date, datetime and timestamp types, where integer
+//     values of any size are converted as unix timestamp seconds in UTC
+//   - []byte: for binary types
+//
+// values are returned as is when the column type is unknown or the
+// value can not be converted.
+func NormalizeValue(sqltype string, v any) any {
+	return normalize_value(sql_value_kind(sqltype), v)
+}
+
+// normalizes the row values using the column kinds
+func normalize_row(kinds []valueKind, row []any) {
+	for i, k := range kinds {
+		if k != valueAny {
+			row[i] = normalize_value(k, row[i])
+		}
+	}
+}
+
+// returns the columns value kinds from the table meta column types,
+// falling back to the driver reported database types.
+func column_kinds(meta *TableMeta, names []string,
+	types []*sql.ColumnType) []valueKind {
+	kinds := make([]valueKind, len(names))
+	for i, name := range names {
+		if meta != nil {
+			for _, c := range meta.Columns {
+				if c.Name == name {
+					kinds[i] = column_value_kind(&c)
+					break
+				}
+			}
+		}
+		if kinds[i] == valueAny && i < len(types) && types[i] != nil {
+			kinds[i] = sql_value_kind(types[i].DatabaseTypeName())
+		}
+	}
+	return kinds
+}
+
+// returns the value kind for SQL type name or column definition
+func sql_value_kind(sqltype string) valueKind {
+	s := strings.ToUpper(strings.TrimSpace(sqltype))
+	if s == "" {
+		return valueAny
+	}
+	if strings.HasPrefix(s, "TINYINT(1)") {
+		return valueBool
+	}
+	// BIT types are boolean only for single bit
+	if s == "BIT" || strings.HasPrefix(s, "BIT(1)") ||
+		(strings.HasPrefix(s, "BIT ") && !strings.HasPrefix(s, "BIT VARYING")) {
+		return valueBool
+	}
+	if strings.HasPrefix(s, "DOUBLE PRECISION") {
+		return valueFloat
+	}
+	s = strings.TrimPrefix(s, "UNSIGNED ")
+
+	// get base type name
+	if i := strings.IndexAny(s, " ("); i > 0 {
+		s = s[:i]
+	}
+
+	switch s {
+	case "BOOL", "BOOLEAN":
+		return valueBool
+	case "INT", "INTEGER", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT",
+		"INT2", "INT4", "INT8", "SERIAL", "SMALLSERIAL", "BIGSERIAL",
+		"YEAR":
+		return valueInt
+	case "REAL", "FLOAT", "FLOAT4", "FLOAT8", "DOUBLE":
+		return valueFloat
+	case "DECIMAL", "NUMERIC", "MONEY", "SMALLMONEY":
+		return valueDecimal
+	case "DATE", "DATETIME", "DATETIME2", "SMALLDATETIME",
+		"DATETIMEOFFSET", "TIMESTAMP", "TIMESTAMPTZ":
+		return valueTime
+	case "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY",
+		"VARBINARY", "BYTEA", "IMAGE":
+		return valueBytes
+	case "UNIQUEIDENTIFIER":
+		return valueUUID
+	case "CHAR", "VARCHAR", "TEXT", "TINYTEXT", "MEDIUMTEXT", "LONGTEXT",
+		"NCHAR", "NVARCHAR", "NTEXT", "CLOB", "CHARACTER", "BPCHAR",
+		"UUID", "JSON", "JSONB", "XML", "ENUM", "SET", "TIME", "INTERVAL",
+		"CITEXT", "STRING":
+		return valueString
+	}
+	return valueAny
+}
+
+// converts value into the standard Go type of value kind
+func normalize_value(kind valueKind, v any) any {
+	if v == nil {
+		return nil
+	}
+
+	var err error
+	var res any
+	switch kind {
+	case valueString:
+		res, err = to_string(v)
+	case valueInt:
+		res, err = to_int64(v)
+	case valueFloat:
+		res, err = to_float64(v)
+	case valueDecimal:
+		res, err = to_decimal(v)
+	case valueBool:
+		res, err = to_bool(v)
+	case valueTime:
+		res, err = to_time(v)
+	case valueBytes:
+		res, err = to_bytes(v)
+	case valueUUID:
+		res, err = to_uuid(v)
+	default:
+		return v
+	}
+	if err != nil {
+		return v
+	}
+	return res
+}
+
+////////////////////////////////////////////////////
+
+func to_string(v any) (string, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case []byte:
+		return string(t), nil
+	case time.Time:
+		return t.Format(time.RFC3339Nano), nil
+	case int64, int32, int16, int8, int, uint64, uint32, uint16, uint8,
+		uint, float64, float32, bool:
+		return fmt.Sprint(t), nil
+	}
+	return "", fmt.Errorf("cannot convert %T to string", v)
+}
+
+func to_int64(v any) (int64, error) {
+	switch t := v.(type) {
+	case int64:
+		return t, nil
+	case int:
+		return int64(t), nil
+	case int32:
+		return int64(t), nil
+	case int16:
+		return int64(t), nil
+	case int8:
+		return int64(t), nil
+	case uint64:
+		if t > math.MaxInt64 {
+			return 0, fmt.Errorf("value %d overflows int64", t)
+		}
+		return int64(t), nil
+	case uint32:
+		return int64(t), nil
+	case uint16:
+		return int64(t), nil
+	case uint8:
+		return int64(t), nil
+	case uint:
+		return int64(t), nil
+	case float64:
+		if math.IsNaN(t) || math.IsInf(t, 0) || t != math.Trunc(t) {
+			return 0, fmt.Errorf("cannot convert %v to int64", t)
+		}
+		if t >= 1<<63 || t < -(1<<63) {
+			return 0, fmt.Errorf("value %v overflows int64", t)
+		}
+		return int64(t), nil
+	case float32:
+		return to_int64(float64(t))
+	case bool:
+		if t {
+			return 1, nil
+		}
+		return 0, nil
+	case []byte:
+		return to_int64(string(t))
+	case string:
+		return strconv.ParseInt(strings.TrimSpace(t), 10, 64)
+	}
+	return 0, fmt.Errorf("cannot convert %T to int64", v)
+}
+
+func to_float64(v any) (float64, error) {
+	switch t := v.(type) {
+	case float64:
+		return t, nil
+	case float32:
+		return float64(t), nil
+	case []byte:
+		return to_float64(string(t))
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(t), 64)
+	case bool:
+		return 0, fmt.Errorf("cannot convert %T to float64", v)
+	}
+	if n, err := to_int64(v); err == nil {
+		return float64(n), nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to float64", v)
+}
+
+func to_decimal(v any) (string, error) {
+	switch t := v.(type) {
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), nil
+	case float32:
+		return strconv.FormatFloat(float64(t), 'f', -1, 32), nil
+	case []byte:
+		return strings.TrimSpace(string(t)), nil
+	case string:
+		return strings.TrimSpace(t), nil
+	}
+	if n, err := to_int64(v); err == nil {
+		return strconv.FormatInt(n, 10), nil
+	}
+	return "", fmt.Errorf("cannot convert %T to decimal", v)
+}
+
+func to_bool(v any) (bool, error) {
+	switch t := v.(type) {
+	case bool:
+		return t, nil
+	case []byte:
+		// BIT values returned as raw bytes
+		if len(t) == 1 && t[0] <= 1 {
+			return t[0] == 1, nil
+		}
+		return to_bool(string(t))
+	case string:
+		switch strings.ToLower(strings.TrimSpace(t)) {
+		case "1", "t", "true", "y", "yes", "on":
+			return true, nil
+		case "0", "f", "false", "n", "no", "off":
+			return false, nil
+		}
+		return false, fmt.Errorf("cannot convert '%s' to bool", t)
+	}
+	if n, err := to_int64(v); err == nil {
+		return n != 0, nil
+	}
+	return false, fmt.Errorf("cannot convert %T to bool", v)
+}
+
+// converts time text or integer unix timestamp seconds to time
+func to_time(v any) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case []byte:
+		return to_time(string(t))
+	case string:
+		s := strings.TrimSpace(t)
+		for _, layout := range timeLayouts {
+			if res, err := time.Parse(layout, s); err == nil {
+				return res, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("cannot convert '%s' to time", t)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		n, err := to_int64(t)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(n, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("cannot convert %T to time", v)
+}
+
+func to_bytes(v any) ([]byte, error) {
+	switch t := v.(type) {
+	case []byte:
+		return t, nil
+	case string:
+		return []byte(t), nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to bytes", v)
+}
+
+// converts mssql UNIQUEIDENTIFIER raw bytes into canonical uuid string
+func to_uuid(v any) (string, error) {
+	b, ok := v.([]byte)
+	if !ok || len(b) != 16 {
+		return to_string(v)
+	}
+	// first 3 groups are stored in little-endian order
+	return fmt.Sprintf("%X-%X-%X-%X-%X",
+		[]byte{b[3], b[2], b[1], b[0]}, []byte{b[5], b[4]},
+		[]byte{b[7], b[6]}, b[8:10], b[10:16]), nil
+}
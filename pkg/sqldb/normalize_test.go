@@ -0,0 +1,104 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNormalizeTime(t *testing.T) {
+	want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, v := range []any{
+		want.Unix(), int(want.Unix()), int32(want.Unix()),
+		uint32(want.Unix()), uint64(want.Unix()),
+		"2024-01-02T03:04:05Z", []byte("2024-01-02 03:04:05"),
+	} {
+		got, ok := NormalizeValue("TIMESTAMP", v).(time.Time)
+		if !ok || !got.Equal(want) {
+			t.Errorf("NormalizeValue(%T %v) = %v, want %v", v, v, got, want)
+		}
+	}
+
+	// other values are returned as is
+	if got := NormalizeValue("DATETIME", 1.5); got != 1.5 {
+		t.Errorf("NormalizeValue(1.5) = %v", got)
+	}
+}
+
+func TestSqlValueKind(t *testing.T) {
+	tests := []struct {
+		sqltype string
+		kind    valueKind
+	}{
+		{"BOOLEAN", valueBool},
+		{"TINYINT(1)", valueBool},
+		{"BIT", valueBool},
+		{"bit(1)", valueBool},
+		{"BIT NOT NULL DEFAULT 0", valueBool},
+		{"BIT(8)", valueAny},
+		{"BIT VARYING", valueAny},
+		{"BIT VARYING(4)", valueAny},
+		{"TINYINT(4)", valueInt},
+		{"UNSIGNED BIGINT", valueInt},
+		{"DOUBLE PRECISION", valueFloat},
+		{"NUMERIC(10,2)", valueDecimal},
+		{"VARCHAR(32) NOT NULL", valueString},
+		{"BYTEA", valueBytes},
+		{"UNIQUEIDENTIFIER", valueUUID},
+		{"GEOMETRY", valueAny},
+		{"", valueAny},
+	}
+	for _, tt := range tests {
+		if kind := sql_value_kind(tt.sqltype); kind != tt.kind {
+			t.Errorf("sql_value_kind(%q) = %v, want %v", tt.sqltype, kind, tt.kind)
+		}
+	}
+}
+
+func TestNormalizeBool(t *testing.T) {
+	for _, v := range []any{true, int64(1), []byte{1}, []byte("1"), "true"} {
+		if got := NormalizeValue("BIT", v); got != true {
+			t.Errorf("NormalizeValue(BIT, %T %v) = %v", v, v, got)
+		}
+	}
+	// multi bit values are returned as is
+	v := []byte{0x0f}
+	if got, ok := NormalizeValue("BIT(8)", v).([]byte); !ok || got[0] != 0x0f {
+		t.Errorf("NormalizeValue(BIT(8)) = %v", got)
+	}
+}
+
+func TestToInt64(t *testing.T) {
+	tests := []struct {
+		value any
+		want  int64
+	}{
+		{int64(-5), -5},
+		{uint32(7), 7},
+		{3.0, 3},
+		{float32(-2), -2},
+		{-9223372036854775808.0, math.MinInt64},
+		{" 42 ", 42},
+		{[]byte("12"), 12},
+		{true, 1},
+	}
+	for _, tt := range tests {
+		if n, err := to_int64(tt.value); err != nil || n != tt.want {
+			t.Errorf("to_int64(%T %v) = %d, %v, want %d",
+				tt.value, tt.value, n, err, tt.want)
+		}
+	}
+	for _, v := range []any{
+		1.5, math.NaN(), math.Inf(1), math.Inf(-1),
+		9223372036854775808.0, -9223372036854777856.0, 1e300,
+		uint64(math.MaxUint64), "1.5", "x", time.Time{},
+	} {
+		if n, err := to_int64(v); err == nil {
+			t.Errorf("to_int64(%T %v) = %d, want error", v, v, n)
+		}
+	}
+}
@@ -21,6 +21,8 @@ type Query struct {
 	model Model
 	// SQL statment attributes
 	attrs StmtAttrs
+	// deferred query build error
+	err error
 }
 
 // NewQuery creates a new query object
@@ -74,18 +76,85 @@ func (q *Query) Filters(expr string, args ...any) *Query {
 	return q
 }
 
-// FilterBy adds AND related filter to the statment.
+// FilterBy adds AND related filter to the statment. for columns with
+// index codecs, the filter is applied on the codec index value.
 func (q *Query) FilterBy(column string, value any) *Query {
 	if column != "" {
+		column, value = q.filter_index(column, value)
 		if q.attrs.Filters != "" {
 			q.attrs.Filters += " AND "
 		}
-		q.attrs.Filters = fmt.Sprintf("%s=%s", column, SQL_PLACEHOLDER)
+		q.attrs.Filters += fmt.Sprintf("%s=%s", column, SQL_PLACEHOLDER)
 		q.attrs.FiltersArgs = append(q.attrs.FiltersArgs, value)
 	}
 	return q
 }
 
+// Where adds AND related filter conditions to the statment, rendered
+// using the session backend SQL dialect.
+func (q *Query) Where(conds ...Condition) *Query {
+	if q.dbs == nil {
+		q.err = ErrDBSession
+		return q
+	}
+	if err := q.dbs.check_run(); err != nil {
+		q.err = err
+		return q
+	}
+	g := q.dbs.db.engine.SqlGenerator()
+	for _, c := range conds {
+		expr, args, err := c.Expr(g)
+		if err != nil {
+			q.err = fmt.Errorf("%w - filter condition, %v", ErrOperation, err)
+			return q
+		}
+		if q.attrs.Filters != "" {
+			q.attrs.Filters += " AND "
+		}
+		q.attrs.Filters += expr
+		q.attrs.FiltersArgs = append(q.attrs.FiltersArgs, args...)
+	}
+	return q
+}
+
+// Search adds AND related full-text search filter to the statment,
+// matching all words of text. if no columns are given, the model
+// full-text index columns are used. empty search text is ignored.
+func (q *Query) Search(columns []string, text string) *Query {
+	if len(SearchTerms(text)) == 0 {
+		return q
+	}
+	if q.dbs == nil {
+		q.err = ErrDBSession
+		return q
+	}
+	if err := q.dbs.check_run(); err != nil {
+		q.err = err
+		return q
+	}
+
+	var meta *TableMeta
+	if q.model != nil {
+		meta = q.model.TableMeta()
+	}
+	if len(columns) == 0 && meta != nil && meta.FullText != nil {
+		columns = meta.FullText.Columns
+	}
+	if len(columns) == 0 {
+		q.err = fmt.Errorf("%w - no full-text search columns", ErrOperation)
+		return q
+	}
+
+	expr, args := q.dbs.db.engine.SqlGenerator().FullTextMatch(
+		q.attrs.Tablename, meta, columns, text)
+	if q.attrs.Filters != "" {
+		q.attrs.Filters += " AND "
+	}
+	q.attrs.Filters += expr
+	q.attrs.FiltersArgs = append(q.attrs.FiltersArgs, args...)
+	return q
+}
+
 // GroupBy adds grouping expresion to the statment.
 func (q *Query) GroupBy(columns ...string) *Query {
 	q.attrs.Groupby = columns
@@ -118,8 +187,43 @@ func (q *Query) Limit(limit int) *Query {
 	return q
 }
 
+// filter_index returns the filter column and value for columns
+// with index codecs.
+func (q *Query) filter_index(column string, value any) (string, any) {
+	if q.model == nil {
+		return column, value
+	}
+	meta := q.model.TableMeta()
+	if meta == nil {
+		return column, value
+	}
+	for _, c := range meta.Columns {
+		if c.Name != column {
+			continue
+		}
+		if idx, ok := c.Codec.(IndexCodec); ok {
+			col, res, err := idx.Index(column, value)
+			if err != nil {
+				q.err = fmt.Errorf(
+					"%w - filter column '%s', %v", ErrOperation, column, err)
+			} else if col == "" {
+				q.err = fmt.Errorf(
+					"%w - filter column '%s' not supported by codec",
+					ErrOperation, column)
+			} else {
+				return col, res
+			}
+		}
+		break
+	}
+	return column, value
+}
+
 // check attrs before running query
 func (q *Query) check_run() error {
+	if q.err != nil {
+		return q.err
+	}
 	if q.attrs.Tablename == "" {
 		return fmt.Errorf("%w - empty table name", ErrOperation)
 	}
@@ -129,22 +233,86 @@ func (q *Query) check_run() error {
 	return q.dbs.check_run()
 }
 
-// All returns all data entries matching defined filters.
+// check attrs before running write query, where the view models are
+// read-only.
+func (q *Query) check_write() error {
+	if err := q.check_run(); err != nil {
+		return err
+	}
+	if q.model != nil {
+		if meta := q.model.TableMeta(); meta != nil && meta.View != nil {
+			return fmt.Errorf("%w - %s", ErrReadOnly, q.attrs.Tablename)
+		}
+	}
+	return nil
+}
+
+// filtered_attrs returns the statment attrs after applying the session
+// default filters.
+func (q *Query) filtered_attrs() *StmtAttrs {
+	if len(q.dbs.filters) == 0 {
+		return &q.attrs
+	}
+
+	attrs := q.attrs
+	exprs, args := []string{}, []any{}
+	if attrs.Filters != "" {
+		exprs = append(exprs, "("+attrs.Filters+")")
+		args = append(args, attrs.FiltersArgs...)
+	}
+	for _, f := range q.dbs.filters {
+		exprs = append(exprs,
+			fmt.Sprintf("%s=%s", f.column, SQL_PLACEHOLDER))
+		args = append(args, f.value)
+	}
+	attrs.Filters = strings.Join(exprs, " AND ")
+	attrs.FiltersArgs = args
+
+	return &attrs
+}
+
+// set_filtered_data sets the session default filters values in data.
+func (q *Query) set_filtered_data(data Data) {
+	for _, f := range q.dbs.filters {
+		data[f.column] = f.value
+	}
+}
+
+// encode applies the model encoding then the columns codecs on data.
+func (q *Query) encode(data []Data) error {
+	if err := q.model.DataEncode(data); err != nil {
+		return err
+	}
+	return EncodeData(q.model.TableMeta(), data)
+}
+
+// decode applies the columns codecs then the model decoding on data.
+func (q *Query) decode(data []Data) error {
+	if err := DecodeData(q.model.TableMeta(), data); err != nil {
+		return err
+	}
+	return q.model.DataDecode(data)
+}
+
+// All returns all data entries matching defined filters. the fetched
+// values are normalized into the same Go types on all backends, based on
+// the model columns types, before applying decoding.
 func (q *Query) All() ([]Data, error) {
 	if err := q.check_run(); err != nil {
 		return nil, err
 	}
 
 	// generate and run query
-	stmt, params := q.dbs.db.engine.SqlGenerator().Select(&q.attrs)
-	result, err := q.dbs.Fetch(stmt, params...)
+	stmt, params := q.dbs.db.engine.SqlGenerator().Select(
+		q.filtered_attrs())
+	result, err := q.dbs.fetch(q.model.TableMeta(), stmt, params...)
 	if err != nil {
 		return nil, err
 	}
 
 	// apply decoding on result data
 	if len(result) > 0 {
-		if err := q.model.DataDecode(result); err != nil {
+		if err := q.decode(result); err != nil {
 			return nil, fmt.Errorf(
 				"%w - decoding data failed, %v", ErrOperation, err)
 		}
@@ -189,7 +357,8 @@ func (q *Query) Count() (int, error) {
 	}
 
 	// generate and run query
-	stmt, params := q.dbs.db.engine.SqlGenerator().Count(&q.attrs)
+	stmt, params := q.dbs.db.engine.SqlGenerator().Count(
+		q.filtered_attrs())
 	result, err := q.dbs.Fetch(stmt, params...)
 	if err != nil {
 		return 0, err
@@ -214,12 +383,12 @@ func (q *Query) Insert(data Data) (string, error) {
 	if data == nil {
 		return "", fmt.Errorf("%w - empty insert data", ErrOperation)
 	}
-	if err := q.check_run(); err != nil {
+	if err := q.check_write(); err != nil {
 		return "", err
 	}
 
 	// apply encoding on insert data
-	if err := q.model.DataEncode([]Data{data}); err != nil {
+	if err := q.encode([]Data{data}); err != nil {
 		return "", fmt.Errorf(
 			"%w - encoding data error, %v", ErrOperation, err)
 	}
@@ -230,10 +399,11 @@ func (q *Query) Insert(data Data) (string, error) {
 		guid = NewGuid()
 		dictx.Set(data, "guid", guid)
 	}
+	q.set_filtered_data(data)
 
 	// generate and run query
 	stmt, params := q.dbs.db.engine.SqlGenerator().Insert(&q.attrs, data)
-	_, err := q.dbs.Exec(stmt, params...)
+	_, err := q.dbs.exec(stmt, params...)
 	if err != nil {
 		return "", err
 	}
@@ -241,25 +411,93 @@ func (q *Query) Insert(data Data) (string, error) {
 	return guid, nil
 }
 
+// InsertMany inserts many data entries in order and returns the guids of
+// new entries. the entries are inserted all or none, within the session
+// transaction if started, otherwise within a new transaction.
+// If Model AutoGuid is enabled, a new guid value is generated for the
+// entries having empty or no guid value.
+func (q *Query) InsertMany(data []Data) ([]string, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w - empty insert data", ErrOperation)
+	}
+	for _, d := range data {
+		if d == nil {
+			return nil, fmt.Errorf("%w - empty insert data", ErrOperation)
+		}
+	}
+	if err := q.check_write(); err != nil {
+		return nil, err
+	}
+
+	// apply encoding on insert data
+	if err := q.encode(data); err != nil {
+		return nil, fmt.Errorf(
+			"%w - encoding data error, %v", ErrOperation, err)
+	}
+
+	if q.dbs.InTransaction() {
+		return q.insert_many(data)
+	}
+	if err := q.dbs.Begin(); err != nil {
+		return nil, err
+	}
+	guids, err := q.insert_many(data)
+	if err != nil {
+		q.dbs.RollBack()
+		return nil, err
+	}
+	if err := q.dbs.Commit(); err != nil {
+		return nil, err
+	}
+	return guids, nil
+}
+
+// inserts the encoded data entries and returns the guids of new entries
+func (q *Query) insert_many(data []Data) ([]string, error) {
+	g := q.dbs.db.engine.SqlGenerator()
+	guids := make([]string, 0, len(data))
+	for _, d := range data {
+		// check and create guid in data
+		guid := dictx.Fetch(d, "guid", "")
+		if q.model.IsAutoGuid() && guid == "" {
+			guid = NewGuid()
+			dictx.Set(d, "guid", guid)
+		}
+		q.set_filtered_data(d)
+
+		// generate and run query
+		stmt, params := g.Insert(&q.attrs, d)
+		if _, err := q.dbs.exec(stmt, params...); err != nil {
+			return guids, err
+		}
+		guids = append(guids, guid)
+	}
+
+	return guids, nil
+}
+
 // Updates data entries matching defined filters and returns the number
 // of affected entries.
 func (q *Query) Update(data Data) (int, error) {
 	if data == nil {
 		return 0, fmt.Errorf("%w - empty update data", ErrOperation)
 	}
-	if err := q.check_run(); err != nil {
+	if err := q.check_write(); err != nil {
 		return 0, err
 	}
 
 	// apply encoding on update data
-	if err := q.model.DataEncode([]Data{data}); err != nil {
+	if err := q.encode([]Data{data}); err != nil {
 		return 0, fmt.Errorf(
 			"%w - encoding data error, %v", ErrOperation, err)
 	}
 
+	q.set_filtered_data(data)
+
 	// generate and run query
-	stmt, params := q.dbs.db.engine.SqlGenerator().Update(&q.attrs, data)
-	return q.dbs.Exec(stmt, params...)
+	stmt, params := q.dbs.db.engine.SqlGenerator().Update(
+		q.filtered_attrs(), data)
+	return q.dbs.exec(stmt, params...)
 }
 
 // UpdateGuid updates only one element by guid.
@@ -271,13 +509,14 @@ func (q *Query) UpdateGuid(guid string, data Data) error {
 // Deletes data entries matching defined filters and returns the number
 // of affected entries.
 func (q *Query) Delete() (int, error) {
-	if err := q.check_run(); err != nil {
+	if err := q.check_write(); err != nil {
 		return 0, err
 	}
 
 	// generate and run query
-	stmt, params := q.dbs.db.engine.SqlGenerator().Delete(&q.attrs)
-	return q.dbs.Exec(stmt, params...)
+	stmt, params := q.dbs.db.engine.SqlGenerator().Delete(
+		q.filtered_attrs())
+	return q.dbs.exec(stmt, params...)
 }
 
 // DeleteGuid deletes only one element by guid.
@@ -286,6 +525,55 @@ func (q *Query) DeleteGuid(guid string) error {
 	return err
 }
 
+// Truncate deletes all table entries and resets the table identity
+// sequences. it is not allowed with query filters or session default
+// filters, where Delete should be used instead.
+func (q *Query) Truncate() error {
+	if err := q.check_write(); err != nil {
+		return err
+	}
+	if q.attrs.Filters != "" || len(q.dbs.filters) > 0 {
+		return fmt.Errorf(
+			"%w - truncate not allowed with filters", ErrOperation)
+	}
+
+	// generate and run query
+	stmts := q.dbs.db.engine.SqlGenerator().Truncate(
+		q.attrs.Tablename, q.model.TableMeta())
+	for _, stmt := range stmts {
+		if _, err := q.dbs.exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RefreshView refreshes the materialized view data of view model. it
+// does nothing for plain views and for backends without materialized
+// views, where the view is created as plain view.
+func (q *Query) RefreshView() error {
+	if err := q.check_run(); err != nil {
+		return err
+	}
+	var meta *TableMeta
+	if q.model != nil {
+		meta = q.model.TableMeta()
+	}
+	if meta == nil || meta.View == nil {
+		return fmt.Errorf("%w - not a view model", ErrOperation)
+	}
+
+	// generate and run query
+	stmts := q.dbs.db.engine.SqlGenerator().RefreshView(
+		q.attrs.Tablename, meta)
+	for _, stmt := range stmts {
+		if _, err := q.dbs.exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 ////////////////////////////////////////////////////
 
 // NewGuid generates a new string guid in hex format.
@@ -0,0 +1,86 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+// creates the items test table in database
+func itemsModel(t *testing.T, db *sqldb.Database) *testModel {
+	t.Helper()
+	model := &testModel{meta: &sqldb.TableMeta{
+		Columns: []sqldb.ColumnMeta{
+			{Name: "id", Kind: sqldb.KindInt, Primary: true},
+			{Name: "name", Kind: sqldb.KindText},
+		},
+	}}
+	model.DefaultTable = "items"
+	err := sqldb.InitializeModels(db, []sqldb.ModelMeta{
+		{Table: "items", Model: model}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return model
+}
+
+func TestInsertMany(t *testing.T) {
+	db := testDatabase(t)
+	dbs := db.Session()
+	model := itemsModel(t, db)
+
+	// the entries are inserted all or none without session transaction
+	_, err := dbs.Query(model).InsertMany([]sqldb.Data{
+		{"id": 1, "name": "a"}, {"id": 2, "name": "b"}, {"id": 1, "name": "c"},
+	})
+	if err == nil {
+		t.Fatal("duplicate key inserted")
+	}
+	if dbs.InTransaction() {
+		t.Errorf("session left in transaction")
+	}
+	if n := testCount(t, dbs, "SELECT count(*) AS n FROM items;"); n != 0 {
+		t.Errorf("rows after failed insert = %d, want 0", n)
+	}
+
+	guids, err := dbs.Query(model).InsertMany([]sqldb.Data{
+		{"id": 1, "name": "a"}, {"id": 2, "name": "b"},
+	})
+	if err != nil || len(guids) != 2 {
+		t.Fatalf("inserted %v, %v", guids, err)
+	}
+	if n := testCount(t, dbs, "SELECT count(*) AS n FROM items;"); n != 2 {
+		t.Errorf("rows = %d, want 2", n)
+	}
+
+	// the session transaction is used if started
+	if err := dbs.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.Query(model).InsertMany([]sqldb.Data{
+		{"id": 3, "name": "c"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !dbs.InTransaction() {
+		t.Fatal("session transaction ended")
+	}
+	if err := dbs.RollBack(); err != nil {
+		t.Fatal(err)
+	}
+	if n := testCount(t, dbs, "SELECT count(*) AS n FROM items;"); n != 2 {
+		t.Errorf("rows after rollback = %d, want 2", n)
+	}
+}
+
+func TestRefreshViewNoModel(t *testing.T) {
+	dbs := testDatabase(t).Session()
+	err := dbs.Query(nil).TableName("v").RefreshView()
+	if !errors.Is(err, sqldb.ErrOperation) {
+		t.Errorf("error = %v, want ErrOperation", err)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Row represents a table data row with typed values accessors.
+// data rows can be converted directly using Row(data).
+//
+// The accessors apply lenient conversions between the different drivers
+// values representations, and return descriptive errors for missing
+// columns, null values or not convertible values.
+type Row map[string]any
+
+// IsNull returns true if the column value is null or column not exist.
+func (r Row) IsNull(column string) bool {
+	return r[column] == nil
+}
+
+// returns the column value or error if missing or null
+func (r Row) value(column string) (any, error) {
+	v, ok := r[column]
+	if !ok {
+		return nil, fmt.Errorf("%w - column '%s' not found", ErrValue, column)
+	}
+	if v == nil {
+		return nil, fmt.Errorf("%w - column '%s' is null", ErrValue, column)
+	}
+	return v, nil
+}
+
+// String returns the column value as string.
+func (r Row) String(column string) (string, error) {
+	v, err := r.value(column)
+	if err != nil {
+		return "", err
+	}
+	res, err := to_string(v)
+	if err != nil {
+		return "", fmt.Errorf("%w - column '%s', %v", ErrValue, column, err)
+	}
+	return res, nil
+}
+
+// Int64 returns the column value as int64.
+func (r Row) Int64(column string) (int64, error) {
+	v, err := r.value(column)
+	if err != nil {
+		return 0, err
+	}
+	res, err := to_int64(v)
+	if err != nil {
+		return 0, fmt.Errorf("%w - column '%s', %v", ErrValue, column, err)
+	}
+	return res, nil
+}
+
+// Float64 returns the column value as float64.
+func (r Row) Float64(column string) (float64, error) {
+	v, err := r.value(column)
+	if err != nil {
+		return 0, err
+	}
+	res, err := to_float64(v)
+	if err != nil {
+		return 0, fmt.Errorf("%w - column '%s', %v", ErrValue, column, err)
+	}
+	return res, nil
+}
+
+// Bool returns the column value as bool.
+func (r Row) Bool(column string) (bool, error) {
+	v, err := r.value(column)
+	if err != nil {
+		return false, err
+	}
+	res, err := to_bool(v)
+	if err != nil {
+		return false, fmt.Errorf(
+			"%w - column '%s', %v", ErrValue, column, err)
+	}
+	return res, nil
+}
+
+// Time returns the column value as time. integer values of any size
+// are converted as unix timestamp seconds in UTC.
+func (r Row) Time(column string) (time.Time, error) {
+	v, err := r.value(column)
+	if err != nil {
+		return time.Time{}, err
+	}
+	res, err := to_time(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(
+			"%w - column '%s', %v", ErrValue, column, err)
+	}
+	return res, nil
+}
+
+// Bytes returns the column value as bytes.
+func (r Row) Bytes(column string) ([]byte, error) {
+	v, err := r.value(column)
+	if err != nil {
+		return nil, err
+	}
+	res, err := to_bytes(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w - column '%s', %v", ErrValue, column, err)
+	}
+	return res, nil
+}
+
+// JSON decodes the column JSON value into dst. already decoded values
+// are converted into dst type.
+func (r Row) JSON(column string, dst any) error {
+	v, err := r.value(column)
+	if err != nil {
+		return err
+	}
+
+	var b []byte
+	switch t := v.(type) {
+	case string:
+		b = []byte(t)
+	case []byte:
+		b = t
+	default:
+		if b, err = json.Marshal(t); err != nil {
+			return fmt.Errorf(
+				"%w - column '%s', %v", ErrValue, column, err)
+		}
+	}
+	if err := json.Unmarshal(b, dst); err != nil {
+		return fmt.Errorf("%w - column '%s', %v", ErrValue, column, err)
+	}
+	return nil
+}
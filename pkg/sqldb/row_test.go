@@ -0,0 +1,135 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRowAccessors(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 10, 20, 30, 0, time.UTC)
+	row := Row{
+		"text":      "abc",
+		"text_num":  " 42 ",
+		"bytes_num": []byte("7"),
+		"int":       int64(5),
+		"int32":     int32(-3),
+		"uint8":     uint8(1),
+		"float":     2.5,
+		"float_int": 3.0,
+		"bool":      true,
+		"bool_text": "yes",
+		"bit":       []byte{1},
+		"time":      ts,
+		"time_text": "2024-05-01 10:20:30",
+		"unix":      ts.Unix(),
+		"unix_int8": int8(9),
+		"json":      `{"a":[1,2]}`,
+		"json_map":  map[string]any{"a": []any{1.0, 2.0}},
+		"null":      nil,
+	}
+
+	for column, want := range map[string]string{
+		"text": "abc", "int": "5", "float": "2.5", "bool": "true",
+		"time": "2024-05-01T10:20:30Z", "bytes_num": "7",
+	} {
+		if v, err := row.String(column); err != nil || v != want {
+			t.Errorf("String(%s) = %q, %v, want %q", column, v, err, want)
+		}
+	}
+	for column, want := range map[string]int64{
+		"text_num": 42, "bytes_num": 7, "int": 5, "int32": -3, "uint8": 1,
+		"float_int": 3, "bool": 1,
+	} {
+		if v, err := row.Int64(column); err != nil || v != want {
+			t.Errorf("Int64(%s) = %d, %v, want %d", column, v, err, want)
+		}
+	}
+	for column, want := range map[string]float64{
+		"float": 2.5, "int": 5, "text_num": 42,
+	} {
+		if v, err := row.Float64(column); err != nil || v != want {
+			t.Errorf("Float64(%s) = %v, %v, want %v", column, v, err, want)
+		}
+	}
+	for column, want := range map[string]bool{
+		"bool": true, "bool_text": true, "bit": true, "int": true,
+	} {
+		if v, err := row.Bool(column); err != nil || v != want {
+			t.Errorf("Bool(%s) = %v, %v, want %v", column, v, err, want)
+		}
+	}
+	for _, column := range []string{"time", "time_text", "unix"} {
+		if v, err := row.Time(column); err != nil || !v.Equal(ts) {
+			t.Errorf("Time(%s) = %v, %v, want %v", column, v, err, ts)
+		}
+	}
+	if v, err := row.Time("unix_int8"); err != nil || v.Unix() != 9 {
+		t.Errorf("Time(unix_int8) = %v, %v", v, err)
+	}
+	if v, err := row.Bytes("text"); err != nil || string(v) != "abc" {
+		t.Errorf("Bytes(text) = %v, %v", v, err)
+	}
+	for _, column := range []string{"json", "json_map"} {
+		var dst struct{ A []int }
+		if err := row.JSON(column, &dst); err != nil ||
+			!reflect.DeepEqual(dst.A, []int{1, 2}) {
+			t.Errorf("JSON(%s) = %v, %v", column, dst, err)
+		}
+	}
+
+	// not convertible values
+	if _, err := row.Int64("text"); !errors.Is(err, ErrValue) {
+		t.Errorf("Int64(text) error = %v", err)
+	}
+	if _, err := row.Int64("float"); !errors.Is(err, ErrValue) {
+		t.Errorf("Int64(float) error = %v", err)
+	}
+	if _, err := row.Bool("text_num"); !errors.Is(err, ErrValue) {
+		t.Errorf("Bool(text_num) error = %v", err)
+	}
+	if _, err := row.Time("float"); !errors.Is(err, ErrValue) {
+		t.Errorf("Time(float) error = %v", err)
+	}
+	if _, err := row.Bytes("int"); !errors.Is(err, ErrValue) {
+		t.Errorf("Bytes(int) error = %v", err)
+	}
+	if err := row.JSON("text", &map[string]any{}); !errors.Is(err, ErrValue) {
+		t.Errorf("JSON(text) error = %v", err)
+	}
+}
+
+func TestRowMissingNull(t *testing.T) {
+	row := Row{"null": nil, "text": ""}
+	if !row.IsNull("null") || !row.IsNull("missing") || row.IsNull("text") {
+		t.Errorf("IsNull() of null, missing and empty values")
+	}
+	getters := map[string]func(string) error{
+		"String":  func(c string) error { _, err := row.String(c); return err },
+		"Int64":   func(c string) error { _, err := row.Int64(c); return err },
+		"Float64": func(c string) error { _, err := row.Float64(c); return err },
+		"Bool":    func(c string) error { _, err := row.Bool(c); return err },
+		"Time":    func(c string) error { _, err := row.Time(c); return err },
+		"Bytes":   func(c string) error { _, err := row.Bytes(c); return err },
+		"JSON":    func(c string) error { return row.JSON(c, &[]any{}) },
+	}
+	for name, get := range getters {
+		for column, msg := range map[string]string{
+			"null":    "column 'null' is null",
+			"missing": "column 'missing' not found",
+		} {
+			err := get(column)
+			if !errors.Is(err, ErrValue) {
+				t.Errorf("%s(%s) error = %v", name, column, err)
+			} else if !strings.Contains(err.Error(), msg) {
+				t.Errorf("%s(%s) error = %v, want %q", name, column, err, msg)
+			}
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/exonlabs/go-utils/pkg/events"
@@ -21,6 +22,8 @@ type Session struct {
 	// sql driver and transactioin handlers
 	sdb *sql.DB
 	stx *sql.Tx
+	// the transaction driver handler is the engine writer handler
+	swriter bool
 
 	// break event and context-cancel
 	breakEvent *events.Event
@@ -33,6 +36,18 @@ type Session struct {
 	// trials are done untill operation is done or timeout is reached.
 	// retry interval value must be > 0. (default 0.1 sec)
 	RetryInterval float64
+
+	// mandatory filters applied on all session queries
+	filters []sessionFilter
+	// AllowRawExec allows running raw Exec statments when session default
+	// filters are defined, raw statments bypass the filters. (default false)
+	AllowRawExec bool
+}
+
+// sessionFilter represents a mandatory column filter for session queries.
+type sessionFilter struct {
+	column string
+	value  any
 }
 
 // NewSession creates new database session.
@@ -66,6 +81,31 @@ func (s *Session) Ping() bool {
 	return s.db.Ping()
 }
 
+// DefaultFilter adds a mandatory column filter for all session queries.
+// the filter is injected into every query select, update and delete, and
+// the column value is set on every insert. raw Exec statments are blocked
+// unless AllowRawExec is set.
+func (s *Session) DefaultFilter(column string, value any) *Session {
+	column = strings.TrimSpace(column)
+	if column == "" {
+		return s
+	}
+	for i := range s.filters {
+		if s.filters[i].column == column {
+			s.filters[i].value = value
+			return s
+		}
+	}
+	s.filters = append(s.filters, sessionFilter{column: column, value: value})
+	return s
+}
+
+// WithTenant sets the tenant column and value to isolate session queries
+// to the tenant rows only.
+func (s *Session) WithTenant(column string, value any) *Session {
+	return s.DefaultFilter(column, value)
+}
+
 // Cancel breaks all active session operation.
 func (s *Session) Cancel() {
 	s.breakEvent.Set()
@@ -82,6 +122,11 @@ func (s *Session) check_run() error {
 	return s.db.check_run()
 }
 
+// InTransaction checks weather the session is in transactional scope.
+func (s *Session) InTransaction() bool {
+	return s.sdb != nil && s.stx != nil
+}
+
 // Begin starts a new transactional scope.
 func (s *Session) Begin() error {
 	// already in transaction
@@ -93,20 +138,30 @@ func (s *Session) Begin() error {
 		return err
 	}
 
-	if sdb, err := s.db.engine.SqlDB(); err != nil {
-		return fmt.Errorf("%w - %v", ErrOpen, err)
+	var ctx context.Context
+	s.breakEvent.Clear()
+	if s.OperationTimeout > 0 {
+		ctx, s.ctxBreak = context.WithDeadline(s.db.ctx, time.Now().Add(
+			time.Duration(s.OperationTimeout*float64(time.Second))))
 	} else {
-		s.sdb = sdb
+		ctx, s.ctxBreak = context.WithCancel(s.db.ctx)
+	}
+	defer s.ctxBreak()
+
+	sdb, writer, err := s.acquire(ctx)
+	if err != nil {
+		return err
 	}
 
 	if s.db.Log != nil {
 		s.db.Log.Trace("begin new transaction")
 	}
-	if stx, err := s.sdb.Begin(); err != nil {
+	stx, err := sdb.Begin()
+	if err != nil {
+		s.release(sdb, writer)
 		return fmt.Errorf("%w - %v", ErrOperation, err)
-	} else {
-		s.stx = stx
 	}
+	s.sdb, s.stx, s.swriter = sdb, stx, writer
 	return nil
 }
 
@@ -122,8 +177,8 @@ func (s *Session) Commit() error {
 	}
 
 	defer func() {
-		s.db.engine.Release(s.sdb)
-		s.sdb, s.stx = nil, nil
+		s.release(s.sdb, s.swriter)
+		s.sdb, s.stx, s.swriter = nil, nil, false
 	}()
 
 	if s.db.Log != nil {
@@ -147,8 +202,8 @@ func (s *Session) RollBack() error {
 	}
 
 	defer func() {
-		s.db.engine.Release(s.sdb)
-		s.sdb, s.stx = nil, nil
+		s.release(s.sdb, s.swriter)
+		s.sdb, s.stx, s.swriter = nil, nil, false
 	}()
 
 	if s.db.Log != nil {
@@ -160,9 +215,59 @@ func (s *Session) RollBack() error {
 	return nil
 }
 
+// acquire returns the driver handler for write operations, waiting for
+// the engine writer handler if the engine serializes the writes. it
+// returns whether the handler is the writer handler.
+func (s *Session) acquire(ctx context.Context) (*sql.DB, bool, error) {
+	if we, ok := s.db.engine.(WriterEngine); ok {
+		if s.db.Log != nil && WriterWaitWarning > 0 {
+			warn := time.AfterFunc(WriterWaitWarning, func() {
+				s.db.Log.Warn("writer queue wait exceeds %v, possible "+
+					"write in another session within transaction, %s",
+					WriterWaitWarning, we.WriterStats())
+			})
+			defer warn.Stop()
+		}
+		sdb, err := we.WriterDB(ctx)
+		if err == context.Canceled {
+			return nil, false, ErrBreak
+		} else if err == context.DeadlineExceeded {
+			return nil, false, fmt.Errorf("%w - writer queue wait", ErrTimeout)
+		} else if err != nil {
+			return nil, false, fmt.Errorf("%w - %v", ErrOpen, err)
+		} else if sdb != nil {
+			return sdb, true, nil
+		}
+	}
+	sdb, err := s.db.engine.SqlDB()
+	if err != nil {
+		return nil, false, fmt.Errorf("%w - %v", ErrOpen, err)
+	}
+	return sdb, false, nil
+}
+
+// release frees the driver handler acquired for write operations.
+func (s *Session) release(sdb *sql.DB, writer bool) {
+	if writer {
+		s.db.engine.(WriterEngine).ReleaseWriter(sdb)
+	} else {
+		s.db.engine.Release(sdb)
+	}
+}
+
 // Exec runs a query without returning any rows. it takes the statment
 // to run and the args are for any placeholder parameters in the query.
+// raw statments are not allowed when session default filters are defined
+// unless AllowRawExec is set.
 func (s *Session) Exec(stmt string, params ...any) (int, error) {
+	if len(s.filters) > 0 && !s.AllowRawExec {
+		return 0, ErrRawExec
+	}
+	return s.exec(stmt, params...)
+}
+
+// exec runs the statment without checking session default filters.
+func (s *Session) exec(stmt string, params ...any) (int, error) {
 	if err := s.check_run(); err != nil {
 		return 0, err
 	}
@@ -184,14 +289,6 @@ func (s *Session) Exec(stmt string, params ...any) (int, error) {
 	var ctx context.Context
 	var res sql.Result
 
-	// not in transaction
-	if s.sdb == nil || s.stx == nil {
-		if sdb, err = s.db.engine.SqlDB(); err != nil {
-			return 0, fmt.Errorf("%w - %v", ErrOpen, err)
-		}
-		defer s.db.engine.Release(sdb)
-	}
-
 	s.breakEvent.Clear()
 	if s.OperationTimeout > 0 {
 		ctx, s.ctxBreak = context.WithDeadline(s.db.ctx, time.Now().Add(
@@ -201,6 +298,15 @@ func (s *Session) Exec(stmt string, params ...any) (int, error) {
 	}
 	defer s.ctxBreak()
 
+	// not in transaction
+	if s.sdb == nil || s.stx == nil {
+		var writer bool
+		if sdb, writer, err = s.acquire(ctx); err != nil {
+			return 0, err
+		}
+		defer s.release(sdb, writer)
+	}
+
 	var lastErr error
 	for {
 		if s.sdb != nil && s.stx != nil {
@@ -224,12 +330,21 @@ func (s *Session) Exec(stmt string, params ...any) (int, error) {
 		s.breakEvent.Wait(s.RetryInterval)
 	}
 
-	return 0, fmt.Errorf("%w - %v", ErrOperation, err)
+	return 0, s.operation_err(err)
 }
 
 // Fetch runs a query that returns rows. it takes the statment
 // to run and the args are for any placeholder parameters in the query.
+// the fetched values are returned as scanned by the backend driver.
 func (s *Session) Fetch(stmt string, params ...any) ([]Data, error) {
+	return s.fetch(nil, stmt, params...)
+}
+
+// fetch runs a query that returns rows. when table meta is defined, the
+// fetched values are normalized using the table columns types and the
+// driver reported columns types.
+func (s *Session) fetch(
+	meta *TableMeta, stmt string, params ...any) ([]Data, error) {
 	if err := s.check_run(); err != nil {
 		return nil, err
 	}
@@ -251,11 +366,15 @@ func (s *Session) Fetch(stmt string, params ...any) ([]Data, error) {
 	var ctx context.Context
 	var rows *sql.Rows
 	var colNames []string
+	var colKinds []valueKind
 
-	if sdb, err = s.db.engine.SqlDB(); err != nil {
-		return nil, fmt.Errorf("%w - %v", ErrOpen, err)
+	// not in transaction
+	if s.sdb == nil || s.stx == nil {
+		if sdb, err = s.db.engine.SqlDB(); err != nil {
+			return nil, fmt.Errorf("%w - %v", ErrOpen, err)
+		}
+		defer s.db.engine.Release(sdb)
 	}
-	defer s.db.engine.Release(sdb)
 
 	s.breakEvent.Clear()
 	if s.OperationTimeout > 0 {
@@ -268,13 +387,21 @@ func (s *Session) Fetch(stmt string, params ...any) ([]Data, error) {
 
 	var lastErr error
 	for {
-		rows, err = sdb.QueryContext(ctx, stmt, params...)
+		if s.sdb != nil && s.stx != nil {
+			rows, err = s.stx.QueryContext(ctx, stmt, params...)
+		} else {
+			rows, err = sdb.QueryContext(ctx, stmt, params...)
+		}
 		if err == nil {
 			defer rows.Close()
 			colNames, err = rows.Columns()
 			if err != nil {
 				return nil, fmt.Errorf("%w - %v", ErrOperation, err)
 			}
+			if meta != nil {
+				colTypes, _ := rows.ColumnTypes()
+				colKinds = column_kinds(meta, colNames, colTypes)
+			}
 			break
 		} else if err == context.Canceled {
 			return nil, ErrBreak
@@ -283,12 +410,36 @@ func (s *Session) Fetch(stmt string, params ...any) ([]Data, error) {
 		} else {
 			lastErr = err
 			if !s.db.engine.CanRetryErr(err) {
-				return nil, fmt.Errorf("%w - %v", ErrOperation, err)
+				return nil, s.operation_err(err)
 			}
 		}
 		s.breakEvent.Wait(s.RetryInterval)
 	}
 
+	result, err := scan_rows(rows, colKinds)
+	if err != nil {
+		return nil, fmt.Errorf("%w - %v", ErrOperation, err)
+	}
+	return result, nil
+}
+
+// returns the operation error, classified as ErrNotSupported for the
+// database features not available in the backend build.
+func (s *Session) operation_err(err error) error {
+	if c, ok := s.db.engine.(NotSupportedChecker); ok && c.IsNotSupportedErr(err) {
+		return fmt.Errorf("%w - %w", ErrNotSupported, err)
+	}
+	return fmt.Errorf("%w - %w", ErrOperation, err)
+}
+
+// scan_rows scans the rows of current result set into data entries. the
+// values are normalized when the columns kinds are defined.
+func scan_rows(rows *sql.Rows, colKinds []valueKind) ([]Data, error) {
+	colNames, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
 	result := []Data{}
 	lenCols := len(colNames)
 
@@ -301,7 +452,10 @@ func (s *Session) Fetch(stmt string, params ...any) ([]Data, error) {
 	}
 	for rows.Next() {
 		if err := rows.Scan(colsPtrs...); err != nil {
-			return nil, fmt.Errorf("%w - %v", ErrOperation, err)
+			return nil, err
+		}
+		if colKinds != nil {
+			normalize_row(colKinds, colsData)
 		}
 		rowData := Data{}
 		// retrieve value for each column from data slice,
@@ -311,5 +465,43 @@ func (s *Session) Fetch(stmt string, params ...any) ([]Data, error) {
 		result = append(result, rowData)
 	}
 
-	return result, nil
+	return result, rows.Err()
+}
+
+// column_types returns the table columns types as reported by driver.
+func (s *Session) column_types(tablename string) ([]*sql.ColumnType, error) {
+	if err := s.check_run(); err != nil {
+		return nil, err
+	}
+
+	stmt, _ := s.db.engine.SqlGenerator().Select(&StmtAttrs{
+		Tablename: tablename,
+		Filters:   "1=0",
+	})
+	if s.db.Log != nil {
+		s.db.Log.Trace("SQL: %s", stmt)
+	}
+
+	var err error
+	var rows *sql.Rows
+	if s.sdb != nil && s.stx != nil {
+		rows, err = s.stx.QueryContext(s.db.ctx, stmt)
+	} else {
+		var sdb *sql.DB
+		if sdb, err = s.db.engine.SqlDB(); err != nil {
+			return nil, fmt.Errorf("%w - %v", ErrOpen, err)
+		}
+		defer s.db.engine.Release(sdb)
+		rows, err = sdb.QueryContext(s.db.ctx, stmt)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w - %v", ErrOperation, err)
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("%w - %v", ErrOperation, err)
+	}
+	return types, nil
 }
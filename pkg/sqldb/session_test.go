@@ -0,0 +1,91 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+func TestWithTenant(t *testing.T) {
+	db := testDatabase(t)
+	model := &testModel{meta: &sqldb.TableMeta{
+		Columns: []sqldb.ColumnMeta{
+			{Name: "id", Kind: sqldb.KindInt, Primary: true},
+			{Name: "tenant_id", Kind: sqldb.KindInt},
+			{Name: "name", Kind: sqldb.KindText},
+		},
+	}}
+	model.DefaultTable = "items"
+	err := sqldb.InitializeModels(db, []sqldb.ModelMeta{
+		{Table: "items", Model: model}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Session().Exec("INSERT INTO items (id, tenant_id, name) " +
+		"VALUES (1, 1, 'a'), (2, 1, 'b'), (3, 2, 'a'), (4, 2, 'b');"); err != nil {
+		t.Fatal(err)
+	}
+	dbs := db.Session().WithTenant("tenant_id", 1)
+
+	// select and count are scoped to the tenant rows
+	rows, err := dbs.Query(model).OrderBy("id ASC").All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0]["id"] != int64(1) || rows[1]["id"] != int64(2) {
+		t.Errorf("tenant rows %v", rows)
+	}
+	if n, err := dbs.Query(model).FilterBy("name", "a").Count(); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Errorf("tenant count = %d, want 1", n)
+	}
+
+	// insert sets the tenant column
+	if _, err := dbs.Query(model).Insert(
+		sqldb.Data{"id": 5, "tenant_id": 2, "name": "c"}); err != nil {
+		t.Fatal(err)
+	}
+	all := db.Session()
+	if n := testCount(t, all, "SELECT count(*) AS n FROM items "+
+		"WHERE id=5 AND tenant_id=1;"); n != 1 {
+		t.Errorf("inserted row tenant not set")
+	}
+
+	// update and delete are scoped to the tenant rows
+	if n, err := dbs.Query(model).FilterBy("name", "a").Update(
+		sqldb.Data{"name": "x"}); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Errorf("tenant updated rows = %d, want 1", n)
+	}
+	if n, err := dbs.Query(model).Delete(); err != nil {
+		t.Fatal(err)
+	} else if n != 3 {
+		t.Errorf("tenant deleted rows = %d, want 3", n)
+	}
+	if n := testCount(t, all, "SELECT count(*) AS n FROM items "+
+		"WHERE tenant_id=2 AND name IN ('a', 'b');"); n != 2 {
+		t.Errorf("other tenant rows = %d, want 2", n)
+	}
+
+	// raw statments and truncate bypass the filters
+	if _, err := dbs.Exec("DELETE FROM items;"); !errors.Is(err, sqldb.ErrRawExec) {
+		t.Errorf("raw exec error = %v", err)
+	}
+	if err := dbs.Query(model).Truncate(); !errors.Is(err, sqldb.ErrOperation) {
+		t.Errorf("tenant truncate error = %v", err)
+	}
+	dbs.AllowRawExec = true
+	if _, err := dbs.Exec("DELETE FROM items WHERE id=3;"); err != nil {
+		t.Errorf("allowed raw exec error = %v", err)
+	}
+	if n := testCount(t, all, "SELECT count(*) AS n FROM items;"); n != 1 {
+		t.Errorf("rows = %d, want 1", n)
+	}
+}
@@ -5,9 +5,12 @@
 package sqldb
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/exonlabs/go-utils/pkg/abc/dictx"
 )
@@ -35,6 +38,44 @@ type StmtAttrs struct {
 	Limit       int
 }
 
+// Kind represents the portable column kinds, which are mapped into the
+// native column types of each backend.
+type Kind int
+
+const (
+	// KindRaw uses the column Type as defined in SQL syntax.
+	KindRaw Kind = iota
+	// KindJSON defines a JSON document column, which is mapped to
+	// JSONB (pgsql), JSON (mysql), TEXT with json_valid() check (sqlite)
+	// and NVARCHAR(MAX) with ISJSON() check (mssql).
+	KindJSON
+	// KindString defines a variable length string column of Size
+	// characters (default 255).
+	KindString
+	// KindText defines an unlimited length text column.
+	KindText
+	// KindInt defines a 32-bit integer column.
+	KindInt
+	// KindBigInt defines a 64-bit integer column.
+	KindBigInt
+	// KindBool defines a boolean column.
+	KindBool
+	// KindFloat defines a double precision floating point column.
+	KindFloat
+	// KindDecimal defines a fixed point decimal column with Precision
+	// and Scale digits, or the backend default precision if not set.
+	KindDecimal
+	// KindTime defines a date and time column.
+	KindTime
+	// KindDate defines a date column.
+	KindDate
+	// KindBlob defines a binary data column.
+	KindBlob
+	// KindUUID defines a UUID column, which is mapped to UUID (pgsql),
+	// UNIQUEIDENTIFIER (mssql) and CHAR(36) on other backends.
+	KindUUID
+)
+
 // ColumnMeta represents column definition.
 //
 // References:
@@ -45,13 +86,45 @@ type ColumnMeta struct {
 	Name string
 	// the column data type as defined in SQL syntax.
 	// ex. "VARCHAR(128) NOT NULL", "BOOLEAN NOT NULL DEFAULT false"
+	// for portable kinds, Type holds only the extra column modifiers
+	// if any. ex. "CHECK (col1>0)"
 	Type string
+	// the column portable kind, defaults to KindRaw.
+	Kind Kind
+	// the column size for KindString columns.
+	Size int
+	// the column precision and scale for KindDecimal columns.
+	Precision int
+	Scale     int
+	// set to disallow null values for portable kinds.
+	NotNull bool
+	// the column default value for portable kinds, which is rendered
+	// as SQL literal of the backend. use SqlExpr for SQL expressions.
+	// ex. 0, "value", true, SqlExpr("CURRENT_TIMESTAMP")
+	Default any
 	// set column primary key constraint.
 	Primary bool
 	// set column unique value constraint.
 	Unique bool
 	// set to create column index.
 	Index bool
+	// set for KindInt and KindBigInt primary column with database
+	// generated values, which is rendered as the backend auto increment
+	// or identity column.
+	AutoIncrement bool
+	// the column codec applied on values written to and read from
+	// database. leave nil to use values as is. KindJSON columns use
+	// JSONCodec by default. the codec is applied by the queries, after
+	// the model DataEncode and before the model DataDecode.
+	Codec Codec
+}
+
+// returns the effective column codec
+func (c *ColumnMeta) codec() Codec {
+	if c.Codec == nil && c.Kind == KindJSON {
+		return JSONCodec
+	}
+	return c.Codec
 }
 
 // ConstraintMeta represents constraint definitions.
@@ -70,6 +143,74 @@ type ConstraintMeta struct {
 	Definition string
 }
 
+// IndexMeta represents table index definition.
+type IndexMeta struct {
+	// the index name, should be unique per database. leave empty to
+	// generate the name from table and columns names.
+	Name string
+	// the index columns names in order, with optional sort order.
+	// ex. "col1", "col2 DESC"
+	Columns []string
+	// set for unique index.
+	Unique bool
+	// set for the table primary key index.
+	Primary bool
+	// the partial index predicate as defined in SQL syntax, for backends
+	// supporting partial indexes (sqlite, pgsql and mssql).
+	// ex. "deleted_at IS NULL"
+	Where string
+	// the non-key columns included in index, for backends supporting
+	// covering indexes (pgsql and mssql), and ignored otherwise.
+	Include []string
+}
+
+// ForeignKeyMeta represents table foreign key constraint definition.
+//
+// References:
+//   - https://www.w3schools.com/sql/sql_foreignkey.asp
+type ForeignKeyMeta struct {
+	// the constraint name, should be unique per database. leave empty to
+	// generate the name from table and columns names.
+	Name string
+	// the referencing columns names in order.
+	Columns []string
+	// the referenced table name.
+	RefTable string
+	// the referenced columns names in order, matching Columns.
+	RefColumns []string
+	// the referential actions on delete and update of referenced rows.
+	// ex. "CASCADE", "SET NULL", "SET DEFAULT", "RESTRICT", "NO ACTION"
+	// leave empty for the backend default action (NO ACTION).
+	OnDelete string
+	OnUpdate string
+}
+
+// ViewMeta represents the view definition of read-only models.
+//
+// References:
+//   - https://www.w3schools.com/sql/sql_view.asp
+type ViewMeta struct {
+	// the view SELECT statment as defined in SQL syntax.
+	// ex. "SELECT id, name FROM users WHERE active=TRUE"
+	Select string
+	// the view query used if Select is empty, created by NewQuery with nil
+	// session. the query filters args are inlined in view definition.
+	// ex. NewQuery(nil, Users).Columns("id", "name").Filters("active=?", true)
+	Query *Query
+	// set to create materialized view, for backends supporting it (pgsql).
+	// a plain view is created otherwise.
+	Materialized bool
+}
+
+// FullTextMeta represents the table full-text index definition.
+type FullTextMeta struct {
+	// the text columns included in full-text index.
+	Columns []string
+	// the text search language config for backends supporting it.
+	// ex. "english" (default "simple")
+	Language string
+}
+
 // TableMeta represents table definition, columns and constraints.
 //
 // References:
@@ -82,10 +223,23 @@ type TableMeta struct {
 	// Table Constraints as defined in SQL syntax. constraints are appended to
 	// table after auto generated columns constraints.
 	Constraints []ConstraintMeta
+	// Table Indexes, created after the columns indexes.
+	Indexes []IndexMeta
+	// Table Foreign keys, appended to table after the explicit table
+	// constraints. the referenced tables are created first when
+	// initializing models.
+	ForeignKeys []ForeignKeyMeta
 	// AutoGuid sets weather to enable AutoGuid operations, which is to
 	// create a first primary guid column for table.
 	// guid column is created with schema "guid VARCHAR(32) NOT NULL"
 	AutoGuid bool
+	// FullText defines the table full-text index, used for full-text
+	// search queries. leave nil to disable full-text index.
+	FullText *FullTextMeta
+	// View defines the read-only view definition, where the model is
+	// created as database view instead of table. the Columns are used
+	// only for the fetched values types.
+	View *ViewMeta
 	// Extra options for backends.
 	Args dictx.Dict
 }
@@ -108,8 +262,87 @@ type SqlGenerator interface {
 	// Delete generates a DELETE statment
 	Delete(attrs *StmtAttrs) (string, []any)
 
+	// ColumnType generates the column type definition
+	ColumnType(c *ColumnMeta) string
+	// JSONValue generates the expression extracting a JSON document value
+	// at path from column. path has the format "$.key1.key2[0]", and
+	// numeric sets to extract the value as number instead of text. error
+	// is returned for invalid path.
+	JSONValue(column, path string, numeric bool) (string, error)
+	// FullTextMatch generates the full-text search filter expression on
+	// columns of table, matching all words of text.
+	FullTextMatch(tablename string, meta *TableMeta,
+		columns []string, text string) (string, []any)
+
 	// Schema generates table schema statments from metainfo
 	Schema(tablename string, meta *TableMeta) []string
+	// Index generates the table index creation statment
+	Index(tablename string, meta *TableMeta, ix *IndexMeta) string
+	// ForeignKey generates the foreign key constraint definition used in
+	// table schema.
+	ForeignKey(tablename string, fk *ForeignKeyMeta) string
+	// AddForeignKey generates the statments adding foreign key constraint
+	// to existing table, or nil if not supported by backend.
+	AddForeignKey(tablename string, fk *ForeignKeyMeta) []string
+	// AlterSchema generates the statments adding the missing columns of
+	// table schema difference.
+	AlterSchema(diff *SchemaDiff) ([]string, error)
+	// DropSchema generates the statments dropping table schema if exists.
+	DropSchema(tablename string, meta *TableMeta) []string
+	// Truncate generates the statments deleting all table rows and
+	// resetting the table identity sequences.
+	Truncate(tablename string, meta *TableMeta) []string
+	// RefreshView generates the statments refreshing materialized view
+	// data, or nil if not needed.
+	RefreshView(tablename string, meta *TableMeta) []string
+	// ForeignKeyChecks generates the statments disabling or enabling the
+	// foreign key checks on writing tables within transaction, where the
+	// enabling statments run before the transaction commit.
+	ForeignKeyChecks(tablenames []string, enable bool) []string
+	// IdentityInsert generates the statments allowing or disallowing the
+	// explicit values inserts into table auto increment column, or nil
+	// if not needed.
+	IdentityInsert(tablename string, enable bool) []string
+	// ResetIdentity generates the statments resetting the table auto
+	// increment column sequence after the column max value, or nil if
+	// the sequence follows the inserted values.
+	ResetIdentity(tablename, column string) []string
+
+	// Call generates the stored procedure call statment followed by the
+	// statments fetching the output args values, and the call params.
+	// nil is returned if stored procedures are not supported.
+	Call(proc string, args []CallArg) ([]string, []any)
+}
+
+// registered backends SQL generators
+var (
+	generators   = map[string]func() SqlGenerator{}
+	muGenerators sync.Mutex
+)
+
+// RegisterGenerator registers the SQL statment generator of backend, used
+// to generate statments without database connection. it is called on
+// init by the backends engines packages. the first registration of
+// backend is kept, where the engines packages of same backend such as
+// the sqlite drivers register equivalent generators.
+func RegisterGenerator(backend string, fn func() SqlGenerator) {
+	muGenerators.Lock()
+	defer muGenerators.Unlock()
+	if _, ok := generators[backend]; !ok {
+		generators[backend] = fn
+	}
+}
+
+// NewSqlGenerator returns the SQL statment generator of backend, where
+// the backend engine package must be imported to register its generator.
+func NewSqlGenerator(backend string) (SqlGenerator, error) {
+	muGenerators.Lock()
+	defer muGenerators.Unlock()
+	if fn, ok := generators[backend]; ok {
+		return fn(), nil
+	}
+	return nil, fmt.Errorf(
+		"%w - backend %s generator not registered", ErrNotSupported, backend)
 }
 
 // StdSqlGenerator represents a standard SQL statment generator.
@@ -143,12 +376,12 @@ func (*StdSqlGenerator) Select(attrs *StmtAttrs) (string, []any) {
 	if len(attrs.Orderby) > 0 {
 		stmt += " ORDER BY " + strings.Join(attrs.Orderby, ", ")
 	}
-	if attrs.Offset > 0 {
-		stmt += fmt.Sprintf(" OFFSET %d", attrs.Offset)
-	}
 	if attrs.Limit > 0 {
 		stmt += fmt.Sprintf(" LIMIT %d", attrs.Limit)
 	}
+	if attrs.Offset > 0 {
+		stmt += fmt.Sprintf(" OFFSET %d", attrs.Offset)
+	}
 	stmt += ";"
 
 	// create the params for statment placeholders
@@ -227,10 +460,114 @@ func (*StdSqlGenerator) Delete(attrs *StmtAttrs) (string, []any) {
 	return stmt, attrs.FiltersArgs
 }
 
+// ColumnType generates the column type definition
+func (*StdSqlGenerator) ColumnType(c *ColumnMeta) string {
+	return StdTypes.ColumnType(c)
+}
+
+// JSONValue generates the expression extracting a JSON document value
+func (*StdSqlGenerator) JSONValue(
+	column, path string, numeric bool) (string, error) {
+	if _, err := JSONPathKeys(path); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("json_extract(%s, '%s')", column, path), nil
+}
+
+// FullTextMatch generates the full-text search filter expression. the
+// standard expression matches all words of text in any of columns using
+// LIKE patterns, for backends not supporting full-text search.
+func (*StdSqlGenerator) FullTextMatch(tablename string, meta *TableMeta,
+	columns []string, text string) (string, []any) {
+	exprs, params := []string{}, []any{}
+	for _, term := range SearchTerms(text) {
+		likes := []string{}
+		for _, c := range columns {
+			likes = append(likes, c+" LIKE "+SQL_PLACEHOLDER)
+			params = append(params, "%"+term+"%")
+		}
+		exprs = append(exprs, "("+strings.Join(likes, " OR ")+")")
+	}
+	return strings.Join(exprs, " AND "), params
+}
+
 // Schema generates table schema from table metainfo
-func (*StdSqlGenerator) Schema(tablename string, meta *TableMeta) []string {
+func (g *StdSqlGenerator) Schema(tablename string, meta *TableMeta) []string {
+	return GenerateSchema(g, tablename, meta)
+}
+
+// Index generates the table index creation statment
+func (*StdSqlGenerator) Index(tablename string, meta *TableMeta, ix *IndexMeta) string {
+	return GenerateIndex(tablename, meta, ix, 0)
+}
+
+// ForeignKey generates the foreign key constraint definition
+func (*StdSqlGenerator) ForeignKey(tablename string, fk *ForeignKeyMeta) string {
+	return GenerateForeignKey(tablename, fk, 0)
+}
+
+// AddForeignKey generates the statments adding foreign key constraint
+func (g *StdSqlGenerator) AddForeignKey(tablename string, fk *ForeignKeyMeta) []string {
+	return GenerateAddForeignKey(g, tablename, fk)
+}
+
+// AlterSchema generates the statments adding the missing columns
+func (g *StdSqlGenerator) AlterSchema(diff *SchemaDiff) ([]string, error) {
+	return GenerateAlterSchema(g, diff)
+}
+
+// DropSchema generates the statments dropping table schema if exists
+func (*StdSqlGenerator) DropSchema(tablename string, meta *TableMeta) []string {
+	if meta != nil && meta.View != nil {
+		return []string{fmt.Sprintf("DROP VIEW IF EXISTS %s;", tablename)}
+	}
+	return []string{fmt.Sprintf("DROP TABLE IF EXISTS %s;", tablename)}
+}
+
+// Truncate generates the statments deleting all table rows
+func (*StdSqlGenerator) Truncate(tablename string, meta *TableMeta) []string {
+	return []string{fmt.Sprintf("DELETE FROM %s;", tablename)}
+}
+
+// RefreshView returns nil as materialized views are not supported
+func (*StdSqlGenerator) RefreshView(tablename string, meta *TableMeta) []string {
+	return nil
+}
+
+// ForeignKeyChecks returns nil as disabling foreign key checks is not
+// supported.
+func (*StdSqlGenerator) ForeignKeyChecks(tablenames []string, enable bool) []string {
+	return nil
+}
+
+// IdentityInsert returns nil as explicit values inserts into auto
+// increment columns are allowed.
+func (*StdSqlGenerator) IdentityInsert(tablename string, enable bool) []string {
+	return nil
+}
+
+// ResetIdentity returns nil as auto increment sequences follow the
+// inserted values.
+func (*StdSqlGenerator) ResetIdentity(tablename, column string) []string {
+	return nil
+}
+
+// Call returns nil as stored procedures are not supported
+func (*StdSqlGenerator) Call(proc string, args []CallArg) ([]string, []any) {
+	return nil, nil
+}
+
+// GenerateSchema generates the standard table schema statments from
+// table metainfo, using the columns types definitions of generator g.
+// it is used by the backends generators extending the standard schema.
+func GenerateSchema(g SqlGenerator, tablename string, meta *TableMeta) []string {
 	var buff, constraints, indexes []string
 
+	if meta.View != nil {
+		return []string{fmt.Sprintf("CREATE VIEW IF NOT EXISTS %s AS\n%s;",
+			tablename, ViewSelect(g, meta.View, StdTypes))}
+	}
+
 	// if AutoGuid, add guid column if not exist as first column
 	if meta.AutoGuid && meta.Columns[0].Name != "guid" {
 		meta.Columns = append([]ColumnMeta{
@@ -242,27 +579,27 @@ func (*StdSqlGenerator) Schema(tablename string, meta *TableMeta) []string {
 	if dictx.Fetch(meta.Args, "disable_table_exists", false) {
 		table_exists = ""
 	}
-	index_exists := " IF NOT EXISTS"
-	if dictx.Fetch(meta.Args, "disable_index_exists", false) {
-		index_exists = ""
-	}
 
 	// loop and parse columns meta
 	for _, c := range meta.Columns {
-		buff = append(buff, fmt.Sprintf("%s %s", c.Name, c.Type))
+		col_type := g.ColumnType(&c)
+		buff = append(buff, fmt.Sprintf("%s %s", c.Name, col_type))
 
-		// add constraints and indexes
+		// add constraints and indexes, where the primary key constraint is
+		// omitted for the column types declaring it, as sqlite autoincrement
+		// columns.
 		if c.Primary {
-			constraints = append(constraints,
-				fmt.Sprintf("PRIMARY KEY (%s)", c.Name))
+			if !primaryKeyRegex.MatchString(col_type) {
+				constraints = append(constraints,
+					fmt.Sprintf("PRIMARY KEY (%s)", c.Name))
+			}
 		} else if c.Unique {
 			constraints = append(constraints,
 				fmt.Sprintf("UNIQUE (%s)", c.Name))
 		}
 		if c.Primary || c.Index {
-			indexes = append(indexes, fmt.Sprintf(
-				"CREATE INDEX%s ix_%s_%s ON %s (%s);",
-				index_exists, tablename, c.Name, tablename, c.Name))
+			indexes = append(indexes, g.Index(tablename, meta,
+				&IndexMeta{Columns: []string{c.Name}}))
 		}
 	}
 
@@ -279,6 +616,16 @@ func (*StdSqlGenerator) Schema(tablename string, meta *TableMeta) []string {
 		}
 	}
 
+	// add foreign keys constraints
+	for _, fk := range meta.ForeignKeys {
+		buff = append(buff, g.ForeignKey(tablename, &fk))
+	}
+
+	// add explicit table indexes
+	for _, ix := range meta.Indexes {
+		indexes = append(indexes, g.Index(tablename, meta, &ix))
+	}
+
 	stmt := fmt.Sprintf(
 		"CREATE TABLE%s %s (\n  %s\n);",
 		table_exists, tablename, strings.Join(buff, ",\n  "))
@@ -286,8 +633,138 @@ func (*StdSqlGenerator) Schema(tablename string, meta *TableMeta) []string {
 	return append([]string{stmt}, indexes...)
 }
 
+// ViewSelect returns the view SELECT statment, where the view query
+// args are inlined as SQL literals of types map. the query orders are
+// dropped if no limit or offset is set, as views rows are not ordered.
+func ViewSelect(g SqlGenerator, view *ViewMeta, types *TypeMap) string {
+	if view.Select != "" || view.Query == nil {
+		return strings.TrimSuffix(strings.TrimSpace(view.Select), ";")
+	}
+	attrs := view.Query.attrs
+	if attrs.Limit <= 0 && attrs.Offset <= 0 {
+		attrs.Orderby = nil
+	}
+	stmt, params := g.Select(&attrs)
+	for _, p := range params {
+		stmt = strings.Replace(stmt, SQL_PLACEHOLDER, types.Literal(p), 1)
+	}
+	return strings.TrimSuffix(strings.TrimSpace(stmt), ";")
+}
+
+// GenerateIndex generates the standard index creation statment, where
+// the generated index name is limited to maxlen characters if maxlen
+// is set. it is used by the backends generators extending the standard
+// index statment.
+func GenerateIndex(tablename string, meta *TableMeta, ix *IndexMeta,
+	maxlen int) string {
+	index_exists := " IF NOT EXISTS"
+	if meta != nil && dictx.Fetch(meta.Args, "disable_index_exists", false) {
+		index_exists = ""
+	}
+	unique := ""
+	if ix.Unique {
+		unique = " UNIQUE"
+	}
+
+	stmt := fmt.Sprintf("CREATE%s INDEX%s %s ON %s (%s)",
+		unique, index_exists, IndexName(tablename, ix, maxlen), tablename,
+		strings.Join(ix.Columns, ", "))
+	if len(ix.Include) > 0 {
+		stmt += fmt.Sprintf(" INCLUDE (%s)", strings.Join(ix.Include, ", "))
+	}
+	if ix.Where != "" {
+		stmt += " WHERE " + ix.Where
+	}
+	return stmt + ";"
+}
+
+// IndexName returns the index name if defined, or generates the index
+// name from table and columns names. ex. "ix_table_col1_col2" and
+// "ux_table_col1_col2" for unique indexes. generated names longer than
+// maxlen are truncated and suffixed with a hash of the full name to
+// keep names unique, when maxlen is set.
+func IndexName(tablename string, ix *IndexMeta, maxlen int) string {
+	if ix.Name != "" {
+		return ix.Name
+	}
+	name := "ix_" + tablename
+	if ix.Unique {
+		name = "ux_" + tablename
+	}
+	for _, c := range ix.Columns {
+		// strip the column sort order
+		if f := strings.Fields(c); len(f) > 0 {
+			name += "_" + f[0]
+		}
+	}
+	return limit_name(name, maxlen)
+}
+
+// GenerateForeignKey generates the standard foreign key constraint
+// definition, where the generated constraint name is limited to maxlen
+// characters if maxlen is set.
+func GenerateForeignKey(tablename string, fk *ForeignKeyMeta, maxlen int) string {
+	return fmt.Sprintf("CONSTRAINT %s %s",
+		ForeignKeyName(tablename, fk, maxlen), ForeignKeyDefinition(
+			fk.Columns, fk.RefTable, fk.RefColumns, fk.OnUpdate, fk.OnDelete))
+}
+
+// GenerateAddForeignKey generates the standard statments adding foreign
+// key constraint to existing table, using the foreign key definition of
+// generator g.
+func GenerateAddForeignKey(g SqlGenerator, tablename string,
+	fk *ForeignKeyMeta) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s ADD %s;",
+		tablename, g.ForeignKey(tablename, fk))}
+}
+
+// ForeignKeyName returns the foreign key constraint name if defined, or
+// generates the name from table and columns names. ex. "fk_table_col1".
+// generated names longer than maxlen are truncated and suffixed with a
+// hash of the full name, when maxlen is set.
+func ForeignKeyName(tablename string, fk *ForeignKeyMeta, maxlen int) string {
+	if fk.Name != "" {
+		return fk.Name
+	}
+	return limit_name(
+		"fk_"+tablename+"_"+strings.Join(fk.Columns, "_"), maxlen)
+}
+
+// truncates the generated name to maxlen characters, and suffix it with
+// a hash of the full name to keep names unique.
+func limit_name(name string, maxlen int) string {
+	if maxlen > 0 && len(name) > maxlen {
+		sum := sha1.Sum([]byte(name))
+		hash := hex.EncodeToString(sum[:])[:8]
+		name = name[:maxlen-len(hash)-1] + "_" + hash
+	}
+	return name
+}
+
 ////////////////////////////////////////////////////
 
+// SearchTerms returns the words of full-text search text.
+func SearchTerms(text string) []string {
+	return strings.Fields(text)
+}
+
+// PrimaryColumn returns the table primary column name, or empty
+// string if not defined.
+func PrimaryColumn(meta *TableMeta) string {
+	if meta == nil {
+		return ""
+	}
+	if meta.AutoGuid {
+		return "guid"
+	}
+	for _, c := range meta.Columns {
+		if c.Primary {
+			return c.Name
+		}
+	}
+	return ""
+}
+
 // SqlIdent checks for a valid SQL identifier string.
 func SqlIdent(s string) bool {
 	matched, _ := regexp.MatchString("^[a-zA-Z0-9_]+$", s)
@@ -0,0 +1,83 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIndexName(t *testing.T) {
+	tests := []struct {
+		ix     IndexMeta
+		maxlen int
+		want   string
+	}{
+		{IndexMeta{Name: "custom", Columns: []string{"a"}}, 8, "custom"},
+		{IndexMeta{Columns: []string{"a", "b DESC"}}, 0, "ix_t_a_b"},
+		{IndexMeta{Columns: []string{"a"}, Unique: true}, 0, "ux_t_a"},
+		{IndexMeta{Columns: []string{"a", "b"}}, 8, "ix_t_a_b"},
+	}
+	for _, tt := range tests {
+		if got := IndexName("t", &tt.ix, tt.maxlen); got != tt.want {
+			t.Errorf("IndexName(%v) = %q, want %q", tt.ix.Columns, got, tt.want)
+		}
+	}
+
+	// long names are truncated to maxlen keeping them unique
+	table := strings.Repeat("table", 12)
+	a := IndexName(table, &IndexMeta{Columns: []string{"column_a"}}, 63)
+	b := IndexName(table, &IndexMeta{Columns: []string{"column_b"}}, 63)
+	if len(a) != 63 || len(b) != 63 || a == b {
+		t.Errorf("truncated names %q, %q", a, b)
+	}
+	if a != IndexName(table, &IndexMeta{Columns: []string{"column_a"}}, 63) {
+		t.Errorf("truncated name is not stable")
+	}
+	if !strings.HasPrefix(a, "ix_"+table[:40]) {
+		t.Errorf("truncated name %q lost prefix", a)
+	}
+}
+
+func TestForeignKeyName(t *testing.T) {
+	fk := &ForeignKeyMeta{Columns: []string{"a", "b"}, RefTable: "r"}
+	if got := ForeignKeyName("t", fk, 0); got != "fk_t_a_b" {
+		t.Errorf("ForeignKeyName() = %q", got)
+	}
+	named := &ForeignKeyMeta{Name: "custom", Columns: []string{"a"}}
+	if got := ForeignKeyName("t", named, 4); got != "custom" {
+		t.Errorf("ForeignKeyName() = %q", got)
+	}
+
+	table := strings.Repeat("table", 12)
+	a := ForeignKeyName(table, &ForeignKeyMeta{Columns: []string{"ref_a"}}, 64)
+	b := ForeignKeyName(table, &ForeignKeyMeta{Columns: []string{"ref_b"}}, 64)
+	if len(a) != 64 || len(b) != 64 || a == b {
+		t.Errorf("truncated names %q, %q", a, b)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	g := &StdSqlGenerator{}
+	stmt, params := g.Select(&StmtAttrs{
+		Tablename:   "t",
+		Filters:     "a=$1",
+		FiltersArgs: []any{1},
+		Orderby:     []string{"id"},
+		Offset:      20,
+		Limit:       10,
+	})
+	want := "SELECT * FROM t WHERE a=$1 ORDER BY id LIMIT 10 OFFSET 20;"
+	if stmt != want || len(params) != 1 {
+		t.Errorf("Select() = %q, %v, want %q", stmt, params, want)
+	}
+
+	// the offset is rendered without limit
+	stmt, _ = g.Select(&StmtAttrs{
+		Tablename: "t", Columns: []string{"a", "b"}, Offset: 5})
+	if want := "SELECT a, b FROM t OFFSET 5;"; stmt != want {
+		t.Errorf("Select() = %q, want %q", stmt, want)
+	}
+}
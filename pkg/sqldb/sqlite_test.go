@@ -0,0 +1,66 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/exonlabs/go-utils/pkg/abc/dictx"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+	sqlitedb "github.com/exonlabs/go-sqldb/pkg/sqlite_modernc"
+)
+
+// test model with optional initial data hook
+type testModel struct {
+	sqldb.BaseModel
+	meta        *sqldb.TableMeta
+	initialData func(dbs *sqldb.Session, tablename string) error
+}
+
+func (m *testModel) TableMeta() *sqldb.TableMeta {
+	return m.meta
+}
+
+func (m *testModel) InitialData(dbs *sqldb.Session, tablename string) error {
+	if m.initialData != nil {
+		return m.initialData(dbs, tablename)
+	}
+	return nil
+}
+
+// creates the sqlite test database in temp dir
+func testDatabase(t *testing.T) *sqldb.Database {
+	t.Helper()
+	opts := dictx.Dict{
+		"database": filepath.Join(t.TempDir(), "test.db"),
+	}
+	engine, err := sqlitedb.NewEngine(nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sqldb.NewDatabase(nil, engine, opts)
+	t.Cleanup(db.Shutdown)
+	return db
+}
+
+// returns the "n" column value of query first row
+func testCount(t *testing.T, dbs *sqldb.Session, stmt string, params ...any) int {
+	t.Helper()
+	rows, err := dbs.Fetch(stmt, params...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _ := sqldb.Row(rows[0]).Int64("n")
+	return int(n)
+}
+
+// checks weather table exists in sqlite database
+func tableExists(t *testing.T, dbs *sqldb.Session, table string) bool {
+	t.Helper()
+	return testCount(t, dbs, "SELECT count(*) AS n FROM sqlite_master "+
+		"WHERE type='table' AND name=?;", table) > 0
+}
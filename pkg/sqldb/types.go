@@ -0,0 +1,283 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SqlExpr represents a raw SQL expression, which is used as is in
+// statments. ex. SqlExpr("CURRENT_TIMESTAMP")
+type SqlExpr string
+
+// TypeMap defines the mapping of portable column kinds into the backend
+// native column types.
+type TypeMap struct {
+	// the native types names of portable kinds. the columns sizes are
+	// appended to KindString and KindDecimal types names.
+	Types map[Kind]string
+	// the boolean literals used in default values.
+	True, False string
+	// the auto increment columns modifier, appended to the native type
+	// of KindInt and KindBigInt columns with AutoIncrement set.
+	AutoIncrement string
+}
+
+// StdTypes defines the standard SQL types of portable column kinds.
+var StdTypes = &TypeMap{
+	Types: map[Kind]string{
+		KindJSON:    "TEXT",
+		KindString:  "VARCHAR",
+		KindText:    "TEXT",
+		KindInt:     "INTEGER",
+		KindBigInt:  "BIGINT",
+		KindBool:    "BOOLEAN",
+		KindFloat:   "DOUBLE PRECISION",
+		KindDecimal: "DECIMAL",
+		KindTime:    "TIMESTAMP",
+		KindDate:    "DATE",
+		KindBlob:    "BLOB",
+		KindUUID:    "CHAR(36)",
+	},
+	True:          "TRUE",
+	False:         "FALSE",
+	AutoIncrement: "GENERATED BY DEFAULT AS IDENTITY",
+}
+
+// ColumnType generates the column type definition, which is the native
+// type of column kind followed by the column modifiers in order:
+// auto increment, NOT NULL, DEFAULT and the extra modifiers of column Type.
+// KindRaw columns use the column Type as is.
+func (m *TypeMap) ColumnType(c *ColumnMeta) string {
+	if c.Kind == KindRaw {
+		return c.Type
+	}
+
+	typedef := m.Types[c.Kind]
+	switch c.Kind {
+	case KindString:
+		size := c.Size
+		if size <= 0 {
+			size = 255
+		}
+		typedef += fmt.Sprintf("(%d)", size)
+	case KindDecimal:
+		if c.Precision > 0 {
+			typedef += fmt.Sprintf("(%d,%d)", c.Precision, c.Scale)
+		}
+	case KindInt, KindBigInt:
+		if c.AutoIncrement && m.AutoIncrement != "" {
+			typedef += " " + m.AutoIncrement
+		}
+	}
+
+	if c.NotNull {
+		typedef += " NOT NULL"
+	}
+	if c.Default != nil {
+		typedef += " DEFAULT " + m.Literal(c.Default)
+	}
+	if c.Type != "" {
+		typedef += " " + c.Type
+	}
+	return typedef
+}
+
+// Literal returns the SQL literal of value, where the strings are quoted
+// and the SqlExpr values are used as is.
+func (m *TypeMap) Literal(v any) string {
+	switch t := v.(type) {
+	case SqlExpr:
+		return string(t)
+	case bool:
+		if t {
+			return m.True
+		}
+		return m.False
+	case string:
+		return "'" + strings.ReplaceAll(t, "'", "''") + "'"
+	case time.Time:
+		return "'" + t.Format("2006-01-02 15:04:05") + "'"
+	case nil:
+		return "NULL"
+	}
+	return fmt.Sprint(v)
+}
+
+// the portable kinds of backends native types names
+var nativeKinds = map[string]Kind{
+	"JSON": KindJSON, "JSONB": KindJSON,
+	"VARCHAR": KindString, "NVARCHAR": KindString, "CHAR": KindString,
+	"NCHAR": KindString, "CHARACTER": KindString,
+	"CHARACTER VARYING": KindString, "VARCHAR2": KindString,
+	"TEXT": KindText, "NTEXT": KindText, "CLOB": KindText,
+	"TINYTEXT": KindText, "MEDIUMTEXT": KindText, "LONGTEXT": KindText,
+	"INTEGER": KindInt, "INT": KindInt, "SMALLINT": KindInt,
+	"TINYINT": KindInt, "MEDIUMINT": KindInt, "INT4": KindInt,
+	"BIGINT": KindBigInt, "INT8": KindBigInt,
+	"BOOLEAN": KindBool, "BOOL": KindBool, "BIT": KindBool,
+	"REAL": KindFloat, "FLOAT": KindFloat, "DOUBLE": KindFloat,
+	"DOUBLE PRECISION": KindFloat, "FLOAT4": KindFloat, "FLOAT8": KindFloat,
+	"DECIMAL": KindDecimal, "NUMERIC": KindDecimal,
+	"DATETIME": KindTime, "DATETIME2": KindTime, "SMALLDATETIME": KindTime,
+	"TIMESTAMP": KindTime, "TIMESTAMP WITHOUT TIME ZONE": KindTime,
+	"TIMESTAMP WITH TIME ZONE": KindTime, "TIMESTAMPTZ": KindTime,
+	"DATE": KindDate,
+	"BLOB": KindBlob, "TINYBLOB": KindBlob, "MEDIUMBLOB": KindBlob,
+	"LONGBLOB": KindBlob, "BYTEA": KindBlob, "BINARY": KindBlob,
+	"VARBINARY": KindBlob, "IMAGE": KindBlob,
+	"UUID": KindUUID, "UNIQUEIDENTIFIER": KindUUID,
+}
+
+// the not null, literal default, auto increment and primary key
+// modifiers in column type
+var (
+	notNullRegex = regexp.MustCompile(`(?i)\bNOT\s+NULL\b`)
+	defaultRegex = regexp.MustCompile(
+		`(?i)\bDEFAULT\s+('(?:[^']|'')*'|-?[0-9.]+|TRUE|FALSE)(\s|$)`)
+	autoIncrementRegex = regexp.MustCompile(`(?i)\b(` +
+		`GENERATED\s+(ALWAYS|BY\s+DEFAULT)\s+AS\s+IDENTITY(\s*\([^)]*\))?|` +
+		`IDENTITY(\s*\(\s*-?\d+\s*,\s*-?\d+\s*\))?|` +
+		`AUTO_INCREMENT|AUTOINCREMENT|` +
+		`DEFAULT\s+nextval\('(?:[^']|'')*'(::regclass)?\))`)
+	primaryKeyRegex = regexp.MustCompile(`(?i)\bPRIMARY\s+KEY\b`)
+)
+
+// PortableColumn converts the KindRaw column with backend native type into
+// the matching portable kind, where the type size, precision and scale are
+// set in column, the not null and literal default modifiers are set in
+// column and the other type modifiers are kept in column Type. the native
+// auto increment modifiers of integer columns, as AUTOINCREMENT,
+// AUTO_INCREMENT, IDENTITY and the pgsql identity and serial sequences,
+// set the column AutoIncrement and Primary. it is used to generate the
+// schema of introspected tables for other backends. the columns with
+// unknown native types are returned unchanged.
+func PortableColumn(c ColumnMeta) ColumnMeta {
+	if c.Kind != KindRaw {
+		return c
+	}
+	typedef := strings.TrimSpace(c.Type)
+	upper := strings.ToUpper(typedef)
+
+	// match the longest native type name
+	names := []string{}
+	for name := range nativeKinds {
+		if strings.HasPrefix(upper, name) && (len(upper) == len(name) ||
+			strings.ContainsAny(upper[len(name):len(name)+1], " (")) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return c
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	kind := nativeKinds[names[0]]
+	rest := strings.TrimSpace(typedef[len(names[0]):])
+
+	// parse type size args
+	args := []string{}
+	if strings.HasPrefix(rest, "(") {
+		i := strings.Index(rest, ")")
+		if i < 0 {
+			return c
+		}
+		for _, a := range strings.Split(rest[1:i], ",") {
+			args = append(args, strings.ToUpper(strings.TrimSpace(a)))
+		}
+		rest = strings.TrimSpace(rest[i+1:])
+	}
+	// drop the native integer modifiers
+	for _, m := range []string{"UNSIGNED", "ZEROFILL"} {
+		if strings.HasPrefix(strings.ToUpper(rest), m) {
+			rest = strings.TrimSpace(rest[len(m):])
+		}
+	}
+
+	switch kind {
+	case KindString:
+		if len(args) > 0 && args[0] == "MAX" {
+			kind = KindText
+		} else if len(args) > 0 {
+			c.Size, _ = strconv.Atoi(args[0])
+		}
+	case KindInt:
+		// mysql boolean type
+		if names[0] == "TINYINT" && len(args) > 0 && args[0] == "1" {
+			kind = KindBool
+		}
+	case KindDecimal:
+		if len(args) > 0 {
+			c.Precision, _ = strconv.Atoi(args[0])
+		}
+		if len(args) > 1 {
+			c.Scale, _ = strconv.Atoi(args[1])
+		}
+	case KindBool:
+		// mysql bit fields
+		if len(args) > 0 && args[0] != "1" {
+			return c
+		}
+	}
+	// set the portable auto increment modifier, where the sqlite
+	// autoincrement columns declare the primary key in column type
+	if (kind == KindInt || kind == KindBigInt) &&
+		autoIncrementRegex.MatchString(rest) {
+		c.AutoIncrement, c.Primary = true, true
+		rest = autoIncrementRegex.ReplaceAllString(rest, "")
+		rest = primaryKeyRegex.ReplaceAllString(rest, "")
+	}
+	// set the portable not null and literal default modifiers
+	if notNullRegex.MatchString(rest) {
+		c.NotNull = true
+		rest = notNullRegex.ReplaceAllString(rest, "")
+	}
+	if m := defaultRegex.FindStringSubmatch(rest); m != nil {
+		value := strings.ToUpper(m[1])
+		switch {
+		case kind == KindBool:
+			c.Default = value == "1" || value == "TRUE"
+		case strings.HasPrefix(value, "'"):
+			c.Default = strings.ReplaceAll(m[1][1:len(m[1])-1], "''", "'")
+		default:
+			c.Default = SqlExpr(value)
+		}
+		rest = defaultRegex.ReplaceAllString(rest, "")
+	}
+	c.Kind, c.Type = kind, strings.Join(strings.Fields(rest), " ")
+	return c
+}
+
+////////////////////////////////////////////////////
+
+// returns the column values kind from the column kind, or from the
+// column Type for KindRaw columns.
+func column_value_kind(c *ColumnMeta) valueKind {
+	switch c.Kind {
+	case KindRaw:
+		return sql_value_kind(c.Type)
+	case KindJSON, KindString, KindText:
+		return valueString
+	case KindInt, KindBigInt:
+		return valueInt
+	case KindBool:
+		return valueBool
+	case KindFloat:
+		return valueFloat
+	case KindDecimal:
+		return valueDecimal
+	case KindTime, KindDate:
+		return valueTime
+	case KindBlob:
+		return valueBytes
+	case KindUUID:
+		return valueUUID
+	}
+	return valueAny
+}
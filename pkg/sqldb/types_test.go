@@ -0,0 +1,67 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPortableColumn(t *testing.T) {
+	tests := []struct {
+		typ  string
+		want ColumnMeta
+	}{
+		{"VARCHAR(64) NOT NULL DEFAULT 'a''b'", ColumnMeta{
+			Kind: KindString, Size: 64, NotNull: true, Default: "a'b"}},
+		{"NVARCHAR(MAX)", ColumnMeta{Kind: KindText}},
+		{"CHARACTER VARYING(32)", ColumnMeta{Kind: KindString, Size: 32}},
+		{"DECIMAL(10,2) DEFAULT 0", ColumnMeta{
+			Kind: KindDecimal, Precision: 10, Scale: 2, Default: SqlExpr("0")}},
+		{"TINYINT(1) NOT NULL DEFAULT 1", ColumnMeta{
+			Kind: KindBool, NotNull: true, Default: true}},
+		{"INT UNSIGNED NOT NULL", ColumnMeta{Kind: KindInt, NotNull: true}},
+		{"TIMESTAMP WITH TIME ZONE", ColumnMeta{Kind: KindTime}},
+		{"TEXT CHECK (x<>'')", ColumnMeta{Kind: KindText, Type: "CHECK (x<>'')"}},
+
+		// auto increment columns
+		{"INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT", ColumnMeta{
+			Kind: KindInt, NotNull: true, Primary: true, AutoIncrement: true}},
+		{"INT NOT NULL AUTO_INCREMENT", ColumnMeta{
+			Kind: KindInt, NotNull: true, Primary: true, AutoIncrement: true}},
+		{"BIGINT NOT NULL IDENTITY(1,1)", ColumnMeta{
+			Kind: KindBigInt, NotNull: true, Primary: true, AutoIncrement: true}},
+		{"INTEGER NOT NULL GENERATED BY DEFAULT AS IDENTITY", ColumnMeta{
+			Kind: KindInt, NotNull: true, Primary: true, AutoIncrement: true}},
+		{"BIGINT NOT NULL DEFAULT nextval('t_id_seq'::regclass)", ColumnMeta{
+			Kind: KindBigInt, NotNull: true, Primary: true, AutoIncrement: true}},
+
+		// unknown native types
+		{"GEOMETRY", ColumnMeta{Type: "GEOMETRY"}},
+		{"BIT(8)", ColumnMeta{Type: "BIT(8)"}},
+	}
+	for _, tt := range tests {
+		got := PortableColumn(ColumnMeta{Type: tt.typ})
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("PortableColumn(%q) = %+v, want %+v", tt.typ, got, tt.want)
+		}
+	}
+}
+
+func TestColumnTypeAutoIncrement(t *testing.T) {
+	types := &TypeMap{
+		Types:         map[Kind]string{KindInt: "INT", KindText: "TEXT"},
+		AutoIncrement: "AUTO_INCREMENT",
+	}
+	c := ColumnMeta{Kind: KindInt, NotNull: true, AutoIncrement: true}
+	if got := types.ColumnType(&c); got != "INT AUTO_INCREMENT NOT NULL" {
+		t.Errorf("ColumnType() = %q", got)
+	}
+	// the modifier applies only to integer columns
+	c = ColumnMeta{Kind: KindText, AutoIncrement: true}
+	if got := types.ColumnType(&c); got != "TEXT" {
+		t.Errorf("ColumnType() = %q", got)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WriterEngine defines the engines serializing the write operations
+// through a dedicated writer driver handler. the sessions transactions
+// and statments execution acquire the writer handler, while the fetch
+// operations outside transactions use the engine SqlDB handler.
+//
+// NOTE: the session transaction holds the writer until Commit or
+// RollBack, so a write operation of another session made by the same
+// caller within the transaction waits for its own transaction to end,
+// which is a deadlock if the session OperationTimeout is disabled. the
+// writes within transaction must use the transaction session. waits
+// longer than WriterWaitWarning are logged as warnings.
+type WriterEngine interface {
+	Engine
+
+	// WriterDB waits in FIFO order to acquire the writer driver handler
+	// until ctx is done. it returns nil handler if the engine writes are
+	// not serialized.
+	WriterDB(ctx context.Context) (*sql.DB, error)
+	// ReleaseWriter frees the writer driver handler for the next writer.
+	ReleaseWriter(*sql.DB)
+	// WriterStats returns the writer queue statistics.
+	WriterStats() WriteQueueStats
+}
+
+// WriterWaitWarning is the writer queue wait duration after which a
+// warning is logged to the database logger.
+var WriterWaitWarning = 10 * time.Second
+
+// WriteQueueStats represents the writer queue statistics.
+type WriteQueueStats struct {
+	// Depth is the number of writers waiting in queue.
+	Depth int
+	// MaxDepth is the max reached queue depth.
+	MaxDepth int
+	// Active indicates that the writer is acquired.
+	Active bool
+	// Acquired is the total number of writer acquisitions.
+	Acquired int64
+	// Canceled is the number of writers left the queue when their
+	// context is done before acquiring the writer.
+	Canceled int64
+	// WaitTime is the total queue wait time of acquired writers.
+	WaitTime time.Duration
+	// MaxWait is the max queue wait time of acquired writers.
+	MaxWait time.Duration
+}
+
+// AvgWait returns the average queue wait time of acquired writers.
+func (s WriteQueueStats) AvgWait() time.Duration {
+	if s.Acquired == 0 {
+		return 0
+	}
+	return s.WaitTime / time.Duration(s.Acquired)
+}
+
+// String returns the writer queue statistics description.
+func (s WriteQueueStats) String() string {
+	return fmt.Sprintf(
+		"depth %d (max %d), active %v, acquired %d, canceled %d, "+
+			"wait avg %v (max %v)", s.Depth, s.MaxDepth, s.Active,
+		s.Acquired, s.Canceled, s.AvgWait(), s.MaxWait)
+}
+
+// WriteQueue represents a FIFO queue granting the writer to one holder
+// at a time, in the order of acquire calls. the zero value is an empty
+// queue ready to use.
+type WriteQueue struct {
+	mu      sync.Mutex
+	active  bool
+	waiters []chan struct{}
+	stats   WriteQueueStats
+}
+
+// Acquire waits until the writer is granted to caller or ctx is done.
+// the granted writer must be freed using Release.
+func (q *WriteQueue) Acquire(ctx context.Context) error {
+	start := time.Now()
+	q.mu.Lock()
+	if !q.active && len(q.waiters) == 0 {
+		q.active = true
+		q.granted(start)
+		q.mu.Unlock()
+		return nil
+	}
+	ch := make(chan struct{})
+	q.waiters = append(q.waiters, ch)
+	q.stats.MaxDepth = max(q.stats.MaxDepth, len(q.waiters))
+	q.mu.Unlock()
+
+	select {
+	case <-ch:
+		q.mu.Lock()
+		q.granted(start)
+		q.mu.Unlock()
+		return nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		select {
+		case <-ch:
+			// granted while leaving, pass to the next writer
+			q.release()
+		default:
+			for i, w := range q.waiters {
+				if w == ch {
+					q.waiters = append(q.waiters[:i], q.waiters[i+1:]...)
+					break
+				}
+			}
+		}
+		q.stats.Canceled++
+		return ctx.Err()
+	}
+}
+
+// Release frees the writer and grants it to the next waiting writer.
+func (q *WriteQueue) Release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.release()
+}
+
+// Stats returns the queue statistics.
+func (q *WriteQueue) Stats() WriteQueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	s := q.stats
+	s.Depth = len(q.waiters)
+	s.Active = q.active
+	return s
+}
+
+// updates the stats of granted writer
+func (q *WriteQueue) granted(start time.Time) {
+	wait := time.Since(start)
+	q.stats.Acquired++
+	q.stats.WaitTime += wait
+	q.stats.MaxWait = max(q.stats.MaxWait, wait)
+}
+
+// grants the writer to the first waiting writer, or frees it if no
+// waiting writers.
+func (q *WriteQueue) release() {
+	if !q.active {
+		return
+	}
+	if len(q.waiters) == 0 {
+		q.active = false
+		return
+	}
+	ch := q.waiters[0]
+	q.waiters = q.waiters[1:]
+	close(ch)
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqldb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waits until the queue has depth waiting writers
+func waitDepth(t *testing.T, q *WriteQueue, depth int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for q.Stats().Depth != depth {
+		if time.Now().After(deadline) {
+			t.Fatalf("queue depth %d, want %d", q.Stats().Depth, depth)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWriteQueueFIFO(t *testing.T) {
+	q := &WriteQueue{}
+	if err := q.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	order := []int{}
+	for i := range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := q.Acquire(context.Background()); err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			q.Release()
+		}()
+		waitDepth(t, q, i+1)
+	}
+	q.Release()
+	wg.Wait()
+
+	for i, n := range order {
+		if i != n {
+			t.Fatalf("grant order %v, want FIFO", order)
+		}
+	}
+	if s := q.Stats(); s.Active || s.Depth != 0 || s.Acquired != 6 ||
+		s.MaxDepth != 5 {
+		t.Errorf("stats: %s", s)
+	}
+}
+
+func TestWriteQueueCancel(t *testing.T) {
+	q := &WriteQueue{}
+	if err := q.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- q.Acquire(ctx) }()
+	waitDepth(t, q, 1)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled acquire error = %v", err)
+	}
+	if s := q.Stats(); s.Depth != 0 || s.Canceled != 1 || !s.Active {
+		t.Errorf("stats after cancel: %s", s)
+	}
+
+	// the deadline is reported for waiting writers
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("timed out acquire error = %v", err)
+	}
+
+	q.Release()
+	if s := q.Stats(); s.Active {
+		t.Errorf("writer not freed: %s", s)
+	}
+}
+
+func TestWriteQueueGrantCancelRace(t *testing.T) {
+	q := &WriteQueue{}
+	canceled := 0
+	for range 500 {
+		if err := q.Acquire(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			err := q.Acquire(ctx)
+			if err == nil {
+				q.Release()
+			}
+			done <- err
+		}()
+		waitDepth(t, q, 1)
+
+		// the writer is granted while the waiter leaves the queue
+		go cancel()
+		q.Release()
+		if err := <-done; err != nil {
+			canceled++
+		}
+
+		// the writer is never leaked by the canceled waiter
+		ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+		if err := q.Acquire(ctx); err != nil {
+			t.Fatalf("writer leaked: %v, %s", err, q.Stats())
+		}
+		cancel()
+		q.Release()
+	}
+
+	s := q.Stats()
+	if s.Active || s.Depth != 0 || s.Canceled != int64(canceled) ||
+		s.Acquired != int64(3*500-canceled) {
+		t.Errorf("stats: %s, canceled %d", s, canceled)
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// snapshot files time suffix format
+const snapshotLayout = "20060102T150405.000"
+
+// BackupOptions represents the online backup options.
+type BackupOptions struct {
+	// Pages is the number of database pages copied per step, where the
+	// database is unlocked for writers between steps. defaults to 256,
+	// and negative value copies all pages in one step.
+	Pages int
+	// Interval is the wait duration between steps, defaults to 10ms.
+	Interval time.Duration
+	// Progress is called after each step with the remaining and the
+	// total database pages.
+	Progress func(remaining, total int)
+}
+
+// returns the backup options with default values
+func backup_options(opts *BackupOptions) *BackupOptions {
+	o := BackupOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Pages == 0 {
+		o.Pages = 256
+	}
+	if o.Interval <= 0 {
+		o.Interval = 10 * time.Millisecond
+	}
+	return &o
+}
+
+// Backup creates an online backup of the database into destPath, using
+// the default backup options.
+func (e *Engine) Backup(ctx context.Context, destPath string) error {
+	return e.BackupWith(ctx, destPath, nil)
+}
+
+// BackupWith creates an online backup of the database into destPath.
+// it uses the sqlite online backup API copying the database pages in
+// steps, or VACUUM INTO if not supported by driver. the backup restarts
+// if the database is changed by other connections between steps, so after
+// copying twice the database pages the remaining pages are copied in one
+// step, blocking the writers only for that step.
+//
+// the backup is written into a temporary file renamed to destPath when
+// completed, so existing backups are not replaced by partial backups.
+func (e *Engine) BackupWith(ctx context.Context, destPath string,
+	opts *BackupOptions) error {
+	o := backup_options(opts)
+	sdb, err := e.SqlDB()
+	if err != nil {
+		return fmt.Errorf("%w - %v", sqldb.ErrOpen, err)
+	}
+	conn, err := sdb.Conn(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return backup_error(ctx.Err())
+		}
+		return fmt.Errorf("%w - %v", sqldb.ErrOpen, err)
+	}
+	defer conn.Close()
+
+	tmpPath := destPath + ".tmp"
+	os.Remove(tmpPath)
+	if e.Log != nil {
+		e.Log.Debug("backup database into %s", destPath)
+	}
+
+	supported := false
+	err = conn.Raw(func(dc any) error {
+		src, ok := dc.(*sqlite3.SQLiteConn)
+		if !ok {
+			return nil
+		}
+		supported = true
+		dst, err := (&sqlite3.SQLiteDriver{}).Open(tmpPath)
+		if err != nil {
+			return err
+		}
+		defer dst.Close()
+		b, err := dst.(*sqlite3.SQLiteConn).Backup("main", src, "main")
+		if err != nil {
+			return err
+		}
+		copied, pages := 0, o.Pages
+		for {
+			if copied > 2*b.PageCount() {
+				pages = -1
+			}
+			// the busy and locked steps are retried without error
+			done, err := b.Step(pages)
+			if err != nil {
+				b.Finish()
+				return err
+			}
+			copied += max(pages, 0)
+			if o.Progress != nil {
+				o.Progress(b.Remaining(), b.PageCount())
+			}
+			if done {
+				break
+			}
+			if err := backup_wait(ctx, o.Interval); err != nil {
+				b.Finish()
+				return err
+			}
+		}
+		return b.Finish()
+	})
+	if err == nil && !supported {
+		err = vacuum_into(ctx, conn, tmpPath, o)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return backup_error(err)
+	}
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("%w - %v", sqldb.ErrOperation, err)
+	}
+	return nil
+}
+
+// Snapshot creates an online backup of the database in dir, named with
+// the database name and the current UTC time, then removes the oldest
+// snapshots keeping the newest keep snapshots, or all if keep is 0.
+// it returns the created snapshot path.
+func (e *Engine) Snapshot(ctx context.Context, dir string, keep int,
+	opts *BackupOptions) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("%w - %v", sqldb.ErrOperation, err)
+	}
+	prefix := e.snapshot_prefix()
+	path := filepath.Join(dir, fmt.Sprintf("%s%s.db",
+		prefix, time.Now().UTC().Format(snapshotLayout)))
+	if err := e.BackupWith(ctx, path, opts); err != nil {
+		return "", err
+	}
+	if keep <= 0 {
+		return path, nil
+	}
+
+	// remove the oldest snapshots
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return path, fmt.Errorf("%w - %v", sqldb.ErrOperation, err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) ||
+			!strings.HasSuffix(name, ".db") {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".db")
+		if _, err := time.Parse(snapshotLayout, ts); err == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for i := 0; i < len(names)-keep; i++ {
+		if e.Log != nil {
+			e.Log.Debug("removing snapshot %s", names[i])
+		}
+		if err := os.Remove(filepath.Join(dir, names[i])); err != nil {
+			return path, fmt.Errorf("%w - %v", sqldb.ErrOperation, err)
+		}
+	}
+	return path, nil
+}
+
+// RunSnapshots creates database snapshots in dir every interval, keeping
+// the newest keep snapshots, until ctx is cancelled. the snapshots errors
+// are logged and the snapshot is retried on next interval.
+func (e *Engine) RunSnapshots(ctx context.Context, dir string,
+	interval time.Duration, keep int, opts *BackupOptions) error {
+	if interval <= 0 {
+		return fmt.Errorf("%w - invalid snapshots interval", sqldb.ErrOperation)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			path, err := e.Snapshot(ctx, dir, keep, opts)
+			if e.Log == nil {
+				continue
+			}
+			if err != nil && ctx.Err() == nil {
+				e.Log.Error("snapshot failed, %v", err)
+			} else if err == nil {
+				e.Log.Info("created snapshot %s", path)
+			}
+		}
+	}
+}
+
+// returns the snapshot files names prefix
+func (e *Engine) snapshot_prefix() string {
+	name := filepath.Base(e.cfg.Database)
+	return strings.TrimSuffix(name, filepath.Ext(name)) + "-"
+}
+
+////////////////////////////////////////////////////
+
+// creates the backup using VACUUM INTO in one step
+func vacuum_into(ctx context.Context, conn *sql.Conn, path string,
+	o *BackupOptions) error {
+	if _, err := conn.ExecContext(ctx, "VACUUM INTO ?;", path); err != nil {
+		return err
+	}
+	if o.Progress != nil {
+		o.Progress(0, 1)
+	}
+	return nil
+}
+
+// waits between backup steps or returns the context error
+func backup_wait(ctx context.Context, interval time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(interval):
+		return nil
+	}
+}
+
+// returns the backup operation error
+func backup_error(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return sqldb.ErrBreak
+	} else if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w - %v", sqldb.ErrTimeout, err)
+	}
+	return fmt.Errorf("%w - %v", sqldb.ErrOperation, err)
+}
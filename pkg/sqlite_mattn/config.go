@@ -5,7 +5,11 @@
 package sqlitedb
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/exonlabs/go-utils/pkg/abc/dictx"
@@ -19,6 +23,37 @@ import (
 type Config struct {
 	// Database path
 	Database string
+	// JournalMode is the database journal mode, one of DELETE, TRUNCATE,
+	// PERSIST, MEMORY, WAL or OFF. empty keeps the database journal mode.
+	JournalMode string
+	// Synchronous is the connections sync mode, one of OFF, NORMAL, FULL
+	// or EXTRA. empty keeps the driver default.
+	Synchronous string
+	// BusyTimeout is the locked database wait timeout in milliseconds.
+	BusyTimeout int
+	// CacheSize is the connections pages cache size, as number of pages
+	// if positive or as KiB if negative. zero keeps the driver default.
+	CacheSize int
+	// ForeignKeys enables the foreign key constraints enforcement.
+	ForeignKeys bool
+	// TempStore is the temporary tables storage, one of DEFAULT, FILE or
+	// MEMORY. empty keeps the driver default.
+	TempStore string
+	// MmapSize is the max database bytes accessed using memory-mapped I/O,
+	// limited by the library max mmap size. zero keeps the driver default.
+	MmapSize int64
+	// ReadOnly opens the database in read-only mode.
+	ReadOnly bool
+	// Immutable opens the database as read-only without locking and change
+	// detection, for database files that are never changed.
+	Immutable bool
+	// SingleWriter serializes the sessions writes through one writer
+	// connection in FIFO order, while the reads use a separate connections
+	// pool. it should be used with WAL journal mode where the readers
+	// don't block the writer. the writes of other sessions within a
+	// session transaction wait for the transaction to end, see
+	// sqldb.WriterEngine.
+	SingleWriter bool
 	// ConnectArgs holds connection params
 	ConnectArgs string
 }
@@ -28,40 +63,295 @@ type Config struct {
 //
 // The parsed options are:
 //   - database: (string) the database file path - REQUIRED
-//   - connect_args: (string) holds connection params
+//   - journal_mode: (string) the journal mode, ex: WAL
+//   - synchronous: (string) the sync mode, OFF|NORMAL|FULL|EXTRA
+//   - busy_timeout: (int) the locked database wait timeout in ms, default 100
+//   - cache_size: (int) the pages cache size, pages or -KiB
+//   - foreign_keys: (bool) enable foreign keys enforcement, default true
+//   - temp_store: (string) the temporary tables storage, DEFAULT|FILE|MEMORY
+//   - mmap_size: (int) the memory-mapped I/O max bytes
+//   - read_only: (bool) open database in read-only mode
+//   - immutable: (bool) open database as immutable read-only file
+//   - single_writer: (bool) serialize the writes through one connection
+//   - connect_args: (string) holds connection params, which take
+//     precedence over the above options
 func NewConfig(opts dictx.Dict) (*Config, error) {
 	cfg := &Config{
-		Database:    dictx.GetString(opts, "database", ""),
-		ConnectArgs: dictx.GetString(opts, "connect_args", ""),
+		Database:     dictx.GetString(opts, "database", ""),
+		JournalMode:  strings.ToUpper(dictx.GetString(opts, "journal_mode", "")),
+		Synchronous:  strings.ToUpper(dictx.GetString(opts, "synchronous", "")),
+		ForeignKeys:  dictx.Fetch(opts, "foreign_keys", true),
+		TempStore:    strings.ToUpper(dictx.GetString(opts, "temp_store", "")),
+		ReadOnly:     dictx.Fetch(opts, "read_only", false),
+		Immutable:    dictx.Fetch(opts, "immutable", false),
+		SingleWriter: dictx.Fetch(opts, "single_writer", false),
+		ConnectArgs:  dictx.GetString(opts, "connect_args", ""),
 	}
+	var err error
+	if cfg.BusyTimeout, err = int_option(opts, "busy_timeout", 100); err != nil {
+		return nil, err
+	}
+	if cfg.CacheSize, err = int_option(opts, "cache_size", 0); err != nil {
+		return nil, err
+	}
+	mmap, err := int_option(opts, "mmap_size", 0)
+	if err != nil {
+		return nil, err
+	}
+	cfg.MmapSize = int64(mmap)
 
 	// validations
 	if cfg.Database == "" {
 		return nil, sqldb.ErrDBPath
 	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
 
 	return cfg, nil
 }
 
-// DSN returns the driver-specific data source name.
+// checks the pragma options values
+func (cfg *Config) validate() error {
+	for name, value := range map[string]string{
+		"journal_mode": cfg.JournalMode,
+		"synchronous":  cfg.Synchronous,
+		"temp_store":   cfg.TempStore,
+	} {
+		if value != "" && !slices.Contains(pragmaModes[name], value) {
+			return fmt.Errorf("%w - invalid %s '%s'",
+				sqldb.ErrDBConfig, name, value)
+		}
+	}
+	if cfg.BusyTimeout < 0 {
+		return fmt.Errorf("%w - invalid busy_timeout %d",
+			sqldb.ErrDBConfig, cfg.BusyTimeout)
+	}
+	if cfg.MmapSize < 0 {
+		return fmt.Errorf("%w - invalid mmap_size %d",
+			sqldb.ErrDBConfig, cfg.MmapSize)
+	}
+	if cfg.JournalMode != "" && (cfg.ReadOnly || cfg.Immutable) {
+		return fmt.Errorf("%w - journal_mode can not be set in read-only mode",
+			sqldb.ErrDBConfig)
+	}
+	return nil
+}
+
+// DSN returns the driver-specific data source name. the pragmas are set
+// using the driver params, and the read-only modes using the sqlite URI
+// params. the pragmas without driver params are set on connect.
 //
-// format: dbpath[?param1=value1&...&paramN=valueN]
+// format: [file:]dbpath[?param1=value1&...&paramN=valueN]
 func (cfg *Config) DSN() string {
 	args := []string{}
+	if cfg.ReadOnly {
+		args = append(args, "mode=ro")
+	}
+	if cfg.Immutable {
+		args = append(args, "immutable=1")
+	}
 
 	conn_args := strings.TrimSpace(cfg.ConnectArgs)
 	if len(conn_args) > 0 {
 		args = append(args, conn_args)
 	}
 
-	if !strings.Contains(conn_args, "_foreign_keys=") &&
-		!strings.Contains(conn_args, "_fk=") {
-		args = append(args, "_foreign_keys=1")
+	for _, p := range cfg.pragmas() {
+		if params, ok := dsnParams[p.name]; ok {
+			args = append(args, fmt.Sprintf("%s=%s", params[0], p.value))
+		}
+	}
+
+	return dsn_path(cfg.Database, cfg.ReadOnly || cfg.Immutable) +
+		strings.Join(args, "&")
+}
+
+// the driver params of pragmas, with the params aliases
+var dsnParams = map[string][]string{
+	"busy_timeout": {"_busy_timeout", "_timeout"},
+	"foreign_keys": {"_foreign_keys", "_fk"},
+	"journal_mode": {"_journal_mode", "_journal"},
+	"synchronous":  {"_synchronous", "_sync"},
+	"cache_size":   {"_cache_size"},
+}
+
+// returns the pragmas not overridden by connect args
+func (cfg *Config) pragmas() []pragma {
+	list := []pragma{}
+	for _, p := range cfg.all_pragmas() {
+		overridden := false
+		for _, param := range dsnParams[p.name] {
+			if strings.Contains(cfg.ConnectArgs, param+"=") {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			list = append(list, p)
+		}
 	}
-	if !strings.Contains(conn_args, "_busy_timeout=") &&
-		!strings.Contains(conn_args, "_timeout=") {
-		args = append(args, "_busy_timeout=100")
+	return list
+}
+
+// returns the pragmas statments for pragmas without driver params
+func (cfg *Config) connect_pragmas() []string {
+	stmts := []string{}
+	for _, p := range cfg.pragmas() {
+		if _, ok := dsnParams[p.name]; !ok {
+			stmts = append(stmts, fmt.Sprintf("PRAGMA %s = %s;", p.name, p.value))
+		}
 	}
+	return stmts
+}
+
+// returns the writer data source name, where the writer transactions
+// acquire the database write lock on begin.
+func (cfg *Config) writer_dsn() string {
+	dsn := cfg.DSN()
+	if !strings.Contains(cfg.ConnectArgs, "_txlock=") {
+		dsn += "&_txlock=immediate"
+	}
+	return dsn
+}
+
+////////////////////////////////////////////////////
 
-	return fmt.Sprintf("%s?%s", cfg.Database, strings.Join(args, "&"))
+// the sqlite pragmas modes values, where the pragmas synchronous and
+// temp_store return the mode index when queried.
+var pragmaModes = map[string][]string{
+	"journal_mode": {"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"},
+	"synchronous":  {"OFF", "NORMAL", "FULL", "EXTRA"},
+	"temp_store":   {"DEFAULT", "FILE", "MEMORY"},
+}
+
+// database pragma setting
+type pragma struct {
+	// pragma name and set value
+	name, value string
+	// the expected queried value
+	expect string
+	// the expected value is the max applied value, for pragmas limited
+	// by the library settings
+	limit bool
+}
+
+// checks the pragma queried value against the expected value
+func (p pragma) match(value string) bool {
+	if p.limit {
+		n, err := strconv.ParseInt(value, 10, 64)
+		max, _ := strconv.ParseInt(p.expect, 10, 64)
+		return err == nil && n <= max
+	}
+	return strings.EqualFold(value, p.expect)
+}
+
+// returns the configured pragmas
+func (cfg *Config) all_pragmas() []pragma {
+	list := []pragma{}
+	add := func(name, value, expect string) {
+		list = append(list, pragma{name: name, value: value, expect: expect})
+	}
+	mode := func(name, value string) {
+		if value == "" {
+			return
+		}
+		if name == "journal_mode" {
+			add(name, value, strings.ToLower(value))
+		} else {
+			i := slices.Index(pragmaModes[name], value)
+			add(name, value, strconv.Itoa(i))
+		}
+	}
+
+	add("busy_timeout", strconv.Itoa(cfg.BusyTimeout),
+		strconv.Itoa(cfg.BusyTimeout))
+	if cfg.ForeignKeys {
+		add("foreign_keys", "1", "1")
+	} else {
+		add("foreign_keys", "0", "0")
+	}
+	mode("journal_mode", cfg.JournalMode)
+	mode("synchronous", cfg.Synchronous)
+	if cfg.CacheSize != 0 {
+		add("cache_size", strconv.Itoa(cfg.CacheSize),
+			strconv.Itoa(cfg.CacheSize))
+	}
+	mode("temp_store", cfg.TempStore)
+	if cfg.MmapSize > 0 {
+		// the applied size is limited by the library max mmap size
+		size := strconv.FormatInt(cfg.MmapSize, 10)
+		list = append(list, pragma{
+			name: "mmap_size", value: size, expect: size, limit: true})
+	}
+	return list
+}
+
+// checks the applied pragmas values on a database connection
+func (cfg *Config) verify(sdb *sql.DB) error {
+	ctx := context.Background()
+	conn, err := sdb.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	for _, p := range cfg.pragmas() {
+		// in-memory databases report the memory journal mode for all
+		// modes except OFF
+		if p.name == "journal_mode" && cfg.in_memory() {
+			continue
+		}
+		var value string
+		err := conn.QueryRowContext(ctx,
+			fmt.Sprintf("PRAGMA %s;", p.name)).Scan(&value)
+		if err != nil {
+			return err
+		}
+		if !p.match(value) {
+			return fmt.Errorf("%w - pragma %s applied value '%s', expected '%s'",
+				sqldb.ErrDBConfig, p.name, value, p.expect)
+		}
+	}
+	return nil
+}
+
+// checks weather the database is an in-memory database
+func (cfg *Config) in_memory() bool {
+	return cfg.Database == ":memory:" ||
+		strings.HasPrefix(cfg.Database, "file::memory:") ||
+		strings.Contains(cfg.Database, "mode=memory") ||
+		strings.Contains(cfg.ConnectArgs, "mode=memory")
+}
+
+// returns the database path of data source name with the params separator,
+// where the path is converted into URI filename for the URI params.
+func dsn_path(path string, uri bool) string {
+	if uri && !strings.HasPrefix(path, "file:") {
+		path = "file:" + path
+	}
+	if strings.Contains(path, "?") {
+		return path + "&"
+	}
+	return path + "?"
+}
+
+// returns the integer option value
+func int_option(opts dictx.Dict, key string, defval int) (int, error) {
+	switch v := dictx.Fetch[any](opts, key, nil).(type) {
+	case nil:
+		return defval, nil
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("%w - invalid %s option", sqldb.ErrDBConfig, key)
 }
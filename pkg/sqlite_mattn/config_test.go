@@ -0,0 +1,158 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqlitedb
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/exonlabs/go-utils/pkg/abc/dictx"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+func TestNewConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		opts dictx.Dict
+		want *Config
+		err  error
+	}{
+		{"defaults", dictx.Dict{"database": "a.db"},
+			&Config{Database: "a.db", BusyTimeout: 100, ForeignKeys: true}, nil},
+		{"options", dictx.Dict{"database": "a.db", "journal_mode": "wal",
+			"synchronous": "normal", "busy_timeout": "200", "cache_size": -2000,
+			"foreign_keys": false, "temp_store": "memory", "mmap_size": 1024.0,
+			"single_writer": true},
+			&Config{Database: "a.db", JournalMode: "WAL", Synchronous: "NORMAL",
+				BusyTimeout: 200, CacheSize: -2000, TempStore: "MEMORY",
+				MmapSize: 1024, SingleWriter: true}, nil},
+		{"read_only", dictx.Dict{"database": "a.db", "read_only": true},
+			&Config{Database: "a.db", BusyTimeout: 100, ForeignKeys: true,
+				ReadOnly: true}, nil},
+		{"no_database", dictx.Dict{}, nil, sqldb.ErrDBPath},
+		{"journal_mode", dictx.Dict{"database": "a.db", "journal_mode": "x"},
+			nil, sqldb.ErrDBConfig},
+		{"synchronous", dictx.Dict{"database": "a.db", "synchronous": "x"},
+			nil, sqldb.ErrDBConfig},
+		{"busy_timeout", dictx.Dict{"database": "a.db", "busy_timeout": "x"},
+			nil, sqldb.ErrDBConfig},
+		{"busy_timeout_negative", dictx.Dict{"database": "a.db",
+			"busy_timeout": -1}, nil, sqldb.ErrDBConfig},
+		{"mmap_size", dictx.Dict{"database": "a.db", "mmap_size": 1.5},
+			nil, sqldb.ErrDBConfig},
+		{"mmap_size_negative", dictx.Dict{"database": "a.db",
+			"mmap_size": -1}, nil, sqldb.ErrDBConfig},
+		{"read_only_journal", dictx.Dict{"database": "a.db",
+			"journal_mode": "WAL", "immutable": true}, nil, sqldb.ErrDBConfig},
+	}
+	for _, tt := range tests {
+		cfg, err := NewConfig(tt.opts)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: error = %v, want %v", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		} else if *cfg != *tt.want {
+			t.Errorf("%s: config %+v, want %+v", tt.name, cfg, tt.want)
+		}
+	}
+}
+
+func TestDSN(t *testing.T) {
+	tests := []struct {
+		cfg     Config
+		dsn     string
+		pragmas []string
+	}{
+		{Config{Database: "a.db", BusyTimeout: 100, ForeignKeys: true},
+			"a.db?_busy_timeout=100&_foreign_keys=1", []string{}},
+		{Config{Database: "a.db", JournalMode: "WAL", Synchronous: "NORMAL",
+			CacheSize: -2000, TempStore: "MEMORY", MmapSize: 1024},
+			"a.db?_busy_timeout=0&_foreign_keys=0&_journal_mode=WAL" +
+				"&_synchronous=NORMAL&_cache_size=-2000",
+			[]string{"PRAGMA temp_store = MEMORY;",
+				"PRAGMA mmap_size = 1024;"}},
+		{Config{Database: "a.db?cache=shared", ReadOnly: true, BusyTimeout: 5,
+			ConnectArgs: "_timeout=10&_fk=1"},
+			"file:a.db?cache=shared&mode=ro&_timeout=10&_fk=1", []string{}},
+		{Config{Database: "file:a.db", Immutable: true},
+			"file:a.db?immutable=1&_busy_timeout=0&_foreign_keys=0",
+			[]string{}},
+	}
+	for _, tt := range tests {
+		if dsn := tt.cfg.DSN(); dsn != tt.dsn {
+			t.Errorf("DSN() = %s\nwant %s", dsn, tt.dsn)
+		}
+		if pragmas := tt.cfg.connect_pragmas(); !slices.Equal(
+			pragmas, tt.pragmas) {
+			t.Errorf("connect_pragmas() = %v, want %v", pragmas, tt.pragmas)
+		}
+	}
+	cfg := &Config{Database: "a.db", ConnectArgs: "_txlock=deferred"}
+	if dsn := cfg.writer_dsn(); dsn != cfg.DSN() {
+		t.Errorf("writer_dsn() = %s", dsn)
+	}
+	cfg.ConnectArgs = ""
+	if dsn := cfg.writer_dsn(); dsn != cfg.DSN()+"&_txlock=immediate" {
+		t.Errorf("writer_dsn() = %s", dsn)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	tests := []struct {
+		name string
+		opts dictx.Dict
+	}{
+		{"pragmas", dictx.Dict{"database": path, "journal_mode": "WAL",
+			"synchronous": "NORMAL", "cache_size": -2000,
+			"temp_store": "MEMORY", "busy_timeout": 300}},
+		// the applied mmap size is limited by the library
+		{"mmap_size", dictx.Dict{"database": path, "mmap_size": int64(1) << 50}},
+		// in-memory databases report the memory journal mode
+		{"memory", dictx.Dict{"database": ":memory:", "journal_mode": "WAL"}},
+		{"memory_uri", dictx.Dict{"database": "file:mem?mode=memory",
+			"journal_mode": "DELETE"}},
+	}
+	for _, tt := range tests {
+		engine, err := NewEngine(nil, tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		sdb, err := engine.SqlDB()
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		sdb.Close()
+	}
+
+	// pragmas not applied on connection
+	sdb, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sdb.Close()
+	for _, opts := range []dictx.Dict{
+		{"database": path, "synchronous": "EXTRA"},
+		{"database": path, "cache_size": 100},
+		{"database": path, "journal_mode": "TRUNCATE"},
+	} {
+		cfg, err := NewConfig(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cfg.verify(sdb); !errors.Is(err, sqldb.ErrDBConfig) {
+			t.Errorf("%v: verify error = %v", opts, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqlitedb
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/exonlabs/go-utils/pkg/abc/dictx"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+func testDatabase(t *testing.T) *sqldb.Database {
+	t.Helper()
+	opts := dictx.Dict{
+		"database": filepath.Join(t.TempDir(), "test.db"),
+	}
+	engine, err := NewEngine(nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { engine.Close(nil) })
+	return sqldb.NewDatabase(nil, engine, opts)
+}
+
+func testCount(t *testing.T, dbs *sqldb.Session, stmt string) int {
+	t.Helper()
+	rows, err := dbs.Fetch(stmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _ := sqldb.Row(rows[0]).Int64("n")
+	return int(n)
+}
+
+func TestIsDuplicateErr(t *testing.T) {
+	db := testDatabase(t)
+	dbs := db.Session()
+	engine := &Engine{}
+	for _, stmt := range []string{
+		"CREATE TABLE t (id INTEGER PRIMARY KEY, a TEXT UNIQUE);",
+		"CREATE INDEX ix_t_a ON t (a);",
+		"CREATE VIEW v AS SELECT * FROM t;",
+		"INSERT INTO t (id, a) VALUES (1, 'a');",
+	} {
+		if _, err := dbs.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		stmt      string
+		duplicate bool
+	}{
+		{"CREATE TABLE t (id INTEGER);", true},
+		{"CREATE INDEX ix_t_a ON t (a);", true},
+		{"CREATE VIEW v AS SELECT 1;", true},
+		{"ALTER TABLE t ADD COLUMN a TEXT;", true},
+		// other errors of the generic code are not classified
+		{"CREATE TABLE x (id INTEGER", false},
+		{"SELECT * FROM missing;", false},
+		{"INSERT INTO t (id, a) VALUES (2, 'a');", false},
+	}
+	for _, tt := range tests {
+		_, err := dbs.Exec(tt.stmt)
+		if err == nil {
+			t.Fatalf("%s: no error", tt.stmt)
+		}
+		if engine.IsDuplicateErr(err) != tt.duplicate {
+			t.Errorf("%s: IsDuplicateErr(%v) = %v", tt.stmt, err, !tt.duplicate)
+		}
+	}
+}
+
+func TestIsMissingTableErr(t *testing.T) {
+	db := testDatabase(t)
+	dbs := db.Session()
+	engine := &Engine{}
+	if _, err := dbs.Exec("CREATE TABLE t (id INTEGER);"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		stmt    string
+		missing bool
+	}{
+		{"SELECT * FROM missing;", true},
+		{"INSERT INTO missing (id) VALUES (1);", true},
+		{"DROP TABLE missing;", true},
+		{"SELECT missing FROM t;", false},
+		{"CREATE TABLE t (id INTEGER);", false},
+	}
+	for _, tt := range tests {
+		_, err := dbs.Exec(tt.stmt)
+		if err == nil {
+			t.Fatalf("%s: no error", tt.stmt)
+		}
+		if engine.IsMissingTableErr(err) != tt.missing {
+			t.Errorf("%s: IsMissingTableErr(%v) = %v", tt.stmt, err, !tt.missing)
+		}
+	}
+}
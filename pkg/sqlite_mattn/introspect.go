@@ -0,0 +1,199 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqlitedb
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+// list tables query using pragma_table_list, available in SQLite 3.37+
+const listTablesSQL = "SELECT name FROM pragma_table_list " +
+	"WHERE schema='main' AND type='table' " +
+	"AND name NOT LIKE 'sqlite_%' ORDER BY name;"
+
+// list tables query for older SQLite versions, where the shadow tables
+// are matched by the virtual tables names prefix.
+const listTablesCompatSQL = "SELECT name FROM sqlite_master AS t " +
+	"WHERE type='table' AND name NOT LIKE 'sqlite_%' " +
+	"AND sql NOT LIKE 'CREATE VIRTUAL TABLE%' " +
+	"AND NOT EXISTS (SELECT 1 FROM sqlite_master AS v " +
+	"WHERE v.type='table' AND v.sql LIKE 'CREATE VIRTUAL TABLE%' " +
+	"AND t.name LIKE v.name || '\\_%' ESCAPE '\\') ORDER BY name;"
+
+// ListTables returns the database tables names. the internal tables
+// and the virtual tables with their shadow tables are not listed.
+// for SQLite versions before 3.37, the tables are listed from
+// sqlite_master where the tables named with a virtual table name
+// prefix are considered its shadow tables.
+func (e *Engine) ListTables(dbs *sqldb.Session) ([]string, error) {
+	rows, err := dbs.Fetch(listTablesSQL)
+	if err != nil && e.IsMissingTableErr(err) {
+		rows, err = dbs.Fetch(listTablesCompatSQL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := []string{}
+	for _, r := range rows {
+		name, err := sqldb.Row(r).String("name")
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, name)
+	}
+	return result, nil
+}
+
+// DescribeTable returns the table metainfo from database.
+func (e *Engine) DescribeTable(
+	dbs *sqldb.Session, tablename string) (*sqldb.TableMeta, error) {
+	rows, err := dbs.Fetch(
+		"SELECT * FROM pragma_table_info(?) ORDER BY cid;", tablename)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w - %s", sqldb.ErrNoTable, tablename)
+	}
+
+	columns := []sqldb.ColumnMeta{}
+	pkeys := map[int64]string{}
+	for _, r := range rows {
+		row := sqldb.Row(r)
+		c := sqldb.ColumnMeta{}
+		c.Name, _ = row.String("name")
+		c.Type, _ = row.String("type")
+		if notnull, _ := row.Bool("notnull"); notnull {
+			c.Type += " NOT NULL"
+		}
+		if dflt, err := row.String("dflt_value"); err == nil {
+			c.Type += " DEFAULT " + dflt
+		}
+		if pk, _ := row.Int64("pk"); pk > 0 {
+			pkeys[pk] = c.Name
+		}
+		columns = append(columns, c)
+	}
+
+	// the autoincrement rowid primary key is declared in column type
+	if len(pkeys) == 1 {
+		rows, err := dbs.Fetch(
+			"SELECT count(*) AS n FROM sqlite_master "+
+				"WHERE type='table' AND name=? AND sql LIKE '%AUTOINCREMENT%';",
+			tablename)
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := sqldb.Row(rows[0]).Int64("n"); n > 0 {
+			for i := range columns {
+				if columns[i].Name == pkeys[1] {
+					columns[i].Type += " PRIMARY KEY AUTOINCREMENT"
+				}
+			}
+		}
+	}
+
+	// primary key from columns, as rowid primary keys have no index
+	indexes := []sqldb.IndexMeta{}
+	if len(pkeys) > 0 {
+		pk := sqldb.IndexMeta{Primary: true, Unique: true}
+		for i := int64(1); i <= int64(len(pkeys)); i++ {
+			pk.Columns = append(pk.Columns, pkeys[i])
+		}
+		indexes = append(indexes, pk)
+	}
+	ixs, err := e.ListIndexes(dbs, tablename)
+	if err != nil {
+		return nil, err
+	}
+	for _, ix := range ixs {
+		if !ix.Primary {
+			indexes = append(indexes, ix)
+		}
+	}
+
+	// foreign keys
+	rows, err = dbs.Fetch(
+		"SELECT * FROM pragma_foreign_key_list(?) ORDER BY id, seq;",
+		tablename)
+	if err != nil {
+		return nil, err
+	}
+	fkeys := map[int64]*sqldb.ForeignKeyMeta{}
+	ids := []int64{}
+	for _, r := range rows {
+		row := sqldb.Row(r)
+		id, _ := row.Int64("id")
+		fk, ok := fkeys[id]
+		if !ok {
+			fk = &sqldb.ForeignKeyMeta{}
+			fk.RefTable, _ = row.String("table")
+			fk.OnUpdate, _ = row.String("on_update")
+			fk.OnDelete, _ = row.String("on_delete")
+			fkeys[id] = fk
+			ids = append(ids, id)
+		}
+		from, _ := row.String("from")
+		to, _ := row.String("to")
+		fk.Columns = append(fk.Columns, from)
+		fk.RefColumns = append(fk.RefColumns, to)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	foreignkeys := []sqldb.ForeignKeyMeta{}
+	for _, id := range ids {
+		foreignkeys = append(foreignkeys, *fkeys[id])
+	}
+
+	return sqldb.NewTableMeta(columns, indexes, nil, foreignkeys), nil
+}
+
+// ListIndexes returns the table indexes. the primary key index is not
+// listed for tables with integer rowid primary key.
+func (e *Engine) ListIndexes(
+	dbs *sqldb.Session, tablename string) ([]sqldb.IndexMeta, error) {
+	rows, err := dbs.Fetch(
+		"SELECT * FROM pragma_index_list(?) ORDER BY name;", tablename)
+	if err != nil {
+		return nil, err
+	}
+	result := []sqldb.IndexMeta{}
+	for _, r := range rows {
+		row := sqldb.Row(r)
+		ix := sqldb.IndexMeta{}
+		ix.Name, _ = row.String("name")
+		ix.Unique, _ = row.Bool("unique")
+		if origin, _ := row.String("origin"); origin == "pk" {
+			ix.Primary = true
+		}
+
+		cols, err := dbs.Fetch(
+			"SELECT name FROM pragma_index_info(?) ORDER BY seqno;", ix.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cols {
+			if name, err := sqldb.Row(c).String("name"); err == nil {
+				ix.Columns = append(ix.Columns, name)
+			}
+		}
+		result = append(result, ix)
+	}
+	return result, nil
+}
+
+// ServerVersion returns the database server version.
+func (e *Engine) ServerVersion(dbs *sqldb.Session) (string, error) {
+	rows, err := dbs.Fetch("SELECT sqlite_version() AS version;")
+	if err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return "", fmt.Errorf("%w - invalid query result", sqldb.ErrOperation)
+	}
+	return sqldb.Row(rows[0]).String("version")
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqlitedb
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+// creates the introspection test schema
+func introspectSchema(t *testing.T, dbs *sqldb.Session) {
+	t.Helper()
+	for _, stmt := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+			"name TEXT NOT NULL, active BOOLEAN DEFAULT 1);",
+		"CREATE UNIQUE INDEX uix_users_name ON users (name);",
+		"CREATE TABLE roles (user_id INTEGER, role TEXT, " +
+			"PRIMARY KEY (user_id, role), " +
+			"FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE);",
+		"CREATE INDEX ix_roles_role ON roles (role, user_id);",
+		"CREATE TABLE docs_archive (id INTEGER);",
+		"CREATE VIRTUAL TABLE docs USING fts4 (body);",
+		"CREATE VIEW v_users AS SELECT * FROM users;",
+	} {
+		if _, err := dbs.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestListTables(t *testing.T) {
+	db := testDatabase(t)
+	dbs := db.Session()
+	introspectSchema(t, dbs)
+	engine := &Engine{}
+
+	tables, err := engine.ListTables(dbs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"docs_archive", "roles", "users"}
+	if !reflect.DeepEqual(tables, want) {
+		t.Errorf("ListTables() = %v, want %v", tables, want)
+	}
+
+	// missing pragma functions in older versions fall back to compat query
+	_, err = dbs.Fetch("SELECT name FROM pragma_undefined_list;")
+	if !engine.IsMissingTableErr(err) {
+		t.Errorf("missing pragma function error = %v", err)
+	}
+
+	// the compat query excludes the shadow tables by prefix
+	rows, err := dbs.Fetch(listTablesCompatSQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables = []string{}
+	for _, r := range rows {
+		name, _ := sqldb.Row(r).String("name")
+		tables = append(tables, name)
+	}
+	want = []string{"roles", "users"}
+	if !reflect.DeepEqual(tables, want) {
+		t.Errorf("compat tables = %v, want %v", tables, want)
+	}
+}
+
+func TestDescribeTable(t *testing.T) {
+	db := testDatabase(t)
+	dbs := db.Session()
+	introspectSchema(t, dbs)
+	engine := &Engine{}
+
+	meta, err := engine.DescribeTable(dbs, "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := []string{}
+	for _, c := range meta.Columns {
+		types = append(types, c.Name+" "+c.Type)
+	}
+	want := []string{
+		"id INTEGER PRIMARY KEY AUTOINCREMENT",
+		"name TEXT NOT NULL",
+		"active BOOLEAN DEFAULT 1",
+	}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("columns = %q, want %q", types, want)
+	}
+	if c := meta.Columns; !c[0].Primary || !c[1].Unique || len(meta.Indexes) != 0 {
+		t.Errorf("columns = %+v, indexes = %+v", c, meta.Indexes)
+	}
+
+	meta, err = engine.DescribeTable(dbs, "roles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meta.Constraints) != 1 ||
+		meta.Constraints[0].Definition != "PRIMARY KEY (user_id, role)" {
+		t.Errorf("constraints = %+v", meta.Constraints)
+	}
+	if len(meta.Indexes) != 1 || meta.Indexes[0].Name != "ix_roles_role" ||
+		!reflect.DeepEqual(meta.Indexes[0].Columns, []string{"role", "user_id"}) {
+		t.Errorf("indexes = %+v", meta.Indexes)
+	}
+	fk := sqldb.ForeignKeyMeta{Columns: []string{"user_id"},
+		RefTable: "users", RefColumns: []string{"id"},
+		OnDelete: "CASCADE"}
+	if len(meta.ForeignKeys) != 1 || !reflect.DeepEqual(meta.ForeignKeys[0], fk) {
+		t.Errorf("foreign keys = %+v", meta.ForeignKeys)
+	}
+
+	if _, err := engine.DescribeTable(dbs, "missing"); !errors.Is(err, sqldb.ErrNoTable) {
+		t.Errorf("missing table error = %v", err)
+	}
+}
+
+func TestListIndexes(t *testing.T) {
+	db := testDatabase(t)
+	dbs := db.Session()
+	introspectSchema(t, dbs)
+	engine := &Engine{}
+
+	// the composite primary key has an index
+	indexes, err := engine.ListIndexes(dbs, "roles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, ix := range indexes {
+		names = append(names, ix.Name)
+		if strings.HasPrefix(ix.Name, "sqlite_autoindex") && !ix.Primary {
+			t.Errorf("primary key index %+v", ix)
+		}
+	}
+	if len(names) != 2 || names[0] != "ix_roles_role" {
+		t.Errorf("indexes = %v", names)
+	}
+
+	version, err := engine.ServerVersion(dbs)
+	if err != nil || !strings.HasPrefix(version, "3.") {
+		t.Errorf("ServerVersion() = %s, %v", version, err)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+// WriterDB waits in FIFO order to acquire the writer driver handler until
+// ctx is done. the writer handler holds one connection, where the writes
+// are serialized without busy retries. it returns nil handler if the
+// single writer mode is not enabled or the database is read-only.
+func (e *Engine) WriterDB(ctx context.Context) (*sql.DB, error) {
+	if e.cfg == nil {
+		return nil, sqldb.ErrDBConfig
+	}
+	if !e.cfg.SingleWriter || e.cfg.ReadOnly || e.cfg.Immutable {
+		return nil, nil
+	}
+	if err := e.wq.Acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	e.muState.Lock()
+	defer e.muState.Unlock()
+
+	// create new writer driver handler
+	if e.wdb == nil {
+		dsn := e.cfg.writer_dsn()
+		if e.Log != nil {
+			e.Log.Trace("Open writer SqlDB: %s", dsn)
+		}
+		wdb, err := e.open(dsn)
+		if err != nil {
+			e.wq.Release()
+			return nil, err
+		}
+		wdb.SetMaxOpenConns(1)
+		wdb.SetMaxIdleConns(1)
+		e.wdb = wdb
+	}
+
+	return e.wdb, nil
+}
+
+// ReleaseWriter frees the writer driver handler for the next writer.
+func (e *Engine) ReleaseWriter(_ *sql.DB) {
+	e.wq.Release()
+}
+
+// WriterStats returns the writers queue statistics.
+func (e *Engine) WriterStats() sqldb.WriteQueueStats {
+	return e.wq.Stats()
+}
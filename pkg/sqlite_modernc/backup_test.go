@@ -0,0 +1,174 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqlitedb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/exonlabs/go-utils/pkg/abc/dictx"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+// creates the backup test database with rows filling many pages
+func backupDatabase(t *testing.T, journal string) (*sqldb.Database, *Engine) {
+	t.Helper()
+	opts := dictx.Dict{
+		"database":     filepath.Join(t.TempDir(), "test.db"),
+		"journal_mode": journal,
+		"busy_timeout": 5000,
+	}
+	engine, err := NewEngine(nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { engine.Close(nil) })
+	db := sqldb.NewDatabase(nil, engine, opts)
+	dbs := db.Session()
+	if _, err := dbs.Exec("CREATE TABLE t (id INTEGER PRIMARY KEY, " +
+		"data TEXT);"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.Exec("WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL " +
+		"SELECT i+1 FROM n WHERE i < 2000) INSERT INTO t (data) " +
+		"SELECT printf('%.500c', 'x') FROM n;"); err != nil {
+		t.Fatal(err)
+	}
+	return db, engine
+}
+
+// returns the rows count and integrity check result of database file
+func backupCheck(t *testing.T, path string) (int, string) {
+	t.Helper()
+	engine, err := NewEngine(nil, dictx.Dict{"database": path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close(nil)
+	dbs := sqldb.NewDatabase(nil, engine, nil).Session()
+	n := testCount(t, dbs, "SELECT count(*) AS n FROM t;")
+	rows, err := dbs.Fetch("PRAGMA integrity_check;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check, _ := sqldb.Row(rows[0]).String("integrity_check")
+	return n, check
+}
+
+func TestBackupConcurrentWrites(t *testing.T) {
+	for _, journal := range []string{"WAL", "DELETE"} {
+		t.Run(journal, func(t *testing.T) {
+			db, engine := backupDatabase(t, journal)
+
+			// writes from other connections during backup steps
+			ctx, cancel := context.WithCancel(context.Background())
+			wg := sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				dbs := db.Session()
+				for ctx.Err() == nil {
+					dbs.Exec("INSERT INTO t (data) VALUES ('y');")
+					time.Sleep(2 * time.Millisecond)
+				}
+			}()
+
+			steps, total, restarts, last := 0, 0, 0, -1
+			path := filepath.Join(t.TempDir(), "backup.db")
+			err := engine.BackupWith(context.Background(), path,
+				&BackupOptions{Pages: 20, Interval: time.Millisecond,
+					Progress: func(remaining, n int) {
+						if remaining > n {
+							t.Errorf("remaining %d > total %d", remaining, n)
+						}
+						// the remaining pages are not decreased on backup
+						// restart, forced by a write between the first steps
+						if last >= 0 && remaining >= last {
+							restarts++
+						} else if last < 0 {
+							if _, err := db.Session().Exec(
+								"INSERT INTO t (data) VALUES ('z');"); err != nil {
+								t.Error(err)
+							}
+						}
+						steps, total, last = steps+1, n, remaining
+					}})
+			cancel()
+			wg.Wait()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if steps < 2 || total == 0 || restarts == 0 {
+				t.Errorf("progress steps %d, total %d, restarts %d",
+					steps, total, restarts)
+			}
+			n, check := backupCheck(t, path)
+			if n < 2000 || check != "ok" {
+				t.Errorf("backup rows %d, integrity %s", n, check)
+			}
+			if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+				t.Errorf("temporary backup file not removed")
+			}
+		})
+	}
+}
+
+func TestBackupCancel(t *testing.T) {
+	_, engine := backupDatabase(t, "WAL")
+	ctx, cancel := context.WithCancel(context.Background())
+	path := filepath.Join(t.TempDir(), "backup.db")
+	err := engine.BackupWith(ctx, path, &BackupOptions{Pages: 1,
+		Progress: func(remaining, total int) { cancel() }})
+	if err != sqldb.ErrBreak {
+		t.Errorf("cancelled backup error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("partial backup file created")
+	}
+}
+
+func TestSnapshotRetention(t *testing.T) {
+	_, engine := backupDatabase(t, "WAL")
+	dir := t.TempDir()
+	other := filepath.Join(dir, "other.db")
+	if err := os.WriteFile(other, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := []string{}
+	for i := 0; i < 4; i++ {
+		path, err := engine.Snapshot(context.Background(), dir, 2, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(filepath.Base(path), "test-") {
+			t.Errorf("snapshot name %s", path)
+		}
+		paths = append(paths, filepath.Base(path))
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	want := []string{"other.db", paths[2], paths[3]}
+	sort.Strings(want)
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("snapshot files %v, want %v", names, want)
+	}
+}
@@ -6,6 +6,9 @@ package sqlitedb
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
 	"strings"
 	"sync"
 
@@ -26,6 +29,9 @@ type Engine struct {
 	cfg *Config
 	// driver handler
 	sdb *sql.DB
+	// writer driver handler and writers queue
+	wdb *sql.DB
+	wq  sqldb.WriteQueue
 
 	// muState defines mutex for state change operations (open/close).
 	muState sync.Mutex
@@ -48,7 +54,8 @@ func (e *Engine) Backend() string {
 	return "sqlite"
 }
 
-// SqlDB create or return existing backend driver handler.
+// SqlDB create or return existing backend driver handler. the configured
+// pragmas values are verified on open.
 func (e *Engine) SqlDB() (*sql.DB, error) {
 	if e.cfg == nil {
 		return nil, sqldb.ErrDBConfig
@@ -63,7 +70,7 @@ func (e *Engine) SqlDB() (*sql.DB, error) {
 		if e.Log != nil {
 			e.Log.Trace("Open SqlDB: %s", dsn)
 		}
-		sdb, err := sql.Open("sqlite", dsn)
+		sdb, err := e.open(dsn)
 		if err != nil {
 			return nil, err
 		}
@@ -73,6 +80,19 @@ func (e *Engine) SqlDB() (*sql.DB, error) {
 	return e.sdb, nil
 }
 
+// opens new driver handler and verifies the configured pragmas values
+func (e *Engine) open(dsn string) (*sql.DB, error) {
+	sdb, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.cfg.verify(sdb); err != nil {
+		sdb.Close()
+		return nil, err
+	}
+	return sdb, nil
+}
+
 // Release frees the backend driver resources between sessions.
 func (e *Engine) Release(_ *sql.DB) error {
 	// nothing to do
@@ -93,6 +113,15 @@ func (e *Engine) Close(_ *sql.DB) error {
 		}
 		e.sdb = nil
 	}
+	if e.wdb != nil {
+		if e.Log != nil {
+			e.Log.Trace("Close writer SqlDB")
+		}
+		if err := e.wdb.Close(); err != nil {
+			return err
+		}
+		e.wdb = nil
+	}
 
 	return nil
 }
@@ -109,24 +138,343 @@ func (e *Engine) CanRetryErr(err error) bool {
 	return false
 }
 
+// sqlite library messages of creating already existing objects, which are
+// fixed english messages as sqlite does not localize errors.
+var duplicateRegex = regexp.MustCompile(
+	`\b(table|index|view|trigger) \S+ already exists\b|` +
+		`\bduplicate column name: `)
+
+// sqlite library message of accessing not existing table.
+var missingTableRegex = regexp.MustCompile(`\bno such table: `)
+
+// IsDuplicateErr checks weather an operation error is for creating
+// an already existing database object. sqlite is the exception of the
+// backends, as it reports these errors using the generic SQLITE_ERROR
+// code without extended codes, so the error is classified by the code
+// and the object message of the library.
+func (e *Engine) IsDuplicateErr(err error) bool {
+	var serr *sqlite.Error
+	if errors.As(err, &serr) && serr.Code()&0xff == sqlite3.SQLITE_ERROR {
+		return duplicateRegex.MatchString(serr.Error())
+	}
+	return false
+}
+
+// IsNotSupportedErr checks weather an operation error is caused by a
+// sqlite module not available in the library build.
+func (e *Engine) IsNotSupportedErr(err error) bool {
+	var serr *sqlite.Error
+	if errors.As(err, &serr) && serr.Code()&0xff == sqlite3.SQLITE_ERROR {
+		return strings.Contains(serr.Error(), "no such module")
+	}
+	return false
+}
+
+// IsMissingTableErr checks weather an operation error is for accessing
+// a not existing table, classified by the generic SQLITE_ERROR code and
+// the fixed library message as for duplicate errors.
+func (e *Engine) IsMissingTableErr(err error) bool {
+	var serr *sqlite.Error
+	if errors.As(err, &serr) && serr.Code()&0xff == sqlite3.SQLITE_ERROR {
+		return missingTableRegex.MatchString(serr.Error())
+	}
+	return false
+}
+
+// TransactionalDDL checks weather the table schema statments can run
+// within a transaction and be rolled back on failure.
+func (e *Engine) TransactionalDDL(meta *sqldb.TableMeta) bool {
+	return true
+}
+
+// Types defines the sqlite native types of portable column kinds.
+var Types = &sqldb.TypeMap{
+	Types: map[sqldb.Kind]string{
+		sqldb.KindJSON:    "TEXT",
+		sqldb.KindString:  "VARCHAR",
+		sqldb.KindText:    "TEXT",
+		sqldb.KindInt:     "INTEGER",
+		sqldb.KindBigInt:  "BIGINT",
+		sqldb.KindBool:    "BOOLEAN",
+		sqldb.KindFloat:   "REAL",
+		sqldb.KindDecimal: "DECIMAL",
+		sqldb.KindTime:    "DATETIME",
+		sqldb.KindDate:    "DATE",
+		sqldb.KindBlob:    "BLOB",
+		sqldb.KindUUID:    "CHAR(36)",
+	},
+	True:          "TRUE",
+	False:         "FALSE",
+	AutoIncrement: "PRIMARY KEY AUTOINCREMENT",
+}
+
 // SqlGenerator represents sqlite SQL statment generator.
 type SqlGenerator struct {
 	sqldb.StdSqlGenerator
+
+	// the engine foreign keys enforcement is disabled
+	noForeignKeys bool
+}
+
+// ColumnType generates the column type definition, where the auto
+// increment columns are declared as INTEGER PRIMARY KEY AUTOINCREMENT.
+func (g *SqlGenerator) ColumnType(c *sqldb.ColumnMeta) string {
+	switch c.Kind {
+	case sqldb.KindJSON:
+		return Types.ColumnType(c) +
+			fmt.Sprintf(" CHECK (json_valid(%s))", c.Name)
+	case sqldb.KindBigInt:
+		if c.AutoIncrement {
+			col := *c
+			col.Kind = sqldb.KindInt
+			return Types.ColumnType(&col)
+		}
+	}
+	return Types.ColumnType(c)
+}
+
+// FullTextMatch generates the full-text search filter expression using
+// the table FTS5 index.
+func (*SqlGenerator) FullTextMatch(tablename string, meta *sqldb.TableMeta,
+	columns []string, text string) (string, []any) {
+	terms := []string{}
+	for _, t := range sqldb.SearchTerms(text) {
+		terms = append(terms, `"`+strings.ReplaceAll(t, `"`, `""`)+`"`)
+	}
+	query := fmt.Sprintf("{%s} : (%s)",
+		strings.Join(columns, " "), strings.Join(terms, " "))
+
+	stmt := fmt.Sprintf(
+		"%s IN (SELECT fts_key FROM %s_fts WHERE %s_fts MATCH %s)",
+		fulltext_key(meta), tablename, tablename, sqldb.SQL_PLACEHOLDER)
+	return stmt, []any{query}
 }
 
 // Schema generates table schema statments from metainfo
 func (g *SqlGenerator) Schema(tablename string, meta *sqldb.TableMeta) []string {
-	stmts := g.StdSqlGenerator.Schema(tablename, meta)
+	stmts := sqldb.GenerateSchema(g, tablename, meta)
 
 	if dictx.Fetch(meta.Args, "sqlite_without_rowid", false) {
 		s := strings.TrimSuffix(strings.TrimSpace(stmts[0]), ";")
 		stmts[0] = s + " WITHOUT ROWID;"
 	}
 
+	if meta.FullText != nil && len(meta.FullText.Columns) > 0 {
+		stmts = append(stmts, fulltext_schema(tablename, meta)...)
+	}
+
+	return stmts
+}
+
+// Index generates the table index creation statment. the included
+// columns are ignored as sqlite doesn't support covering indexes.
+func (*SqlGenerator) Index(
+	tablename string, meta *sqldb.TableMeta, ix *sqldb.IndexMeta) string {
+	index := *ix
+	index.Include = nil
+	return sqldb.GenerateIndex(tablename, meta, &index, 0)
+}
+
+// AddForeignKey returns nil as sqlite doesn't support adding constraints
+// to existing tables. the foreign keys are created within table schema,
+// where the referenced tables are not required to exist.
+func (*SqlGenerator) AddForeignKey(
+	tablename string, fk *sqldb.ForeignKeyMeta) []string {
+	return nil
+}
+
+// AlterSchema generates the statments adding the missing columns. the
+// table is rebuilt for columns that can not be added by ALTER TABLE,
+// which are primary, unique, not null columns without default value and
+// columns with non constant default value.
+//
+// the rebuild follows the sqlite generalized ALTER TABLE procedure, where
+// the new table is created and filled, the old table is dropped and the
+// new table is renamed, then the table indexes and full-text index
+// triggers are recreated and the full-text index is refilled. the other
+// triggers and views referencing the table are not recreated. the rebuild
+// runs with the foreign keys enforcement disabled, see RebuildConn.
+func (g *SqlGenerator) AlterSchema(diff *sqldb.SchemaDiff) ([]string, error) {
+	if !g.needs_rebuild(diff) {
+		return sqldb.GenerateAlterSchema(g, diff)
+	}
+	if len(diff.ExtraColumns) > 0 {
+		return nil, fmt.Errorf(
+			"table rebuild drops columns not defined in model: %s",
+			strings.Join(diff.ExtraColumns, ", "))
+	}
+
+	// create new table from model and copy the existing columns data
+	tmp := diff.Table + "__new"
+	meta := *diff.Meta
+	meta.FullText = nil
+	create := g.Schema(tmp, &meta)[0]
+
+	columns := []string{}
+	for _, c := range meta.Columns {
+		missing := false
+		for _, m := range diff.MissingColumns {
+			if m.Name == c.Name {
+				missing = true
+				break
+			}
+		}
+		if !missing {
+			columns = append(columns, c.Name)
+		}
+	}
+	cols := strings.Join(columns, ", ")
+
+	stmts := []string{
+		fmt.Sprintf("DROP TABLE IF EXISTS %s;", tmp),
+		create,
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s;",
+			tmp, cols, cols, diff.Table),
+		fmt.Sprintf("DROP TABLE %s;", diff.Table),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", tmp, diff.Table),
+	}
+	// recreate the indexes and triggers dropped with the old table
+	stmts = append(stmts, g.Schema(diff.Table, diff.Meta)[1:]...)
+	if diff.Meta.FullText != nil && len(diff.Meta.FullText.Columns) > 0 {
+		stmts = append(stmts,
+			fmt.Sprintf("DELETE FROM %s_fts;", diff.Table),
+			fulltext_fill(diff.Table, diff.Meta))
+	}
+	return stmts, nil
+}
+
+// RebuildConn returns the statments disabling the foreign keys enforcement
+// during the table rebuild, as dropping the old table deletes its rows
+// and runs the referencing tables foreign keys actions. the foreign keys
+// are checked before commit.
+func (g *SqlGenerator) RebuildConn(
+	diff *sqldb.SchemaDiff) (pre, post []string, check string) {
+	if !g.needs_rebuild(diff) || g.noForeignKeys {
+		return nil, nil, ""
+	}
+	return []string{"PRAGMA foreign_keys = OFF;"},
+		[]string{"PRAGMA foreign_keys = ON;"},
+		"PRAGMA foreign_key_check;"
+}
+
+// checks whether the table is rebuilt to add the missing columns, for
+// columns that can not be added by ALTER TABLE.
+func (g *SqlGenerator) needs_rebuild(diff *sqldb.SchemaDiff) bool {
+	for _, c := range diff.MissingColumns {
+		typedef := strings.ToUpper(g.ColumnType(&c))
+		if c.Primary || c.Unique ||
+			(strings.Contains(typedef, "NOT NULL") &&
+				!strings.Contains(typedef, "DEFAULT")) ||
+			strings.Contains(typedef, "DEFAULT (") ||
+			strings.Contains(typedef, "DEFAULT CURRENT_") {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the full-text index key column of table
+func fulltext_key(meta *sqldb.TableMeta) string {
+	if key := sqldb.PrimaryColumn(meta); key != "" {
+		return key
+	}
+	return "rowid"
+}
+
+// generates the FTS5 index table and the sync triggers, where the index
+// table holds a copy of the text columns keyed by the table primary key.
+// the empty index table is filled with the existing table rows, for
+// full-text indexes declared on tables with data.
+func fulltext_schema(tablename string, meta *sqldb.TableMeta) []string {
+	key := fulltext_key(meta)
+	fts := tablename + "_fts"
+	cols := strings.Join(meta.FullText.Columns, ", ")
+	values := []string{}
+	for _, c := range meta.FullText.Columns {
+		values = append(values, "new."+c)
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (fts_key, %s) VALUES (new.%s, %s);",
+		fts, cols, key, strings.Join(values, ", "))
+	remove := fmt.Sprintf("DELETE FROM %s WHERE fts_key=old.%s;", fts, key)
+
+	return []string{
+		fmt.Sprintf(
+			"CREATE VIRTUAL TABLE IF NOT EXISTS %s "+
+				"USING fts5(fts_key UNINDEXED, %s);", fts, cols),
+		fmt.Sprintf(
+			"INSERT INTO %s (fts_key, %s) SELECT %s, %s FROM %s "+
+				"WHERE NOT EXISTS (SELECT 1 FROM %s);",
+			fts, cols, key, cols, tablename, fts),
+		fmt.Sprintf(
+			"CREATE TRIGGER IF NOT EXISTS %s_ai AFTER INSERT ON %s\n"+
+				"BEGIN\n  %s\nEND;", fts, tablename, insert),
+		fmt.Sprintf(
+			"CREATE TRIGGER IF NOT EXISTS %s_ad AFTER DELETE ON %s\n"+
+				"BEGIN\n  %s\nEND;", fts, tablename, remove),
+		fmt.Sprintf(
+			"CREATE TRIGGER IF NOT EXISTS %s_au AFTER UPDATE ON %s\n"+
+				"BEGIN\n  %s\n  %s\nEND;", fts, tablename, remove, insert),
+	}
+}
+
+// generates the statment filling the full-text index table with the
+// table rows.
+func fulltext_fill(tablename string, meta *sqldb.TableMeta) string {
+	cols := strings.Join(meta.FullText.Columns, ", ")
+	return fmt.Sprintf("INSERT INTO %s_fts (fts_key, %s) SELECT %s, %s FROM %s;",
+		tablename, cols, fulltext_key(meta), cols, tablename)
+}
+
+// DropSchema generates the statments dropping table schema and its
+// full-text index table if exists.
+func (*SqlGenerator) DropSchema(tablename string, meta *sqldb.TableMeta) []string {
+	if meta != nil && meta.View != nil {
+		return []string{fmt.Sprintf("DROP VIEW IF EXISTS %s;", tablename)}
+	}
+	stmts := []string{fmt.Sprintf("DROP TABLE IF EXISTS %s;", tablename)}
+	if meta != nil && meta.FullText != nil {
+		stmts = append(stmts,
+			fmt.Sprintf("DROP TABLE IF EXISTS %s_fts;", tablename))
+	}
+	return stmts
+}
+
+// Truncate generates the statments deleting all table rows. the table
+// autoincrement sequence is reset for tables with AUTOINCREMENT column.
+func (g *SqlGenerator) Truncate(tablename string, meta *sqldb.TableMeta) []string {
+	stmts := []string{fmt.Sprintf("DELETE FROM %s;", tablename)}
+	if meta != nil {
+		for i := range meta.Columns {
+			coldef := g.ColumnType(&meta.Columns[i])
+			if strings.Contains(strings.ToUpper(coldef), "AUTOINCREMENT") {
+				stmts = append(stmts, fmt.Sprintf(
+					"DELETE FROM sqlite_sequence WHERE name='%s';", tablename))
+				break
+			}
+		}
+	}
 	return stmts
 }
 
+// ForeignKeyChecks generates the statments deferring the foreign key
+// checks to the transaction commit, as the foreign keys enforcement can
+// not change within transaction. the deferring ends on commit.
+func (*SqlGenerator) ForeignKeyChecks(tablenames []string, enable bool) []string {
+	if enable {
+		return nil
+	}
+	return []string{"PRAGMA defer_foreign_keys = ON;"}
+}
+
 // SqlGenerator returns the engine SQL statment generator.
 func (e *Engine) SqlGenerator() sqldb.SqlGenerator {
-	return &SqlGenerator{}
+	return &SqlGenerator{noForeignKeys: e.cfg != nil && !e.cfg.ForeignKeys}
+}
+
+// registers the backend SQL generator for statments generation without
+// database connection.
+func init() {
+	sqldb.RegisterGenerator("sqlite", func() sqldb.SqlGenerator {
+		return &SqlGenerator{}
+	})
 }
@@ -0,0 +1,245 @@
+// Copyright (c) 2024 ExonLabs, All rights reserved.
+// Use of this source code is governed by a BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package sqlitedb
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/exonlabs/go-utils/pkg/abc/dictx"
+
+	"github.com/exonlabs/go-sqldb/pkg/sqldb"
+)
+
+type testModel struct {
+	sqldb.BaseModel
+	meta *sqldb.TableMeta
+}
+
+func (m *testModel) TableMeta() *sqldb.TableMeta {
+	return m.meta
+}
+
+func testDatabase(t *testing.T) *sqldb.Database {
+	t.Helper()
+	opts := dictx.Dict{
+		"database": filepath.Join(t.TempDir(), "test.db"),
+	}
+	engine, err := NewEngine(nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { engine.Close(nil) })
+	return sqldb.NewDatabase(nil, engine, opts)
+}
+
+func testCount(t *testing.T, dbs *sqldb.Session, stmt string) int {
+	t.Helper()
+	rows, err := dbs.Fetch(stmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _ := sqldb.Row(rows[0]).Int64("n")
+	return int(n)
+}
+
+func parentMeta(columns ...sqldb.ColumnMeta) *sqldb.TableMeta {
+	return &sqldb.TableMeta{
+		Columns: append([]sqldb.ColumnMeta{
+			{Name: "id", Type: "INTEGER", Primary: true},
+			{Name: "name", Type: "TEXT", Index: true},
+		}, columns...),
+		FullText: &sqldb.FullTextMeta{Columns: []string{"name"}},
+	}
+}
+
+func TestAlterSchemaRebuild(t *testing.T) {
+	for _, action := range []string{"CASCADE", "RESTRICT"} {
+		t.Run(action, func(t *testing.T) {
+			db := testDatabase(t)
+			child := &testModel{meta: &sqldb.TableMeta{
+				Columns: []sqldb.ColumnMeta{
+					{Name: "id", Type: "INTEGER", Primary: true},
+					{Name: "t_id", Type: "INTEGER"},
+				},
+				ForeignKeys: []sqldb.ForeignKeyMeta{{
+					Columns: []string{"t_id"}, RefTable: "t",
+					RefColumns: []string{"id"}, OnDelete: action,
+				}},
+			}}
+			err := sqldb.InitializeModels(db, []sqldb.ModelMeta{
+				{Table: "t", Model: &testModel{meta: parentMeta()}},
+				{Table: "c", Model: child},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			dbs := db.Session()
+			for _, stmt := range []string{
+				"INSERT INTO t (id, name) VALUES (1, 'alpha');",
+				"INSERT INTO c (id, t_id) VALUES (1, 1);",
+			} {
+				if _, err := dbs.Exec(stmt); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// the unique column requires table rebuild
+			meta := parentMeta(
+				sqldb.ColumnMeta{Name: "code", Type: "VARCHAR(8)", Unique: true})
+			diff, err := sqldb.DiffTable(dbs, "t", meta)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := sqldb.ApplyDiff(db, []*sqldb.SchemaDiff{diff}); err != nil {
+				t.Fatal(err)
+			}
+
+			if n := testCount(t, dbs, "SELECT count(*) AS n FROM t;"); n != 1 {
+				t.Errorf("parent rows = %d, want 1", n)
+			}
+			if n := testCount(t, dbs, "SELECT count(*) AS n FROM c;"); n != 1 {
+				t.Errorf("child rows = %d, want 1", n)
+			}
+			if n := testCount(t, dbs, "SELECT count(*) AS n FROM sqlite_master "+
+				"WHERE type='index' AND name='ix_t_name';"); n != 1 {
+				t.Errorf("index ix_t_name not recreated")
+			}
+			if n := testCount(t, dbs, "SELECT count(*) AS n FROM sqlite_master "+
+				"WHERE type='trigger' AND tbl_name='t';"); n != 3 {
+				t.Errorf("full-text triggers = %d, want 3", n)
+			}
+			if n := testCount(t, dbs, "SELECT foreign_keys AS n "+
+				"FROM pragma_foreign_keys;"); n != 1 {
+				t.Errorf("foreign keys enforcement not restored")
+			}
+
+			// the full-text index is refilled and synced by triggers
+			if _, err := dbs.Exec(
+				"INSERT INTO t (id, name) VALUES (2, 'beta');"); err != nil {
+				t.Fatal(err)
+			}
+			for _, word := range []string{"alpha", "beta"} {
+				n := testCount(t, dbs, "SELECT count(*) AS n FROM t_fts "+
+					"WHERE t_fts MATCH '"+word+"';")
+				if n != 1 {
+					t.Errorf("full-text matches of %s = %d, want 1", word, n)
+				}
+			}
+
+			diff, err = sqldb.DiffTable(dbs, "t", meta)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !diff.IsEmpty() {
+				t.Errorf("diff after rebuild: %s", diff)
+			}
+		})
+	}
+}
+
+func TestFullTextBackfill(t *testing.T) {
+	db := testDatabase(t)
+	dbs := db.Session()
+	for _, stmt := range []string{
+		"CREATE TABLE t (id INTEGER, name TEXT, PRIMARY KEY (id));",
+		"INSERT INTO t (id, name) VALUES (1, 'alpha'), (2, 'beta');",
+	} {
+		if _, err := dbs.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the full-text index declared on existing table is filled once
+	for range 2 {
+		err := sqldb.InitializeModels(db, []sqldb.ModelMeta{
+			{Table: "t", Model: &testModel{meta: parentMeta()}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := testCount(t, dbs, "SELECT count(*) AS n FROM t_fts;"); n != 2 {
+		t.Errorf("full-text rows = %d, want 2", n)
+	}
+	if n := testCount(t, dbs, "SELECT count(*) AS n FROM t_fts "+
+		"WHERE t_fts MATCH 'beta';"); n != 1 {
+		t.Errorf("full-text matches = %d, want 1", n)
+	}
+}
+
+func TestNotSupportedErr(t *testing.T) {
+	dbs := testDatabase(t).Session()
+	_, err := dbs.Exec("CREATE VIRTUAL TABLE v USING nosuchmodule(a);")
+	if !errors.Is(err, sqldb.ErrNotSupported) {
+		t.Errorf("error = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestIsDuplicateErr(t *testing.T) {
+	db := testDatabase(t)
+	dbs := db.Session()
+	engine := &Engine{}
+	for _, stmt := range []string{
+		"CREATE TABLE t (id INTEGER PRIMARY KEY, a TEXT UNIQUE);",
+		"CREATE INDEX ix_t_a ON t (a);",
+		"CREATE VIEW v AS SELECT * FROM t;",
+		"INSERT INTO t (id, a) VALUES (1, 'a');",
+	} {
+		if _, err := dbs.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		stmt      string
+		duplicate bool
+	}{
+		{"CREATE TABLE t (id INTEGER);", true},
+		{"CREATE INDEX ix_t_a ON t (a);", true},
+		{"CREATE VIEW v AS SELECT 1;", true},
+		{"ALTER TABLE t ADD COLUMN a TEXT;", true},
+		// other errors of the generic code are not classified
+		{"CREATE TABLE x (id INTEGER", false},
+		{"SELECT * FROM missing;", false},
+		{"INSERT INTO t (id, a) VALUES (2, 'a');", false},
+	}
+	for _, tt := range tests {
+		_, err := dbs.Exec(tt.stmt)
+		if err == nil {
+			t.Fatalf("%s: no error", tt.stmt)
+		}
+		if engine.IsDuplicateErr(err) != tt.duplicate {
+			t.Errorf("%s: IsDuplicateErr(%v) = %v", tt.stmt, err, !tt.duplicate)
+		}
+	}
+}
+
+func TestIsMissingTableErr(t *testing.T) {
+	db := testDatabase(t)
+	dbs := db.Session()
+	engine := &Engine{}
+	if _, err := dbs.Exec("CREATE TABLE t (id INTEGER);"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		stmt    string
+		missing bool
+	}{
+		{"SELECT * FROM missing;", true},
+		{"INSERT INTO missing (id) VALUES (1);", true},
+		{"DROP TABLE missing;", true},
+		{"SELECT missing FROM t;", false},
+		{"CREATE TABLE t (id INTEGER);", false},
+	}
+	for _, tt := range tests {
+		_, err := dbs.Exec(tt.stmt)
+		if err == nil {
+			t.Fatalf("%s: no error", tt.stmt)
+		}
+		if engine.IsMissingTableErr(err) != tt.missing {
+			t.Errorf("%s: IsMissingTableErr(%v) = %v", tt.stmt, err, !tt.missing)
+		}
+	}
+}
@@ -7,6 +7,9 @@ ARGS=(-trimpath -ldflags='-s -w')
 SRC_PATH=examples
 BUILD_PATH=build/examples
 
+# the sqlite_mattn FTS5 module is enabled by build tag
+CGO_ARGS=(-tags sqlite_fts5)
+
 # GO_BIN env variable allow for building with another go binary
 if [ ! -z "${GO_BIN}" ] ;then
     GO=${GO_BIN}
@@ -55,18 +58,18 @@ function build_cgo {
     out=${BUILD_LINUX_PATH}/${name}
     echo "  - ${out}"
     CGO_ENABLED=1 \
-        ${GO} build -o ${out} "${ARGS[@]}" ${SRC_PATH}/${path}/*.go
+        ${GO} build -o ${out} "${ARGS[@]}" "${CGO_ARGS[@]}" ${SRC_PATH}/${path}/*.go
 
     if [ -z "$3" ] ;then
         out=${BUILD_WIN_PATH}_64/${name}_64.exe
         echo "  - ${out}"
         CC=x86_64-w64-mingw32-gcc CGO_ENABLED=1 GOOS=windows GOARCH=amd64 \
-            ${GO} build -o ${out} "${ARGS[@]}" ${SRC_PATH}/${path}/*.go
+            ${GO} build -o ${out} "${ARGS[@]}" "${CGO_ARGS[@]}" ${SRC_PATH}/${path}/*.go
 
         out=${BUILD_WIN_PATH}_32/${name}_32.exe
         echo "  - ${out}"
         CC=i686-w64-mingw32-gcc CGO_ENABLED=1 GOOS=windows GOARCH=386 \
-            ${GO} build -o ${out} "${ARGS[@]}" ${SRC_PATH}/${path}/*.go
+            ${GO} build -o ${out} "${ARGS[@]}" "${CGO_ARGS[@]}" ${SRC_PATH}/${path}/*.go
     fi
 }
 
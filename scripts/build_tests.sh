@@ -6,6 +6,9 @@ GO=go
 SRC_PATH=./pkg
 BUILD_PATH=build/tests
 
+# the sqlite_mattn FTS5 module is enabled by build tag
+CGO_ARGS=(-tags sqlite_fts5)
+
 # GO_BIN env variable allow for building with another go binary
 if [ ! -z "${GO_BIN}" ] ;then
     GO=${GO_BIN}
@@ -54,18 +57,18 @@ function build_cgo {
     out=${BUILD_LINUX_PATH}/${name}_test
     echo "  - ${out}"
     CGO_ENABLED=1 \
-        ${GO} test ${SRC_PATH}/${path} -c -o ${out}
+        ${GO} test "${CGO_ARGS[@]}" ${SRC_PATH}/${path} -c -o ${out}
 
     if [ -z "$3" ] ;then
         out=${BUILD_WIN_PATH}_64/${name}_test_64.exe
         echo "  - ${out}"
         CC=x86_64-w64-mingw32-gcc CGO_ENABLED=1 GOOS=windows GOARCH=amd64 \
-            ${GO} test ${SRC_PATH}/${path} -c -o ${out}
+            ${GO} test "${CGO_ARGS[@]}" ${SRC_PATH}/${path} -c -o ${out}
 
         out=${BUILD_WIN_PATH}_32/${name}_test_32.exe
         echo "  - ${out}"
         CC=i686-w64-mingw32-gcc CGO_ENABLED=1 GOOS=windows GOARCH=386 \
-            ${GO} test ${SRC_PATH}/${path} -c -o ${out}
+            ${GO} test "${CGO_ARGS[@]}" ${SRC_PATH}/${path} -c -o ${out}
     fi
 }
 